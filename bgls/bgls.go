@@ -4,9 +4,10 @@
 package bgls
 
 import (
+	"context"
 	"crypto/rand"
+	"io"
 	"math/big"
-	"sync"
 
 	. "github.com/orbs-network/bgls/curves" // nolint: golint
 )
@@ -27,7 +28,12 @@ type AggSig struct {
 
 //KeyGen generates a *big.Int and Point2
 func KeyGen(curve CurveSystem) (*big.Int, Point, error) {
-	x, err := rand.Int(rand.Reader, curve.GetG1Order())
+	return KeyGenFromReader(curve, rand.Reader)
+}
+
+//KeyGenFromReader is KeyGen with the secret key read from r
+func KeyGenFromReader(curve CurveSystem, r io.Reader) (*big.Int, Point, error) {
+	x, err := rand.Int(r, curve.GetG1Order())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -37,15 +43,39 @@ func KeyGen(curve CurveSystem) (*big.Int, Point, error) {
 
 //LoadPublicKey turns secret key into a public key of type Point2
 func LoadPublicKey(curve CurveSystem, sk *big.Int) Point {
-	pubKey := curve.GetG2().Mul(sk)
+	pubKey := curve.G2BaseMul(sk)
 	return pubKey
 }
 
+//KeyGenScalar generates a secret key as a Scalar, and its public key in G2
+func KeyGenScalar(curve CurveSystem) (*Scalar, Point, error) {
+	return KeyGenScalarFromReader(curve, rand.Reader)
+}
+
+//KeyGenScalarFromReader is KeyGenScalar with the secret key read from r
+func KeyGenScalarFromReader(curve CurveSystem, r io.Reader) (*Scalar, Point, error) {
+	x, err := RandomScalarFromReader(curve, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, LoadPublicKeyScalar(curve, x), nil
+}
+
+//LoadPublicKeyScalar turns a secret key of curve into a public key of type Point2
+func LoadPublicKeyScalar(curve CurveSystem, sk *Scalar) Point {
+	return LoadPublicKey(curve, sk.BigInt())
+}
+
 // Sign creates a standard BLS signature on a message with a private key
 func Sign(curve CurveSystem, sk *big.Int, msg []byte) Point {
 	return SignCustHash(sk, msg, curve.HashToG1)
 }
 
+// SignScalar is Sign with a secret key of curve
+func SignScalar(curve CurveSystem, sk *Scalar, msg []byte) Point {
+	return Sign(curve, sk.BigInt(), msg)
+}
+
 // SignCustHash creates a standard BLS signature on a message with a private key,
 // using a supplied function to hash onto the curve where signatures lie.
 func SignCustHash(sk *big.Int, msg []byte, hash func([]byte) Point) Point {
@@ -61,11 +91,36 @@ func VerifySingleSignature(curve CurveSystem, sig Point, pubKey Point, msg []byt
 
 // VerifySingleSignatureCustHash checks that a single standard BLS signature is
 // valid, using the supplied hash function to hash onto the curve where signatures lie.
+// The point at infinity is rejected as a public key, since it would verify any
+// signature of infinity.
 func VerifySingleSignatureCustHash(curve CurveSystem, sig Point, pubkey Point,
 	msg []byte, hash func([]byte) Point) bool {
+	pts1, pts2, ok := singleSignaturePairs(curve, sig, pubkey, msg, hash)
+	return ok && curve.PairingCheck(pts1, pts2)
+}
+
+// SingleSignaturePairingCalldata returns the input to the ecPairing precompile
+// which performs the same check as VerifySingleSignature. It returns false if
+// VerifySingleSignature would reject the public key before pairing, or if the
+// curve isn't altbn128.
+func SingleSignaturePairingCalldata(curve CurveSystem, sig Point, pubKey Point, msg []byte) ([]byte, bool) {
+	pts1, pts2, ok := singleSignaturePairs(curve, sig, pubKey, msg, curve.HashToG1)
+	if !ok {
+		return nil, false
+	}
+	return AltbnPairingCalldata(pts1, pts2)
+}
+
+// singleSignaturePairs returns the pairs whose product of pairings is one
+// when sig is a valid signature, e(-H(msg), pubkey) * e(sig, g2). It returns
+// false if pubkey is the point at infinity.
+func singleSignaturePairs(curve CurveSystem, sig Point, pubkey Point,
+	msg []byte, hash func([]byte) Point) ([]Point, []Point, bool) {
+	if pubkey == nil || pubkey.IsInfinity() {
+		return nil, nil, false
+	}
 	h := hash(msg).Mul(new(big.Int).SetInt64(-1))
-	paired, _ := curve.PairingProduct([]Point{h, sig}, []Point{pubkey, curve.GetG2()})
-	return curve.GetGTIdentity().Equals(paired)
+	return []Point{h, sig}, []Point{pubkey, curve.GetG2()}, true
 }
 
 // Verify verifies an aggregate signature type.
@@ -75,13 +130,26 @@ func (a *AggSig) Verify(curve CurveSystem) bool {
 
 // VerifyAggregateSignature verifies that the aggregated signature proves that
 // all messages were signed by the associated keys. This will fail if there are
-// duplicate messages, due to the possibility of the rogue public-key attack.
+// duplicate messages, due to the possibility of the rogue public-key attack,
+// or if one of the keys is the point at infinity.
 // If duplicate messages should be allowed, one of the protections against the
 // rogue public-key attack should be used. See doc.go for more details.
 func VerifyAggregateSignature(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
 	return verifyAggSig(curve, aggsig, keys, msgs, false)
 }
 
+// AggregateSignaturePairingCalldata returns the input to the ecPairing
+// precompile which performs the same check as VerifyAggregateSignature.
+// It returns false if VerifyAggregateSignature would reject the input before
+// pairing, or if the curve isn't altbn128.
+func AggregateSignaturePairingCalldata(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) ([]byte, bool) {
+	pts1, pts2, ok := aggregateSignaturePairs(curve, aggsig, keys, msgs, false)
+	if !ok {
+		return nil, false
+	}
+	return AltbnPairingCalldata(pts1, pts2)
+}
+
 // verifyMultiSignature checks that the aggregate signature correctly proves
 // that a single message has been signed by a set of keys. This is
 // vulnerable to the rogue public attack, so one of the defense mechanisms should be used.
@@ -91,30 +159,41 @@ func verifyMultiSignature(curve CurveSystem, aggsig Point, keys []Point, msg []b
 }
 
 func verifyAggSig(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, allowDuplicates bool) bool {
-	if len(keys) != len(msgs) {
-		return false
+	pts1, pts2, ok := aggregateSignaturePairs(curve, aggsig, keys, msgs, allowDuplicates)
+	return ok && curve.PairingCheck(pts1, pts2)
+}
+
+// aggregateSignaturePairs returns the pairs whose product of pairings is one
+// when aggsig is valid, e(H(m_1), key_1) * ... * e(H(m_n), key_n) * e(-aggsig, g2).
+func aggregateSignaturePairs(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	allowDuplicates bool) ([]Point, []Point, bool) {
+	return aggregateSignaturePairsCustHash(curve, aggsig, keys, msgs, allowDuplicates, curve.HashToG1)
+}
+
+// aggregateSignaturePairsCustHash is aggregateSignaturePairs, with the supplied
+// hash function onto G1. It returns false if the keys and messages don't pair
+// up, if one of the keys is the point at infinity, or if there are duplicate
+// messages and allowDuplicates is false.
+func aggregateSignaturePairsCustHash(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	allowDuplicates bool, hash func([]byte) Point) ([]Point, []Point, bool) {
+	if len(keys) != len(msgs) || containsInfinity(keys) {
+		return nil, nil, false
 	}
 	if !allowDuplicates {
 		if containsDuplicateMessage(msgs) {
-			return false
+			return nil, nil, false
 		}
 	}
 	pts1 := make([]Point, len(keys)+1)
 	pts2 := make([]Point, len(keys)+1)
-	var wg sync.WaitGroup
-	wg.Add(len(msgs))
-	for i := 0; i < len(msgs); i++ {
-		go concurrentHash(curve, i, pts1, msgs[i], &wg)
+	Parallelize(context.Background(), len(msgs), func(i int) error {
+		pts1[i] = hash(msgs[i])
 		pts2[i] = keys[i]
-	}
-	wg.Wait()
+		return nil
+	})
 	pts1[len(keys)] = aggsig.Mul(new(big.Int).SetInt64(-1))
 	pts2[len(keys)] = curve.GetG2()
-	aggPt, ok := curve.PairingProduct(pts1, pts2)
-	if ok {
-		return aggPt.Equals(curve.GetGTIdentity())
-	}
-	return ok
+	return pts1, pts2, true
 }
 
 // AggregateSignatures aggregates an array of signatures into one aggsig.
@@ -129,12 +208,6 @@ func AggregateKeys(keys []Point) Point {
 	return AggregatePoints(keys)
 }
 
-// concurrentHash hashes the message and sends the result down the channel.
-func concurrentHash(curve CurveSystem, i int, pts []Point, msg []byte, wg *sync.WaitGroup) {
-	pts[i] = curve.HashToG1(msg)
-	wg.Done()
-}
-
 func containsDuplicateMessage(msgs [][]byte) bool {
 	hashmap := make(map[string]bool)
 	for i := 0; i < len(msgs); i++ {
@@ -147,3 +220,12 @@ func containsDuplicateMessage(msgs [][]byte) bool {
 	}
 	return false
 }
+
+func containsInfinity(keys []Point) bool {
+	for _, key := range keys {
+		if key == nil || key.IsInfinity() {
+			return true
+		}
+	}
+	return false
+}
@@ -9,11 +9,13 @@ import (
 	"os"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	. "github.com/orbs-network/bgls/curves"
 	"github.com/stretchr/testify/assert"
 )
 
-var curves = []CurveSystem{Altbn128}
+var curves = []CurveSystem{Altbn128, Bls12381}
 var benchmarkCurve = Altbn128
 
 func TestSingleSigner(t *testing.T) {
@@ -34,6 +36,35 @@ func TestSingleSigner(t *testing.T) {
 			"signature verification succeeding when it shouldn't")
 
 		// TODO Add tests to show that this doesn't succeed if d or vk is altered
+
+		// The point at infinity would verify the signature at infinity on any message
+		assert.False(t, VerifySingleSignature(curve, curve.GetG1Infinity(), curve.GetG2Infinity(), d),
+			"Standard BLS signature verification accepted an infinity public key")
+		assert.False(t, VerifyAggregateSignature(curve, curve.GetG1Infinity(),
+			[]Point{curve.GetG2Infinity()}, [][]byte{d}),
+			"Aggregate signature verification accepted an infinity public key")
+		assert.False(t, VerifyAggregateSignature(curve, sig,
+			[]Point{vk, curve.GetG2Infinity()}, [][]byte{d, []byte("other")}),
+			"Aggregate signature verification accepted an infinity public key")
+	}
+}
+
+func TestDeterministicKeyGen(t *testing.T) {
+	msg := []byte("message")
+	for _, curve := range curves {
+		sk1, vk1, err := KeyGenFromReader(curve, NewDeterministicReader([]byte("seed")))
+		assert.Nil(t, err)
+		sk2, vk2, _ := KeyGenFromReader(curve, NewDeterministicReader([]byte("seed")))
+		assert.Equal(t, sk1, sk2, "Keys from the same seed differ")
+		assert.Equal(t, vk1.Marshal(), vk2.Marshal(), "Public keys from the same seed differ")
+		assert.Equal(t, Sign(curve, sk1, msg).Marshal(), Sign(curve, sk2, msg).Marshal(),
+			"Signatures from the same seed differ")
+		sk3, _, _ := KeyGenFromReader(curve, NewDeterministicReader([]byte("seed2")))
+		assert.NotEqual(t, sk1, sk3, "Keys from different seeds are equal")
+
+		skScalar, vkScalar, _ := KeyGenScalarFromReader(curve, NewDeterministicReader([]byte("seed")))
+		assert.Equal(t, sk1, skScalar.BigInt())
+		assert.True(t, vk1.Equals(vkScalar))
 	}
 }
 
@@ -76,6 +107,28 @@ func TestAggregation(t *testing.T) {
 	}
 }
 
+func TestSingleSignerCustHash(t *testing.T) {
+	hash := AltbnHashToG1WithDST([]byte("BGLS-TEST-V01-CS01-with-BN254G1_XMD:SHA-256_SVDW_RO_"))
+	otherHash := AltbnHashToG1WithDST([]byte("BGLS-TEST-V01-CS02-with-BN254G1_XMD:SHA-256_SVDW_RO_"))
+	sk, vk, _ := KeyGen(Altbn128)
+	msg := make([]byte, 64)
+	rand.Read(msg)
+	sig := SignCustHash(sk, msg, hash)
+	assert.True(t, VerifySingleSignatureCustHash(Altbn128, sig, vk, msg, hash),
+		"signature verification failed")
+	assert.False(t, VerifySingleSignatureCustHash(Altbn128, sig, vk, msg, otherHash),
+		"signature verification succeeding under a different DST")
+	for _, curve := range curves {
+		hash = HashToG1FouqueTibouchiWithDST(curve, []byte("BGLS-TEST-FT"))
+		sk, vk, _ = KeyGen(curve)
+		sig = SignCustHash(sk, msg, hash)
+		assert.True(t, VerifySingleSignatureCustHash(curve, sig, vk, msg, hash),
+			"Fouque-Tibouchi signature verification failed on "+curve.Name())
+		assert.False(t, VerifySingleSignatureCustHash(curve, sig, vk, msg, curve.HashToG1),
+			"Fouque-Tibouchi signature verifying with a different hash on "+curve.Name())
+	}
+}
+
 func BenchmarkKeygen(b *testing.B) {
 	b.ResetTimer()
 	curve := Altbn128
@@ -130,6 +183,54 @@ func BenchmarkVerification(b *testing.B) {
 	}
 }
 
+// ecPairing runs go-ethereum's ecPairing precompile on input. It returns the
+// result of the pairing check, and false if the precompile rejected the input.
+func ecPairing(input []byte) (bool, bool) {
+	precompile := vm.PrecompiledContractsByzantium[common.BytesToAddress([]byte{8})]
+	output, err := precompile.Run(input)
+	if err != nil {
+		return false, false
+	}
+	return new(big.Int).SetBytes(output).Cmp(big.NewInt(1)) == 0, true
+}
+
+func TestPairingCalldata(t *testing.T) {
+	curve := Altbn128
+	N := 4
+	msgs := make([][]byte, N)
+	sigs := make([]Point, N)
+	keys := make([]Point, N)
+	for i := 0; i < N; i++ {
+		msgs[i] = make([]byte, 32)
+		rand.Read(msgs[i])
+		sk, vk, _ := KeyGen(curve)
+		sigs[i] = Sign(curve, sk, msgs[i])
+		keys[i] = vk
+	}
+	for _, sig := range []Point{sigs[0], sigs[1]} {
+		calldata, ok := SingleSignaturePairingCalldata(curve, sig, keys[0], msgs[0])
+		assert.True(t, ok && len(calldata) == 2*192)
+		result, ok := ecPairing(calldata)
+		assert.True(t, ok, "precompile rejected the calldata")
+		assert.Equal(t, VerifySingleSignature(curve, sig, keys[0], msgs[0]), result,
+			"precompile disagrees with VerifySingleSignature")
+	}
+
+	aggSig := AggregateSignatures(sigs)
+	for _, sig := range []Point{aggSig, sigs[0]} {
+		calldata, ok := AggregateSignaturePairingCalldata(curve, sig, keys, msgs)
+		assert.True(t, ok && len(calldata) == (N+1)*192)
+		result, ok := ecPairing(calldata)
+		assert.True(t, ok, "precompile rejected the calldata")
+		assert.Equal(t, VerifyAggregateSignature(curve, sig, keys, msgs), result,
+			"precompile disagrees with VerifyAggregateSignature")
+	}
+	_, ok := AggregateSignaturePairingCalldata(curve, aggSig, keys, append(msgs[1:], msgs[1]))
+	assert.False(t, ok, "calldata built for duplicate messages")
+	_, ok = SingleSignaturePairingCalldata(Bls12381, Bls12381.GetG1(), Bls12381.GetG2(), msgs[0])
+	assert.False(t, ok, "calldata built for bls12381")
+}
+
 var vks []Point
 var sgs []Point
 var msg []byte
@@ -200,3 +301,16 @@ func BenchmarkAggregateVerification(b *testing.B) {
 		b.Error("Aggregate verificaton failed")
 	}
 }
+
+func TestScalarKeys(t *testing.T) {
+	for _, curve := range curves {
+		sk, vk, err := KeyGenScalar(curve)
+		assert.Nil(t, err, "Key generation failed")
+		assert.True(t, vk.Equals(LoadPublicKey(curve, sk.BigInt())))
+		d := []byte("scalar keys")
+		assert.True(t, VerifySingleSignature(curve, SignScalar(curve, sk, d), vk, d))
+		assert.True(t, KoskVerifySingleSignature(curve, KoskSignScalar(curve, sk, d), vk, d))
+		assert.True(t, CheckAuthentication(curve, vk, AuthenticateScalar(curve, sk)))
+		assert.True(t, DistinctMsgVerifySingleSignature(curve, DistinctMsgSignScalar(curve, sk, d), vk, d))
+	}
+}
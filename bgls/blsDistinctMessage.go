@@ -24,6 +24,11 @@ func DistinctMsgSign(curve CurveSystem, sk *big.Int, m []byte) Point {
 	return DistinctMsgSignCustHash(curve, sk, m, curve.HashToG1)
 }
 
+// DistinctMsgSignScalar is DistinctMsgSign with a secret key of curve.
+func DistinctMsgSignScalar(curve CurveSystem, sk *Scalar, m []byte) Point {
+	return DistinctMsgSign(curve, sk.BigInt(), m)
+}
+
 // DistinctMsgSignCustHash creates a signature on a message with a private key, using
 // a supplied function to hash to g1.
 func DistinctMsgSignCustHash(curve CurveSystem, sk *big.Int, msg []byte, hash func([]byte) Point) Point {
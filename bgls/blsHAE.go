@@ -40,8 +40,7 @@ func AggregateSignaturesWithHAE(sigs []Point, pubkeys []Point) Point {
 		return nil
 	}
 	t := hashPubKeysToExponents(pubkeys)
-	newsigs := ScalePoints(sigs, t)
-	return AggregatePoints(newsigs)
+	return MultiScalarMul(sigs, t)
 }
 
 // VerifyAggregateSignatureWithHAE verifies signatures of different messages aggregated with HAE.
@@ -54,8 +53,8 @@ func VerifyAggregateSignatureWithHAE(curve CurveSystem, aggsig Point, pubkeys []
 // VerifyMultiSignatureWithHAE verifies signatures of the same message aggregated with HAE.
 func VerifyMultiSignatureWithHAE(curve CurveSystem, aggsig Point, pubkeys []Point, msg []byte) bool {
 	t := hashPubKeysToExponents(pubkeys)
-	newkeys := ScalePoints(pubkeys, t)
-	return verifyMultiSignature(curve, aggsig, newkeys, msg)
+	aggkey := MultiScalarMul(pubkeys, t)
+	return VerifySingleSignature(curve, aggsig, aggkey, msg)
 }
 
 // My hash from G^n \to \R^n is using blake2x. The inputs to the hash are the
@@ -45,6 +45,11 @@ func Authenticate(curve CurveSystem, sk *big.Int) Point {
 	return AuthenticateCustHash(curve, sk, curve.HashToG1)
 }
 
+// AuthenticateScalar is Authenticate with a secret key of curve.
+func AuthenticateScalar(curve CurveSystem, sk *Scalar) Point {
+	return Authenticate(curve, sk.BigInt())
+}
+
 // AuthenticateCustHash generates an Aggregatable Authentication for a given secret key.
 // It signs the public key generated from sk, with a null byte prepended to it.
 // This runs with the specified hash function.
@@ -74,6 +79,11 @@ func KoskSign(curve CurveSystem, sk *big.Int, msg []byte) Point {
 	return KoskSignCustHash(curve, sk, msg, curve.HashToG1)
 }
 
+// KoskSignScalar is KoskSign with a secret key of curve.
+func KoskSignScalar(curve CurveSystem, sk *Scalar, msg []byte) Point {
+	return KoskSign(curve, sk.BigInt(), msg)
+}
+
 // KoskSignCustHash creates a kosk signature on a message with a private key, using
 // a supplied function to hash to point. A kosk signature prepends a 0x01 byte
 // to the message before signing.
@@ -132,6 +142,6 @@ func KoskVerifyMultiSignatureWithMultiplicity(curve CurveSystem, aggsig Point, k
 	for i := 0; i < len(keys); i++ {
 		factors[i] = big.NewInt(multiplicity[i])
 	}
-	scaledKeys := ScalePoints(keys, factors)
-	return KoskVerifyMultiSignature(curve, aggsig, scaledKeys, msg)
+	aggkey := MultiScalarMul(keys, factors)
+	return KoskVerifyMultiSignature(curve, aggsig, []Point{aggkey}, msg)
 }
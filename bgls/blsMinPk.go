@@ -0,0 +1,267 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file is the minimal public key size orientation of BLS, where public
+// keys are in G1 and signatures are in G2, the opposite of the rest of this
+// package. Keys are then 2 times smaller, and signatures 2 times larger, which
+// suits committees that store many keys and few signatures. Messages are hashed
+// with the curve's HashToG2, and a signature sig on m by key pk = sk * g_1 is
+// valid if e(pk, H(m)) = e(g_1, sig).
+//
+// Every function here has the same name as its counterpart in the default
+// orientation, with a MinPk suffix. AggregateSignatures, AggregateKeys and
+// AggregateSignaturesWithHAE work on points of either group, so they have no
+// counterpart. Keys and signatures of the two orientations can't be mixed.
+//
+// All three defenses against the rogue public key attack are supported, as
+// described in doc.go. The only difference is that authentications of the
+// MinPk Kosk prepend a null byte to the public key, as blsKosk.go describes,
+// so they can never be confused with a KoskSignMinPk signature, which prepends
+// a 0x01 byte.
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// KeyGenMinPk generates a *big.Int and its public key in G1
+func KeyGenMinPk(curve CurveSystem) (*big.Int, Point, error) {
+	return KeyGenFromReaderMinPk(curve, rand.Reader)
+}
+
+// KeyGenFromReaderMinPk is KeyGenMinPk with the secret key read from r
+func KeyGenFromReaderMinPk(curve CurveSystem, r io.Reader) (*big.Int, Point, error) {
+	x, err := rand.Int(r, curve.GetG1Order())
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, LoadPublicKeyMinPk(curve, x), nil
+}
+
+// LoadPublicKeyMinPk turns secret key into a public key in G1
+func LoadPublicKeyMinPk(curve CurveSystem, sk *big.Int) Point {
+	return curve.G1BaseMul(sk)
+}
+
+// KeyGenScalarMinPk generates a secret key as a Scalar, and its public key in G1
+func KeyGenScalarMinPk(curve CurveSystem) (*Scalar, Point, error) {
+	return KeyGenScalarFromReaderMinPk(curve, rand.Reader)
+}
+
+// KeyGenScalarFromReaderMinPk is KeyGenScalarMinPk with the secret key read from r
+func KeyGenScalarFromReaderMinPk(curve CurveSystem, r io.Reader) (*Scalar, Point, error) {
+	x, err := RandomScalarFromReader(curve, r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, LoadPublicKeyScalarMinPk(curve, x), nil
+}
+
+// LoadPublicKeyScalarMinPk turns a secret key of curve into a public key in G1
+func LoadPublicKeyScalarMinPk(curve CurveSystem, sk *Scalar) Point {
+	return LoadPublicKeyMinPk(curve, sk.BigInt())
+}
+
+// SignMinPk creates a BLS signature in G2 on a message with a private key
+func SignMinPk(curve CurveSystem, sk *big.Int, msg []byte) Point {
+	return SignCustHash(sk, msg, curve.HashToG2)
+}
+
+// SignScalarMinPk is SignMinPk with a secret key of curve
+func SignScalarMinPk(curve CurveSystem, sk *Scalar, msg []byte) Point {
+	return SignMinPk(curve, sk.BigInt(), msg)
+}
+
+// VerifySingleSignatureMinPk checks that a single BLS signature in G2 is valid
+func VerifySingleSignatureMinPk(curve CurveSystem, sig Point, pubKey Point, msg []byte) bool {
+	return VerifySingleSignatureCustHashMinPk(curve, sig, pubKey, msg, curve.HashToG2)
+}
+
+// VerifySingleSignatureCustHashMinPk checks that a single BLS signature in G2
+// is valid, using the supplied hash function to hash onto G2.
+func VerifySingleSignatureCustHashMinPk(curve CurveSystem, sig Point, pubKey Point,
+	msg []byte, hash func([]byte) Point) bool {
+	pts1, pts2, ok := singleSignaturePairsMinPk(curve, sig, pubKey, msg, hash)
+	return ok && curve.PairingCheck(pts1, pts2)
+}
+
+// singleSignaturePairsMinPk returns the pairs whose product of pairings is one
+// when sig is a valid signature, e(pubkey, H(msg)) * e(-g1, sig). Negating in
+// G1 is cheaper than in G2. It returns false if pubkey is the point at infinity.
+func singleSignaturePairsMinPk(curve CurveSystem, sig Point, pubkey Point,
+	msg []byte, hash func([]byte) Point) ([]Point, []Point, bool) {
+	if pubkey == nil || pubkey.IsInfinity() {
+		return nil, nil, false
+	}
+	g1 := curve.GetG1().Mul(new(big.Int).SetInt64(-1))
+	return []Point{pubkey, g1}, []Point{hash(msg), sig}, true
+}
+
+// VerifyAggregateSignatureMinPk verifies that the aggregated signature in G2
+// proves that all messages were signed by the associated keys in G1. Like
+// VerifyAggregateSignature, this will fail if there are duplicate messages.
+func VerifyAggregateSignatureMinPk(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	return verifyAggSigMinPk(curve, aggsig, keys, msgs, false)
+}
+
+// verifyMultiSignatureMinPk checks that the aggregate signature proves that a
+// single message has been signed by a set of keys. This is vulnerable to the
+// rogue public key attack, so one of the defense mechanisms should be used.
+func verifyMultiSignatureMinPk(curve CurveSystem, aggsig Point, keys []Point, msg []byte) bool {
+	vs := AggregatePoints(keys)
+	return VerifySingleSignatureMinPk(curve, aggsig, vs, msg)
+}
+
+func verifyAggSigMinPk(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte, allowDuplicates bool) bool {
+	pts1, pts2, ok := aggregateSignaturePairsMinPk(curve, aggsig, keys, msgs, allowDuplicates)
+	return ok && curve.PairingCheck(pts1, pts2)
+}
+
+// aggregateSignaturePairsMinPk returns the pairs whose product of pairings is
+// one when aggsig is valid, e(key_1, H(m_1)) * ... * e(key_n, H(m_n)) * e(-g1, aggsig).
+func aggregateSignaturePairsMinPk(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte,
+	allowDuplicates bool) ([]Point, []Point, bool) {
+	if len(keys) != len(msgs) || containsInfinity(keys) {
+		return nil, nil, false
+	}
+	if !allowDuplicates && containsDuplicateMessage(msgs) {
+		return nil, nil, false
+	}
+	pts1 := make([]Point, len(keys)+1)
+	pts2 := make([]Point, len(keys)+1)
+	Parallelize(context.Background(), len(msgs), func(i int) error {
+		pts1[i] = keys[i]
+		pts2[i] = curve.HashToG2(msgs[i])
+		return nil
+	})
+	pts1[len(keys)] = curve.GetG1().Mul(new(big.Int).SetInt64(-1))
+	pts2[len(keys)] = aggsig
+	return pts1, pts2, true
+}
+
+// AuthenticateMinPk generates an aggregatable authentication in G2 for a given
+// secret key. It signs the public key in G1 generated from sk, with a null byte
+// prepended to it.
+func AuthenticateMinPk(curve CurveSystem, sk *big.Int) Point {
+	msg := append([]byte{0}, LoadPublicKeyMinPk(curve, sk).Marshal()...)
+	return SignMinPk(curve, sk, msg)
+}
+
+// AuthenticateScalarMinPk is AuthenticateMinPk with a secret key of curve.
+func AuthenticateScalarMinPk(curve CurveSystem, sk *Scalar) Point {
+	return AuthenticateMinPk(curve, sk.BigInt())
+}
+
+// CheckAuthenticationMinPk verifies that the provided signature is in fact
+// authentication for this public key in G1.
+func CheckAuthenticationMinPk(curve CurveSystem, pubkey Point, authentication Point) bool {
+	msg := append([]byte{0}, pubkey.Marshal()...)
+	return VerifySingleSignatureMinPk(curve, authentication, pubkey, msg)
+}
+
+// KoskSignMinPk creates a kosk signature in G2 on a message with a private key.
+// A kosk signature prepends a 0x01 byte to the message before signing.
+func KoskSignMinPk(curve CurveSystem, sk *big.Int, msg []byte) Point {
+	return SignMinPk(curve, sk, append([]byte{1}, msg...))
+}
+
+// KoskSignScalarMinPk is KoskSignMinPk with a secret key of curve.
+func KoskSignScalarMinPk(curve CurveSystem, sk *Scalar, msg []byte) Point {
+	return KoskSignMinPk(curve, sk.BigInt(), msg)
+}
+
+// KoskVerifySingleSignatureMinPk checks that a single kosk signature in G2 is valid.
+func KoskVerifySingleSignatureMinPk(curve CurveSystem, sig Point, pubKey Point, msg []byte) bool {
+	return VerifySingleSignatureMinPk(curve, sig, pubKey, append([]byte{1}, msg...))
+}
+
+// KoskVerifyAggregateSignatureMinPk verifies that the aggregated signature in
+// G2 proves that all messages were signed by the associated keys.
+func KoskVerifyAggregateSignatureMinPk(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	newMsgs := make([][]byte, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		newMsgs[i] = append([]byte{1}, msgs[i]...)
+	}
+	return verifyAggSigMinPk(curve, aggsig, keys, newMsgs, true)
+}
+
+// KoskVerifyMultiSignatureMinPk checks that the aggregate signature in G2
+// proves that a single message has been signed by a set of keys,
+// vulnerable against chosen key attack, if keys have not been authenticated
+func KoskVerifyMultiSignatureMinPk(curve CurveSystem, aggsig Point, keys []Point, msg []byte) bool {
+	return verifyMultiSignatureMinPk(curve, aggsig, keys, append([]byte{1}, msg...))
+}
+
+// KoskVerifyMultiSignatureWithMultiplicityMinPk verifies a multi signature in
+// G2 where multiple copies of each signature may have been included in the aggregation
+func KoskVerifyMultiSignatureWithMultiplicityMinPk(curve CurveSystem, aggsig Point, keys []Point,
+	multiplicity []int64, msg []byte) bool {
+	if multiplicity == nil {
+		return KoskVerifyMultiSignatureMinPk(curve, aggsig, keys, msg)
+	} else if len(keys) != len(multiplicity) {
+		return false
+	}
+	factors := make([]*big.Int, len(multiplicity))
+	for i := 0; i < len(keys); i++ {
+		factors[i] = big.NewInt(multiplicity[i])
+	}
+	aggkey := MultiScalarMul(keys, factors)
+	return KoskVerifyMultiSignatureMinPk(curve, aggsig, []Point{aggkey}, msg)
+}
+
+// DistinctMsgSignMinPk creates a signature in G2 on a message with a private
+// key, with the public key in G1 prepended to the message.
+func DistinctMsgSignMinPk(curve CurveSystem, sk *big.Int, m []byte) Point {
+	msg := append(LoadPublicKeyMinPk(curve, sk).MarshalUncompressed(), m...)
+	return SignMinPk(curve, sk, msg)
+}
+
+// DistinctMsgSignScalarMinPk is DistinctMsgSignMinPk with a secret key of curve.
+func DistinctMsgSignScalarMinPk(curve CurveSystem, sk *Scalar, m []byte) Point {
+	return DistinctMsgSignMinPk(curve, sk.BigInt(), m)
+}
+
+// DistinctMsgVerifySingleSignatureMinPk checks that a single 'Distinct Message'
+// signature in G2 is valid
+func DistinctMsgVerifySingleSignatureMinPk(curve CurveSystem, sig Point, pubkey Point, m []byte) bool {
+	msg := append(pubkey.MarshalUncompressed(), m...)
+	return VerifySingleSignatureMinPk(curve, sig, pubkey, msg)
+}
+
+// DistinctMsgVerifyAggregateSignatureMinPk checks that an aggsig in G2 was
+// generated from the provided set of public key / msg pairs, when the messages
+// are signed using the 'Distinct Message' method.
+func DistinctMsgVerifyAggregateSignatureMinPk(curve CurveSystem, aggsig Point, keys []Point, msgs [][]byte) bool {
+	if len(keys) != len(msgs) {
+		return false
+	}
+	prependedMsgs := make([][]byte, len(msgs))
+	for i := 0; i < len(msgs); i++ {
+		prependedMsgs[i] = append(keys[i].MarshalUncompressed(), msgs[i]...)
+	}
+	// As in DistinctMsgVerifyAggregateSignature, the prepended keys make the
+	// messages distinct, so the duplicate check is skipped.
+	return verifyAggSigMinPk(curve, aggsig, keys, prependedMsgs, true)
+}
+
+// VerifyAggregateSignatureWithHAEMinPk verifies signatures in G2 of different
+// messages aggregated with HAE by AggregateSignaturesWithHAE.
+func VerifyAggregateSignatureWithHAEMinPk(curve CurveSystem, aggsig Point, pubkeys []Point, msgs [][]byte) bool {
+	t := hashPubKeysToExponents(pubkeys)
+	newkeys := ScalePoints(pubkeys, t)
+	return verifyAggSigMinPk(curve, aggsig, newkeys, msgs, true)
+}
+
+// VerifyMultiSignatureWithHAEMinPk verifies signatures in G2 of the same
+// message aggregated with HAE by AggregateSignaturesWithHAE.
+func VerifyMultiSignatureWithHAEMinPk(curve CurveSystem, aggsig Point, pubkeys []Point, msg []byte) bool {
+	t := hashPubKeysToExponents(pubkeys)
+	aggkey := MultiScalarMul(pubkeys, t)
+	return VerifySingleSignatureMinPk(curve, aggsig, aggkey, msg)
+}
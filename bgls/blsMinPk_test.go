@@ -0,0 +1,184 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+// minPkSigners returns n keys in G1, with their signatures in G2 of msgs,
+// made by sign.
+func minPkSigners(t *testing.T, curve CurveSystem, msgs [][]byte,
+	sign func(CurveSystem, *big.Int, []byte) Point) ([]*big.Int, []Point, []Point) {
+	sks := make([]*big.Int, len(msgs))
+	pubkeys := make([]Point, len(msgs))
+	sigs := make([]Point, len(msgs))
+	for i := range msgs {
+		var err error
+		sks[i], pubkeys[i], err = KeyGenMinPk(curve)
+		assert.Nil(t, err, "Key generation failed")
+		sigs[i] = sign(curve, sks[i], msgs[i])
+	}
+	return sks, pubkeys, sigs
+}
+
+func randomMessages(n int) [][]byte {
+	msgs := make([][]byte, n)
+	for i := range msgs {
+		msgs[i] = make([]byte, 32)
+		rand.Read(msgs[i])
+	}
+	return msgs
+}
+
+func TestMinPkSingleSigner(t *testing.T) {
+	for _, curve := range curves {
+		msg := randomMessages(1)[0]
+		sk, vk, err := KeyGenMinPk(curve)
+		assert.Nil(t, err, "Key generation failed")
+		assert.True(t, vk.Equals(curve.GetG1().Mul(sk)), "The public key isn't in G1")
+		sig := SignMinPk(curve, sk, msg)
+		assert.True(t, sig.Equals(curve.HashToG2(msg).Mul(sk)), "The signature isn't in G2")
+		assert.True(t, VerifySingleSignatureMinPk(curve, sig, vk, msg), "MinPk signature verification failed")
+
+		sig2, _ := sig.Copy().Add(curve.GetG2())
+		assert.False(t, VerifySingleSignatureMinPk(curve, sig2, vk, msg),
+			"MinPk signature verification succeeding when it shouldn't")
+		assert.False(t, VerifySingleSignatureMinPk(curve, sig, vk, append(msg, 0)),
+			"MinPk signature verification succeeding on the wrong message")
+		// The orientations don't mix
+		assert.False(t, VerifySingleSignature(curve, Sign(curve, sk, msg), vk, msg),
+			"A signature in G1 verified with a key in G1")
+
+		skScalar, vkScalar, _ := KeyGenScalarFromReaderMinPk(curve, NewDeterministicReader([]byte("seed")))
+		skBig, vkBig, _ := KeyGenFromReaderMinPk(curve, NewDeterministicReader([]byte("seed")))
+		assert.Equal(t, skBig, skScalar.BigInt())
+		assert.True(t, vkBig.Equals(vkScalar))
+		assert.True(t, SignScalarMinPk(curve, skScalar, msg).Equals(SignMinPk(curve, skBig, msg)))
+
+		assert.False(t, VerifySingleSignatureMinPk(curve, curve.GetG2Infinity(), curve.GetG1Infinity(), msg),
+			"MinPk signature verification accepted an infinity public key")
+		assert.False(t, VerifyAggregateSignatureMinPk(curve, curve.GetG2Infinity(),
+			[]Point{curve.GetG1Infinity()}, [][]byte{msg}),
+			"MinPk aggregate signature verification accepted an infinity public key")
+	}
+}
+
+func TestMinPkAggregation(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		msgs := randomMessages(N)
+		_, pubkeys, sigs := minPkSigners(t, curve, msgs, SignMinPk)
+		aggSig := AggregateSignatures(sigs)
+		assert.True(t, VerifyAggregateSignatureMinPk(curve, aggSig, pubkeys, msgs),
+			"MinPk aggregate signature verification failed")
+		assert.False(t, VerifyAggregateSignatureMinPk(curve, aggSig, pubkeys[:N-1], msgs),
+			"MinPk aggregate signature verification succeeding without enough pubkeys")
+		msgs[0], msgs[1] = msgs[1], msgs[0]
+		assert.False(t, VerifyAggregateSignatureMinPk(curve, aggSig, pubkeys, msgs),
+			"MinPk aggregate signature verification succeeded with messages 0 and 1 switched")
+
+		msgs[1] = msgs[0]
+		_, pubkeys, sigs = minPkSigners(t, curve, msgs, SignMinPk)
+		assert.False(t, VerifyAggregateSignatureMinPk(curve, AggregateSignatures(sigs), pubkeys, msgs),
+			"MinPk aggregate signature verification succeeding with duplicate messages")
+	}
+}
+
+func TestMinPkKosk(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		msgs := randomMessages(N)
+		sks, pubkeys, sigs := minPkSigners(t, curve, msgs, KoskSignMinPk)
+		for i := range sks {
+			assert.True(t, CheckAuthenticationMinPk(curve, pubkeys[i], AuthenticateMinPk(curve, sks[i])),
+				"MinPk key authentication failed")
+			assert.False(t, CheckAuthenticationMinPk(curve, pubkeys[i],
+				KoskSignMinPk(curve, sks[i], pubkeys[i].Marshal())),
+				"MinPk kosk signature on the public key accepted as authentication")
+			assert.True(t, KoskVerifySingleSignatureMinPk(curve, sigs[i], pubkeys[i], msgs[i]),
+				"MinPk kosk signature verification failed")
+		}
+		assert.True(t, AuthenticateScalarMinPk(curve, NewScalar(curve, sks[0])).Equals(AuthenticateMinPk(curve, sks[0])))
+
+		// Aggregate signatures allow duplicate messages
+		msgs[N-1] = msgs[0]
+		sigs[N-1] = KoskSignScalarMinPk(curve, NewScalar(curve, sks[N-1]), msgs[N-1])
+		aggSig := AggregateSignatures(sigs)
+		assert.True(t, KoskVerifyAggregateSignatureMinPk(curve, aggSig, pubkeys, msgs),
+			"MinPk kosk aggregate signature verification failed")
+		assert.False(t, KoskVerifyAggregateSignatureMinPk(curve, aggSig, pubkeys[:N-1], msgs[:N-1]),
+			"MinPk kosk aggregate signature verification succeeding without enough pubkeys")
+
+		msg := msgs[0]
+		multi := make([]int64, N)
+		for i := range sks {
+			multi[i] = int64(i + 1)
+			sigs[i] = KoskSignMinPk(curve, sks[i], msg)
+		}
+		aggSig = AggregateSignatures(sigs)
+		assert.True(t, KoskVerifyMultiSignatureMinPk(curve, aggSig, pubkeys, msg),
+			"MinPk kosk multi signature verification failed")
+		assert.False(t, KoskVerifyMultiSignatureMinPk(curve, aggSig, pubkeys, msgs[1]),
+			"MinPk kosk multi signature verification succeeded on incorrect msg")
+		assert.False(t, KoskVerifyMultiSignatureMinPk(curve, aggSig, pubkeys[1:], msg),
+			"MinPk kosk multi signature verification succeeded on incorrect signers")
+		assert.True(t, KoskVerifyMultiSignatureWithMultiplicityMinPk(curve, aggSig, pubkeys, nil, msg))
+		aggSig = MultiScalarMul(sigs, []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3), big.NewInt(4)})
+		assert.True(t, KoskVerifyMultiSignatureWithMultiplicityMinPk(curve, aggSig, pubkeys, multi, msg),
+			"MinPk kosk multi signature with multiplicity verification failed")
+		assert.False(t, KoskVerifyMultiSignatureWithMultiplicityMinPk(curve, aggSig, pubkeys, multi[1:], msg),
+			"MinPk kosk multi signature with multiplicity accepted the wrong number of multiplicities")
+	}
+}
+
+func TestMinPkDistinctMsg(t *testing.T) {
+	for _, curve := range curves {
+		N := 3
+		msgs := randomMessages(N)
+		msgs[N-1] = msgs[0]
+		sks, pubkeys, sigs := minPkSigners(t, curve, msgs, DistinctMsgSignMinPk)
+		for i := range sks {
+			assert.True(t, DistinctMsgVerifySingleSignatureMinPk(curve, sigs[i], pubkeys[i], msgs[i]),
+				"MinPk distinct message signature verification failed")
+		}
+		assert.True(t, DistinctMsgSignScalarMinPk(curve, NewScalar(curve, sks[0]), msgs[0]).Equals(sigs[0]))
+		aggSig := AggregateSignatures(sigs)
+		assert.True(t, DistinctMsgVerifyAggregateSignatureMinPk(curve, aggSig, pubkeys, msgs),
+			"MinPk distinct message aggregate signature verification failed with duplicate messages")
+		assert.False(t, DistinctMsgVerifyAggregateSignatureMinPk(curve, aggSig, pubkeys[:N-1], msgs),
+			"MinPk distinct message aggregate signature verification succeeding without enough pubkeys")
+		assert.False(t, DistinctMsgVerifyAggregateSignatureMinPk(curve, aggSig, pubkeys, msgs[1:]))
+	}
+}
+
+func TestMinPkHAE(t *testing.T) {
+	for _, curve := range curves {
+		N := 4
+		msgs := randomMessages(N)
+		msgs[N-1] = msgs[0]
+		_, pubkeys, sigs := minPkSigners(t, curve, msgs, SignMinPk)
+		aggSig := AggregateSignaturesWithHAE(sigs, pubkeys)
+		assert.True(t, VerifyAggregateSignatureWithHAEMinPk(curve, aggSig, pubkeys, msgs),
+			"MinPk HAE aggregate signature verification failed with duplicate messages")
+		assert.False(t, VerifyAggregateSignatureWithHAEMinPk(curve, AggregateSignatures(sigs), pubkeys, msgs),
+			"MinPk HAE aggregate signature verification succeeded without the exponents")
+
+		msg := msgs[0]
+		_, pubkeys, sigs = minPkSigners(t, curve, [][]byte{msg, msg, msg}, SignMinPk)
+		aggSig = AggregateSignaturesWithHAE(sigs, pubkeys)
+		assert.True(t, VerifyMultiSignatureWithHAEMinPk(curve, aggSig, pubkeys, msg),
+			"MinPk HAE multi signature verification failed")
+		assert.False(t, VerifyMultiSignatureWithHAEMinPk(curve, aggSig, pubkeys, msgs[1]),
+			"MinPk HAE multi signature verification succeeded on incorrect msg")
+		pubkeys[0], pubkeys[1] = pubkeys[1], pubkeys[0]
+		assert.False(t, VerifyMultiSignatureWithHAEMinPk(curve, aggSig, pubkeys, msg),
+			"MinPk HAE multi signature verification succeeded with reordered signers")
+	}
+}
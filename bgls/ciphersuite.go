@@ -0,0 +1,292 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+// This file implements the BLS signature schemes of the IETF draft,
+// draft-irtf-cfrg-bls-signature-05. Unlike the rest of this package, which
+// works with points and leaves the protection against the rogue public key
+// attack to the caller, each ciphersuite is a complete scheme. Keys and
+// signatures are octet strings, the domain separation tag of the hash is the
+// ciphersuite ID, and every public key and signature is validated.
+//
+// The draft has three schemes against the rogue public key attack:
+//  - Basic: aggregate signatures are only verified on distinct messages.
+//  - Message augmentation: every message is prefixed with the public key of its signer.
+//  - Proof of possession: every public key comes with a signature on itself,
+//    under a separate tag, which is checked once with PopVerify. Signatures of
+//    many keys on the same message can then be checked with FastAggregateVerify.
+//
+// As in the rest of this package, the ciphersuites use the minimal signature
+// size variant, with signatures in G1 and public keys in G2. Points are encoded
+// with MarshalCompressedStandard. The BLS12-381 ciphersuites are the ones of the
+// draft. The draft doesn't define ciphersuites for altbn128, so the altbn128
+// ones follow its naming, with the BN254G1_XMD:SHA-256_SVDW_RO_ suite of
+// RFC 9380 as the hash.
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+	"golang.org/x/crypto/hkdf"
+)
+
+// ciphersuite holds what the three schemes have in common.
+type ciphersuite struct {
+	curve CurveSystem
+	id    string
+	// hash is hash_to_point, with the ciphersuite ID as its tag
+	hash func([]byte) Point
+}
+
+// BasicCiphersuite is the basic scheme, whose aggregate signatures must be on
+// distinct messages.
+type BasicCiphersuite struct {
+	ciphersuite
+}
+
+// AugCiphersuite is the message augmentation scheme, which signs the public
+// key of the signer followed by the message.
+type AugCiphersuite struct {
+	ciphersuite
+}
+
+// PopCiphersuite is the proof of possession scheme. Each public key must pass
+// PopVerify before it is used in AggregateVerify or FastAggregateVerify.
+type PopCiphersuite struct {
+	ciphersuite
+	popID string
+	// popHash is hash_pubkey_to_point, with the proof of possession tag
+	popHash func([]byte) Point
+}
+
+// The ciphersuites of the draft on BLS12-381, with signatures in G1
+var (
+	Bls12381Basic = &BasicCiphersuite{newCiphersuite(Bls12381,
+		"BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_", Bls12381HashToG1WithDST)}
+	Bls12381Aug = &AugCiphersuite{newCiphersuite(Bls12381,
+		"BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_AUG_", Bls12381HashToG1WithDST)}
+	Bls12381Pop = newPopCiphersuite(Bls12381, "BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_",
+		"BLS_POP_BLS12381G1_XMD:SHA-256_SSWU_RO_POP_", Bls12381HashToG1WithDST)
+)
+
+// Ciphersuites on altbn128 with signatures in G1, in the format of the draft,
+// which doesn't define any for this curve
+var (
+	Altbn128Basic = &BasicCiphersuite{newCiphersuite(Altbn128,
+		"BLS_SIG_BN254G1_XMD:SHA-256_SVDW_RO_NUL_", AltbnHashToG1WithDST)}
+	Altbn128Aug = &AugCiphersuite{newCiphersuite(Altbn128,
+		"BLS_SIG_BN254G1_XMD:SHA-256_SVDW_RO_AUG_", AltbnHashToG1WithDST)}
+	Altbn128Pop = newPopCiphersuite(Altbn128, "BLS_SIG_BN254G1_XMD:SHA-256_SVDW_RO_POP_",
+		"BLS_POP_BN254G1_XMD:SHA-256_SVDW_RO_POP_", AltbnHashToG1WithDST)
+)
+
+func newCiphersuite(curve CurveSystem, id string, hashWithDST func([]byte) func([]byte) Point) ciphersuite {
+	return ciphersuite{curve, id, hashWithDST([]byte(id))}
+}
+
+func newPopCiphersuite(curve CurveSystem, id string, popID string,
+	hashWithDST func([]byte) func([]byte) Point) *PopCiphersuite {
+	return &PopCiphersuite{newCiphersuite(curve, id, hashWithDST), popID, hashWithDST([]byte(popID))}
+}
+
+var errShortIKM = errors.New("the input keying material must be at least 32 bytes")
+
+// ID returns the ciphersuite ID, which is also the tag of its hash.
+func (c *ciphersuite) ID() string {
+	return c.id
+}
+
+// Curve returns the curve of the ciphersuite.
+func (c *ciphersuite) Curve() CurveSystem {
+	return c.curve
+}
+
+// KeyGen derives a secret key from ikm, which must be at least 32 bytes of
+// secret randomness, and the optional keyInfo, with HKDF-SHA256.
+func (c *ciphersuite) KeyGen(ikm []byte, keyInfo []byte) (*Scalar, error) {
+	if len(ikm) < 32 {
+		return nil, errShortIKM
+	}
+	r := c.curve.GetG1Order()
+	// L = ceil((3 * ceil(log2(r))) / 16)
+	l := (3*r.BitLen() + 15) / 16
+	salt := []byte("BLS-SIG-KEYGEN-SALT-")
+	info := append(append([]byte{}, keyInfo...), byte(l>>8), byte(l))
+	sk := new(big.Int)
+	for sk.Sign() == 0 {
+		h := sha256.Sum256(salt)
+		salt = h[:]
+		okm := make([]byte, l)
+		kdf := hkdf.New(sha256.New, append(append([]byte{}, ikm...), 0), salt, info)
+		if _, err := io.ReadFull(kdf, okm); err != nil {
+			return nil, err
+		}
+		sk.SetBytes(okm).Mod(sk, r)
+	}
+	return NewScalar(c.curve, sk), nil
+}
+
+// SkToPk returns the public key of sk.
+func (c *ciphersuite) SkToPk(sk *Scalar) []byte {
+	return LoadPublicKeyScalar(c.curve, sk).MarshalCompressedStandard()
+}
+
+// KeyValidate checks that pk encodes a point of G2 other than the identity.
+func (c *ciphersuite) KeyValidate(pk []byte) bool {
+	_, ok := c.pubkeyToPoint(pk)
+	return ok
+}
+
+func (c *ciphersuite) pubkeyToPoint(pk []byte) (Point, bool) {
+	pt, ok := c.curve.UnmarshalG2CompressedStandard(pk)
+	if !ok || pt.IsInfinity() {
+		return nil, false
+	}
+	return pt, true
+}
+
+func (c *ciphersuite) signatureToPoint(sig []byte) (Point, bool) {
+	return c.curve.UnmarshalG1CompressedStandard(sig)
+}
+
+// Aggregate returns the sum of the signatures. It returns false if there are
+// no signatures, or one of them isn't valid.
+func (c *ciphersuite) Aggregate(sigs [][]byte) ([]byte, bool) {
+	if len(sigs) == 0 {
+		return nil, false
+	}
+	pts := make([]Point, len(sigs))
+	for i, sig := range sigs {
+		var ok bool
+		if pts[i], ok = c.signatureToPoint(sig); !ok {
+			return nil, false
+		}
+	}
+	return AggregatePoints(pts).MarshalCompressedStandard(), true
+}
+
+// coreSign is CoreSign from the draft, with the given hash to the curve.
+func (c *ciphersuite) coreSign(sk *Scalar, msg []byte, hash func([]byte) Point) []byte {
+	return SignCustHash(sk.BigInt(), msg, hash).MarshalCompressedStandard()
+}
+
+// coreVerify is CoreVerify from the draft, with the given hash to the curve.
+func (c *ciphersuite) coreVerify(pk []byte, msg []byte, sig []byte, hash func([]byte) Point) bool {
+	return c.coreAggregateVerify([][]byte{pk}, [][]byte{msg}, sig, hash)
+}
+
+// coreAggregateVerify is CoreAggregateVerify from the draft, with the given hash
+// to the curve, which checks that e(H(m_1), pk_1) ... e(H(m_n), pk_n) = e(sig, g2).
+func (c *ciphersuite) coreAggregateVerify(pks [][]byte, msgs [][]byte, sig []byte, hash func([]byte) Point) bool {
+	if len(pks) == 0 || len(pks) != len(msgs) {
+		return false
+	}
+	aggsig, ok := c.signatureToPoint(sig)
+	if !ok {
+		return false
+	}
+	keys := make([]Point, len(pks))
+	for i, pk := range pks {
+		if keys[i], ok = c.pubkeyToPoint(pk); !ok {
+			return false
+		}
+	}
+	pts1, pts2, ok := aggregateSignaturePairsCustHash(c.curve, aggsig, keys, msgs, true, hash)
+	return ok && c.curve.PairingCheck(pts1, pts2)
+}
+
+// Sign signs msg with sk.
+func (c *BasicCiphersuite) Sign(sk *Scalar, msg []byte) []byte {
+	return c.coreSign(sk, msg, c.hash)
+}
+
+// Verify checks that sig is a signature on msg under pk.
+func (c *BasicCiphersuite) Verify(pk []byte, msg []byte, sig []byte) bool {
+	return c.coreVerify(pk, msg, sig, c.hash)
+}
+
+// AggregateVerify checks that sig is an aggregate of signatures on msgs[i]
+// under pks[i]. It fails if two of the messages are the same.
+func (c *BasicCiphersuite) AggregateVerify(pks [][]byte, msgs [][]byte, sig []byte) bool {
+	return !containsDuplicateMessage(msgs) && c.coreAggregateVerify(pks, msgs, sig, c.hash)
+}
+
+// Sign signs the public key of sk followed by msg.
+func (c *AugCiphersuite) Sign(sk *Scalar, msg []byte) []byte {
+	return c.coreSign(sk, augment(c.SkToPk(sk), msg), c.hash)
+}
+
+// Verify checks that sig is a signature on pk followed by msg under pk.
+func (c *AugCiphersuite) Verify(pk []byte, msg []byte, sig []byte) bool {
+	return c.coreVerify(pk, augment(pk, msg), sig, c.hash)
+}
+
+// AggregateVerify checks that sig is an aggregate of signatures on pks[i]
+// followed by msgs[i] under pks[i]. The messages may repeat.
+func (c *AugCiphersuite) AggregateVerify(pks [][]byte, msgs [][]byte, sig []byte) bool {
+	if len(pks) != len(msgs) {
+		return false
+	}
+	augmented := make([][]byte, len(msgs))
+	for i := range msgs {
+		augmented[i] = augment(pks[i], msgs[i])
+	}
+	return c.coreAggregateVerify(pks, augmented, sig, c.hash)
+}
+
+func augment(pk []byte, msg []byte) []byte {
+	return append(append([]byte{}, pk...), msg...)
+}
+
+// PopID returns the tag of the hash used by proofs of possession.
+func (c *PopCiphersuite) PopID() string {
+	return c.popID
+}
+
+// Sign signs msg with sk.
+func (c *PopCiphersuite) Sign(sk *Scalar, msg []byte) []byte {
+	return c.coreSign(sk, msg, c.hash)
+}
+
+// Verify checks that sig is a signature on msg under pk.
+func (c *PopCiphersuite) Verify(pk []byte, msg []byte, sig []byte) bool {
+	return c.coreVerify(pk, msg, sig, c.hash)
+}
+
+// AggregateVerify checks that sig is an aggregate of signatures on msgs[i]
+// under pks[i]. The messages may repeat, as the keys have proofs of possession.
+func (c *PopCiphersuite) AggregateVerify(pks [][]byte, msgs [][]byte, sig []byte) bool {
+	return c.coreAggregateVerify(pks, msgs, sig, c.hash)
+}
+
+// PopProve returns a proof of possession of sk, which is a signature on its
+// public key under the proof of possession tag.
+func (c *PopCiphersuite) PopProve(sk *Scalar) []byte {
+	return c.coreSign(sk, c.SkToPk(sk), c.popHash)
+}
+
+// PopVerify checks a proof of possession of the secret key of pk.
+func (c *PopCiphersuite) PopVerify(pk []byte, proof []byte) bool {
+	return c.coreVerify(pk, pk, proof, c.popHash)
+}
+
+// FastAggregateVerify checks that sig is an aggregate of signatures on msg
+// under each of pks, whose proofs of possession must have been verified.
+func (c *PopCiphersuite) FastAggregateVerify(pks [][]byte, msg []byte, sig []byte) bool {
+	if len(pks) == 0 {
+		return false
+	}
+	keys := make([]Point, len(pks))
+	for i, pk := range pks {
+		var ok bool
+		if keys[i], ok = c.curve.UnmarshalG2CompressedStandard(pk); !ok {
+			return false
+		}
+	}
+	aggregate := AggregateKeys(keys).MarshalCompressedStandard()
+	return c.coreVerify(aggregate, msg, sig, c.hash)
+}
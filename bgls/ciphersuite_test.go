@@ -0,0 +1,151 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+// scheme is the part of the API which the three ciphersuites share
+type scheme interface {
+	ID() string
+	Curve() CurveSystem
+	KeyGen(ikm []byte, keyInfo []byte) (*Scalar, error)
+	SkToPk(sk *Scalar) []byte
+	KeyValidate(pk []byte) bool
+	Aggregate(sigs [][]byte) ([]byte, bool)
+	Sign(sk *Scalar, msg []byte) []byte
+	Verify(pk []byte, msg []byte, sig []byte) bool
+	AggregateVerify(pks [][]byte, msgs [][]byte, sig []byte) bool
+}
+
+var schemes = []scheme{Bls12381Basic, Bls12381Aug, Bls12381Pop, Altbn128Basic, Altbn128Aug, Altbn128Pop}
+
+// The master key of test case 0 of EIP-2333, which derives it with KeyGen
+func TestCiphersuiteKeyGenVector(t *testing.T) {
+	seed, _ := hex.DecodeString("c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f" +
+		"09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04")
+	sk, err := Bls12381Basic.KeyGen(seed, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "6083874454709270928345386274498605044986640685124978867557563392430687146096", sk.BigInt().String())
+	_, err = Bls12381Basic.KeyGen(seed[:31], nil)
+	assert.NotNil(t, err, "KeyGen accepted less than 32 bytes of keying material")
+}
+
+// ciphersuiteKeys derives n keys of s, from a fixed seed
+func ciphersuiteKeys(t *testing.T, s scheme, n int) ([]*Scalar, [][]byte) {
+	r := NewDeterministicReader([]byte(s.ID()))
+	sks := make([]*Scalar, n)
+	pks := make([][]byte, n)
+	for i := range sks {
+		ikm := make([]byte, 32)
+		r.Read(ikm)
+		var err error
+		sks[i], err = s.KeyGen(ikm, []byte("key info"))
+		assert.Nil(t, err)
+		pks[i] = s.SkToPk(sks[i])
+		assert.True(t, s.KeyValidate(pks[i]), s.ID()+" rejected a public key")
+	}
+	return sks, pks
+}
+
+func TestCiphersuites(t *testing.T) {
+	msgs := [][]byte{[]byte("first"), []byte("second"), []byte("third")}
+	for _, s := range schemes {
+		sks, pks := ciphersuiteKeys(t, s, len(msgs))
+		sigs := make([][]byte, len(msgs))
+		for i := range sks {
+			sigs[i] = s.Sign(sks[i], msgs[i])
+			assert.True(t, s.Verify(pks[i], msgs[i], sigs[i]), s.ID()+" signature verification failed")
+			assert.False(t, s.Verify(pks[i], msgs[(i+1)%len(msgs)], sigs[i]), s.ID()+" verified the wrong message")
+			assert.False(t, s.Verify(pks[(i+1)%len(msgs)], msgs[i], sigs[i]), s.ID()+" verified the wrong key")
+		}
+		aggsig, ok := s.Aggregate(sigs)
+		assert.True(t, ok)
+		assert.True(t, s.AggregateVerify(pks, msgs, aggsig), s.ID()+" aggregate verification failed")
+		assert.False(t, s.AggregateVerify(pks[:2], msgs[:2], aggsig), s.ID()+" aggregate verification succeeded without every key")
+		assert.False(t, s.AggregateVerify(nil, nil, aggsig), s.ID()+" aggregate verification succeeded without keys")
+		_, ok = s.Aggregate(nil)
+		assert.False(t, ok, s.ID()+" aggregated no signatures")
+		_, ok = s.Aggregate([][]byte{sigs[0], pks[0]})
+		assert.False(t, ok, s.ID()+" aggregated a public key as a signature")
+
+		// The identity isn't a valid public key, and neither is anything else
+		assert.False(t, s.KeyValidate(s.Curve().GetG2Infinity().MarshalCompressedStandard()))
+		assert.False(t, s.KeyValidate(sigs[0]))
+		assert.False(t, s.KeyValidate(nil))
+		zero := NewScalar(s.Curve(), big.NewInt(0))
+		assert.False(t, s.Verify(s.SkToPk(zero), msgs[0], s.Sign(zero, msgs[0])), s.ID()+" verified under the identity")
+
+		// The ID separates the ciphersuites
+		for _, other := range schemes {
+			if other != s && other.Curve() == s.Curve() {
+				assert.False(t, other.Verify(pks[0], msgs[0], sigs[0]), s.ID()+" signature verified under "+other.ID())
+			}
+		}
+	}
+}
+
+func TestCiphersuiteDuplicateMessages(t *testing.T) {
+	msg := []byte("message")
+	for _, s := range schemes {
+		sks, pks := ciphersuiteKeys(t, s, 2)
+		aggsig, _ := s.Aggregate([][]byte{s.Sign(sks[0], msg), s.Sign(sks[1], msg)})
+		_, basic := s.(*BasicCiphersuite)
+		assert.Equal(t, !basic, s.AggregateVerify(pks, [][]byte{msg, msg}, aggsig),
+			s.ID()+" only the basic scheme rejects repeated messages")
+	}
+}
+
+func TestCiphersuiteMessageAugmentation(t *testing.T) {
+	msg := []byte("message")
+	for _, s := range []*AugCiphersuite{Bls12381Aug, Altbn128Aug} {
+		sks, pks := ciphersuiteKeys(t, s, 1)
+		sig := s.Sign(sks[0], msg)
+		assert.Equal(t, sig, s.coreSign(sks[0], append(append([]byte{}, pks[0]...), msg...), s.hash),
+			"the message isn't prefixed with the public key")
+	}
+}
+
+func TestCiphersuiteProofOfPossession(t *testing.T) {
+	msg := []byte("message")
+	for _, s := range []*PopCiphersuite{Bls12381Pop, Altbn128Pop} {
+		sks, pks := ciphersuiteKeys(t, s, 3)
+		sigs := make([][]byte, len(sks))
+		for i := range sks {
+			proof := s.PopProve(sks[i])
+			assert.True(t, s.PopVerify(pks[i], proof), s.ID()+" proof of possession verification failed")
+			assert.False(t, s.PopVerify(pks[(i+1)%len(pks)], proof), s.ID()+" proof of possession verified for another key")
+			// The proof tag separates proofs from signatures on the public key
+			assert.False(t, s.Verify(pks[i], pks[i], proof), s.ID()+" proof of possession verified as a signature")
+			assert.False(t, s.PopVerify(pks[i], s.Sign(sks[i], pks[i])), s.ID()+" signature verified as a proof of possession")
+			sigs[i] = s.Sign(sks[i], msg)
+		}
+		aggsig, _ := s.Aggregate(sigs)
+		assert.True(t, s.FastAggregateVerify(pks, msg, aggsig), s.ID()+" fast aggregate verification failed")
+		assert.False(t, s.FastAggregateVerify(pks[:2], msg, aggsig), s.ID()+" fast aggregate verification succeeded without every key")
+		assert.False(t, s.FastAggregateVerify(pks, []byte("other"), aggsig), s.ID()+" fast aggregate verification of the wrong message")
+		assert.False(t, s.FastAggregateVerify(nil, msg, aggsig), s.ID()+" fast aggregate verification succeeded without keys")
+		assert.NotEqual(t, s.ID(), s.PopID())
+	}
+}
+
+func BenchmarkCiphersuiteVerify(b *testing.B) {
+	for _, s := range []scheme{Bls12381Pop, Altbn128Pop} {
+		ikm := make([]byte, 32)
+		sk, _ := s.KeyGen(ikm, nil)
+		pk, msg := s.SkToPk(sk), []byte("message")
+		sig := s.Sign(sk, msg)
+		b.Run(s.ID(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.Verify(pk, msg, sig)
+			}
+		})
+	}
+}
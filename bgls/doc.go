@@ -33,4 +33,8 @@
 // exponents is to write them to blake2x, and then to squeeze the corresponding
 // amount of output from the XOF.
 //
+// By default public keys are in G2 and signatures in G1. Functions with a MinPk
+// suffix, in blsMinPk.go, swap the groups, for uses which store many more keys
+// than signatures. Every scheme above is available in that orientation.
+//
 package bgls
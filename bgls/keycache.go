@@ -0,0 +1,102 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"sync"
+
+	. "github.com/orbs-network/bgls/curves" // nolint: golint
+)
+
+// KeyCache holds public keys which have been prepared for pairings with
+// G2Preparer.PrepareG2, so that validators which verify the signatures of the
+// same signers over and over only prepare each key once. Keys are looked up by
+// their marshalled encoding, so a key which is unmarshalled again for every
+// signature still hits the cache. It is safe for concurrent use.
+type KeyCache struct {
+	curve    G2Preparer
+	g2       PreparedG2
+	capacity int
+
+	mu   sync.RWMutex
+	keys map[string]PreparedG2
+}
+
+// DefaultKeyCacheCapacity is the capacity of a KeyCache which is created with a
+// capacity of zero or less. A prepared altbn128 key takes about 11 KB.
+const DefaultKeyCacheCapacity = 1024
+
+// NewKeyCache returns an empty cache of prepared keys on curve. Once it holds
+// capacity keys, an arbitrary key is evicted to make room for each new one.
+// A capacity of zero or less is replaced by DefaultKeyCacheCapacity.
+func NewKeyCache(curve G2Preparer, capacity int) *KeyCache {
+	if capacity <= 0 {
+		capacity = DefaultKeyCacheCapacity
+	}
+	g2, _ := curve.PrepareG2(curve.GetG2())
+	return &KeyCache{curve: curve, g2: g2, capacity: capacity, keys: make(map[string]PreparedG2)}
+}
+
+// Prepare returns the prepared pubKey, from the cache if it is there. It
+// returns false if pubKey isn't a point of G2 on the curve of the cache.
+func (c *KeyCache) Prepare(pubKey Point) (PreparedG2, bool) {
+	if pubKey == nil {
+		return nil, false
+	}
+	key := string(pubKey.Marshal())
+	c.mu.RLock()
+	prepared, ok := c.keys[key]
+	c.mu.RUnlock()
+	if ok {
+		return prepared, true
+	}
+	prepared, ok = c.curve.PrepareG2(pubKey)
+	if !ok {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[key]; !ok && len(c.keys) >= c.capacity {
+		for evicted := range c.keys {
+			delete(c.keys, evicted)
+			break
+		}
+	}
+	c.keys[key] = prepared
+	return prepared, true
+}
+
+// Len returns the number of keys in the cache.
+func (c *KeyCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.keys)
+}
+
+// VerifySingleSignature is VerifySingleSignature, with pubKey prepared through the cache.
+func (c *KeyCache) VerifySingleSignature(sig Point, pubKey Point, msg []byte) bool {
+	pts1, pts2, ok := singleSignaturePairs(c.curve, sig, pubKey, msg, c.curve.HashToG1)
+	return ok && c.pairingCheck(pts1, pts2)
+}
+
+// VerifyAggregateSignature is VerifyAggregateSignature, with the keys prepared
+// through the cache.
+func (c *KeyCache) VerifyAggregateSignature(aggsig Point, keys []Point, msgs [][]byte) bool {
+	pts1, pts2, ok := aggregateSignaturePairs(c.curve, aggsig, keys, msgs, false)
+	return ok && c.pairingCheck(pts1, pts2)
+}
+
+// pairingCheck is PairingCheck, where the last point of pts2 is the generator,
+// and the others are keys.
+func (c *KeyCache) pairingCheck(pts1 []Point, pts2 []Point) bool {
+	prepared := make([]PreparedG2, len(pts2))
+	for i := 0; i < len(pts2)-1; i++ {
+		var ok bool
+		if prepared[i], ok = c.Prepare(pts2[i]); !ok {
+			return false
+		}
+	}
+	prepared[len(pts2)-1] = c.g2
+	return c.curve.PairingCheckPrepared(pts1, prepared)
+}
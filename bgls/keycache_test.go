@@ -0,0 +1,89 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package bgls
+
+import (
+	"crypto/rand"
+	"testing"
+
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyCache(t *testing.T) {
+	curve := Altbn128
+	N := 3
+	cache := NewKeyCache(curve, N-1)
+	msgs := make([][]byte, N)
+	sigs := make([]Point, N)
+	pubkeys := make([]Point, N)
+	for i := 0; i < N; i++ {
+		msgs[i] = make([]byte, 32)
+		rand.Read(msgs[i])
+		sk, vk, _ := KeyGen(curve)
+		sigs[i] = Sign(curve, sk, msgs[i])
+		pubkeys[i] = vk
+	}
+	for i := 0; i < N; i++ {
+		// The second verification uses the cached key
+		for j := 0; j < 2; j++ {
+			assert.True(t, cache.VerifySingleSignature(sigs[i], pubkeys[i], msgs[i]),
+				"Signature verification with the key cache failed")
+		}
+		assert.False(t, cache.VerifySingleSignature(sigs[i], pubkeys[(i+1)%N], msgs[i]),
+			"Signature verification with the key cache succeeding with the wrong key")
+	}
+	assert.Equal(t, N-1, cache.Len(), "The key cache grew past its capacity")
+
+	aggSig := AggregateSignatures(sigs)
+	assert.True(t, cache.VerifyAggregateSignature(aggSig, pubkeys, msgs),
+		"Aggregate signature verification with the key cache failed")
+	assert.False(t, cache.VerifyAggregateSignature(aggSig, pubkeys[:N-1], msgs),
+		"Aggregate signature verification with the key cache succeeding without enough pubkeys")
+
+	_, ok := cache.Prepare(curve.GetG1())
+	assert.False(t, ok, "A point of G1 was prepared as a key")
+}
+
+func TestKeyCacheUnmarshalledKeys(t *testing.T) {
+	curve := Altbn128
+	N := 3
+	cache := NewKeyCache(curve, 0)
+	msgs := make([][]byte, N)
+	sigs := make([]Point, N)
+	encoded := make([][]byte, N)
+	for i := 0; i < N; i++ {
+		msgs[i] = make([]byte, 32)
+		rand.Read(msgs[i])
+		sk, vk, _ := KeyGen(curve)
+		sigs[i] = Sign(curve, sk, msgs[i])
+		encoded[i] = vk.Marshal()
+	}
+	// Each round decodes the keys again, as a validator would for keys which
+	// arrive over the wire, and must hit the cache
+	for round := 0; round < 3; round++ {
+		pubkeys := make([]Point, N)
+		for i := 0; i < N; i++ {
+			var ok bool
+			pubkeys[i], ok = curve.UnmarshalG2(encoded[i])
+			assert.True(t, ok)
+			assert.True(t, cache.VerifySingleSignature(sigs[i], pubkeys[i], msgs[i]),
+				"Signature verification with an unmarshalled key failed")
+		}
+		assert.True(t, cache.VerifyAggregateSignature(AggregateSignatures(sigs), pubkeys, msgs),
+			"Aggregate signature verification with unmarshalled keys failed")
+		assert.Equal(t, N, cache.Len(), "Unmarshalled keys missed the key cache")
+	}
+}
+
+func BenchmarkKeyCache(b *testing.B) {
+	sk, vk, _ := KeyGen(benchmarkCurve)
+	msg := []byte("benchmark")
+	sig := Sign(benchmarkCurve, sk, msg)
+	cache := NewKeyCache(benchmarkCurve, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache.VerifySingleSignature(sig, vk, msg)
+	}
+}
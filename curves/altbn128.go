@@ -5,31 +5,82 @@ package curves
 
 import (
 	"bytes"
+	"context"
 	"math/big"
+	"sync"
 
 	"github.com/dchest/blake2b"
-	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
 	gosha3 "github.com/ethereum/go-ethereum/crypto/sha3"
 	"golang.org/x/crypto/sha3"
 )
 
 type altbn128 struct {
+	backend    Bn256Backend
+	g1         *altbn128Point1
+	g2         *altbn128Point2
+	gt         PointT
+	gtIdentity PointT
+	g1Table    *fixedBaseTable
+	g2Table    *fixedBaseTable
 }
 
+// Points and elements of GT record their curve, since curves over different
+// backends can't operate on each other's values.
 type altbn128Point1 struct {
-	point *bn256.G1
+	curve *altbn128
+	point bn256G1
 }
 
 type altbn128Point2 struct {
-	point *bn256.G2
+	curve *altbn128
+	point bn256G2
 }
 
 type altbn128PointT struct {
-	point *bn256.GT
+	curve *altbn128
+	point bn256GT
 }
 
 // Altbn128Inst is the instance for the altbn128 curve, with all of its functions.
-var Altbn128 = &altbn128{}
+// It uses the Bn256Cloudflare backend.
+var Altbn128 = newAltbn128(Bn256Cloudflare)
+
+var altbnCurves = struct {
+	sync.Mutex
+	byBackend map[Bn256Backend]*altbn128
+}{byBackend: map[Bn256Backend]*altbn128{}}
+
+// NewAltbn128 returns the altbn128 curve over the given backend, which is
+// Altbn128 for Bn256Cloudflare. Every backend computes the same results, and
+// the encodings of points don't depend on the backend, but points of curves
+// over different backends can't be combined, except by encoding and decoding
+// them. The curve is built once for each backend. Only Altbn128 is registered.
+func NewAltbn128(backend Bn256Backend) CurveSystem {
+	if backend == Altbn128.backend {
+		return Altbn128
+	}
+	altbnCurves.Lock()
+	defer altbnCurves.Unlock()
+	curve, ok := altbnCurves.byBackend[backend]
+	if !ok {
+		curve = newAltbn128(backend)
+		altbnCurves.byBackend[backend] = curve
+	}
+	return curve
+}
+
+func newAltbn128(backend Bn256Backend) *altbn128 {
+	curve := &altbn128{backend: backend}
+	g1, _ := curve.MakeG1Point([]*big.Int{one, two}, false)
+	g2, _ := curve.MakeG2Point(altbnG2Generator, false)
+	identity, _ := backend.gtFromBytes(append(make([]byte, 383), 1))
+	curve.g1, curve.g2 = g1.(*altbn128Point1), g2.(*altbn128Point2)
+	curve.gtIdentity = altbn128PointT{curve, identity}
+	curve.gt, _ = curve.Pair(curve.g1, curve.g2)
+	curve.g1Table = newFixedBaseTable(curve.g1, altbnG1Order, curve.GetG1Infinity, nil)
+	curve.g2Table = newFixedBaseTable(curve.g2, altbnG1Order, curve.GetG2Infinity, nil)
+	return curve
+}
 
 // Returns the name of the curve
 func (curve *altbn128) Name() string {
@@ -37,50 +88,62 @@ func (curve *altbn128) Name() string {
 }
 
 // MakeG1Point copies points into []byte and unmarshals to get around curvePoint not being exported
-// Check does nothing here, because the upstream library always
-// ensures that the point is on the curve.
+// If check is set, the point is first checked to be on the curve here. The
+// backend checks that again when it decodes the point, and G1 has cofactor one,
+// so points outside of G1 are rejected whether or not check is set.
 func (curve *altbn128) MakeG1Point(coords []*big.Int, check bool) (Point, bool) {
 	if len(coords) != 2 {
 		return nil, false
 	}
+	if check && !altbnG1IsOnCurve(coords[0], coords[1]) {
+		return nil, false
+	}
 	xBytes, yBytes := coords[0].Bytes(), coords[1].Bytes()
 	ret := make([]byte, 64)
 	copy(ret[32-len(xBytes):], xBytes)
 	copy(ret[64-len(yBytes):], yBytes)
-	result := new(bn256.G1)
-	var ok error
-	_, ok = result.Unmarshal(ret)
-	if ok != nil {
+	result, ok := curve.backend.g1FromBytes(ret)
+	if !ok {
 		return nil, false
 	}
-	return &altbn128Point1{result}, true
+	return &altbn128Point1{curve, result}, true
 }
 
 func (g1Point *altbn128Point1) Add(otherPoint1 Point) (Point, bool) {
-	if other, ok := (otherPoint1).(*altbn128Point1); ok {
-		sum := new(bn256.G1).Add(g1Point.point, other.point)
-		ret := &altbn128Point1{sum}
+	if other, ok := (otherPoint1).(*altbn128Point1); ok && other.curve == g1Point.curve {
+		sum := g1Point.point.add(other.point)
+		ret := &altbn128Point1{g1Point.curve, sum}
 		return ret, true
 	}
 	return nil, false
 }
 
 func (g1Point *altbn128Point1) Copy() Point {
-	result := new(bn256.G1)
-	result.Unmarshal(g1Point.point.Marshal())
-	return &altbn128Point1{result}
+	result, _ := g1Point.curve.backend.g1FromBytes(g1Point.point.bytes())
+	return &altbn128Point1{g1Point.curve, result}
 }
 
 func (g1Point *altbn128Point1) Equals(otherPoint1 Point) bool {
-	if other, ok := (otherPoint1).(*altbn128Point1); ok {
-		return bytes.Equal(g1Point.point.Marshal(), other.point.Marshal())
+	if other, ok := (otherPoint1).(*altbn128Point1); ok && other.curve == g1Point.curve {
+		return bytes.Equal(g1Point.point.bytes(), other.point.bytes())
 	}
 	return false
 }
 
+func (g1Point *altbn128Point1) pointCurve() CurveSystem {
+	return g1Point.curve
+}
+
+// Marshal returns the 32 byte compressed encoding of the point. The highest bit is
+// set if y > q / 2, and the point at infinity is encoded with only the second
+// highest bit set.
 func (g1Point *altbn128Point1) Marshal() []byte {
 	coords := g1Point.ToAffineCoords()
 	xBytes := pad32Bytes(coords[0].Bytes())
+	if g1Point.IsInfinity() {
+		xBytes[0] = altbnInfinityFlag
+		return xBytes
+	}
 	coords[1].Mul(coords[1], two)
 	if coords[1].Cmp(altbnG1Q) == 1 {
 		xBytes[0] += 128
@@ -88,8 +151,28 @@ func (g1Point *altbn128Point1) Marshal() []byte {
 	return xBytes
 }
 
+// MarshalCompressedStandard returns the 32 byte compressed encoding used by
+// gnark-crypto and other BN254 libraries. Since q < 2^254, there is only room
+// for two flag bits in the highest byte of x: 10 if y is the lexicographically
+// smallest root, 11 if it is the largest, and 01 for the point at infinity.
+func (g1Point *altbn128Point1) MarshalCompressedStandard() []byte {
+	if g1Point.IsInfinity() {
+		xBytes := make([]byte, 32)
+		xBytes[0] = altbnCompressedInfinity
+		return xBytes
+	}
+	coords := g1Point.ToAffineCoords()
+	xBytes := pad32Bytes(coords[0].Bytes())
+	if parity(coords[1], altbnG1Q) {
+		xBytes[0] |= altbnCompressedLargest
+	} else {
+		xBytes[0] |= altbnCompressedSmallest
+	}
+	return xBytes
+}
+
 func (g1Point *altbn128Point1) MarshalUncompressed() []byte {
-	return g1Point.point.Marshal()
+	return g1Point.point.bytes()
 }
 
 func pad32Bytes(xBytes []byte) []byte {
@@ -111,57 +194,226 @@ func (g1Point *altbn128Point1) Mul(scalar *big.Int) Point {
 		g1Point = g1Point.Negate()
 		scalar2.Mul(scalar, big.NewInt(-1))
 	} else if cmp == 0 {
-		return Altbn128.GetG1Infinity()
+		return g1Point.curve.GetG1Infinity()
 	} else {
 		scalar2 = scalar
 	}
-	prod := new(bn256.G1).ScalarMult(g1Point.point, scalar2)
-	ret := &altbn128Point1{prod}
+	prod := g1Point.point.mul(scalar2)
+	ret := &altbn128Point1{g1Point.curve, prod}
 	return ret
 }
 
 func (g1Point *altbn128Point1) Negate() *altbn128Point1 {
 	coords := g1Point.ToAffineCoords()
-	coords[1].Sub(altbnG1Q, coords[1])
-	newPt, _ := Altbn128.MakeG1Point(coords, false)
+	coords[1].Sub(altbnG1Q, coords[1]).Mod(coords[1], altbnG1Q)
+	newPt, _ := g1Point.curve.MakeG1Point(coords, false)
 	return newPt.(*altbn128Point1)
 }
 
 func (curve *altbn128) Pair(g1Point Point, g2Point Point) (PointT, bool) {
 	pt1, ok := g1Point.(*altbn128Point1)
-	if !ok {
+	if !ok || pt1.curve != curve {
 		return nil, false
 	}
-	if pt2, ok := (g2Point).(*altbn128Point2); ok {
-		p3 := bn256.Pair(pt1.point, pt2.point)
-		ret := altbn128PointT{p3}
+	if pt2, ok := (g2Point).(*altbn128Point2); ok && pt2.curve == curve {
+		// The Miller loop doesn't handle the point at infinity, whose pairings are one.
+		if pt1.IsInfinity() || pt2.IsInfinity() {
+			return curve.gtIdentity, true
+		}
+		p3 := curve.backend.finalExp(curve.backend.miller(pt1.point, pt2.point))
+		ret := altbn128PointT{curve, p3}
 		return ret, true
 	}
 	return nil, false
 }
 
+// PairingProduct computes the product of pairings with a single final exponentiation,
+// as the Ethereum pairing precompile does.
 func (curve *altbn128) PairingProduct(g1Points []Point, g2Points []Point) (PointT, bool) {
-	return concurrentPairingProduct(curve, g1Points, g2Points)
+	prod, err := curve.PairingProductContext(context.Background(), g1Points, g2Points)
+	return prod, err == nil
+}
+
+// PairingProductContext computes the product of pairings with a single final
+// exponentiation. Large batches are split into contiguous chunks, whose Miller
+// loops are run in parallel on the shared executor, and the partial products are
+// multiplied together before the final exponentiation.
+func (curve *altbn128) PairingProductContext(ctx context.Context, g1Points []Point, g2Points []Point) (PointT, error) {
+	if len(g1Points) != len(g2Points) {
+		return nil, errInvalidPairingInput
+	}
+	pts1 := make([]bn256G1, 0, len(g1Points))
+	pts2 := make([]bn256G2, 0, len(g2Points))
+	for i := 0; i < len(g1Points); i++ {
+		pt1, ok1 := g1Points[i].(*altbn128Point1)
+		pt2, ok2 := g2Points[i].(*altbn128Point2)
+		if !ok1 || !ok2 || pt1.curve != curve || pt2.curve != curve {
+			return nil, errInvalidPairingInput
+		}
+		// The Miller loop doesn't handle the point at infinity, whose pairings are one.
+		if pt1.IsInfinity() || pt2.IsInfinity() {
+			continue
+		}
+		pts1 = append(pts1, pt1.point)
+		pts2 = append(pts2, pt2.point)
+	}
+	return curve.pairingProduct(ctx, len(pts1), curve.millerLoops(ctx, pts1, pts2))
+}
+
+// millerLoops returns the function for pairingProduct which multiplies the
+// Miller loops of the backend with pts1[i] and pts2[i].
+func (curve *altbn128) millerLoops(ctx context.Context, pts1 []bn256G1, pts2 []bn256G2) func(start, end int) bn256GT {
+	return func(start, end int) bn256GT {
+		acc := curve.backend.miller(pts1[start], pts2[start])
+		for i := start + 1; i < end && ctx.Err() == nil; i++ {
+			acc = acc.mul(curve.backend.miller(pts1[i], pts2[i]))
+		}
+		return acc
+	}
+}
+
+// pairingProduct applies the final exponentiation to the product of n Miller
+// loops, where miller(start, end) returns the product of the loops in
+// [start, end). miller may stop early once ctx is done.
+func (curve *altbn128) pairingProduct(ctx context.Context, n int, miller func(start, end int) bn256GT) (PointT, error) {
+	if n == 0 {
+		return curve.gtIdentity, ctx.Err()
+	}
+
+	ranges := chunks(n, parallelism(), altbnMinPairsPerWorker)
+	partials := make([]bn256GT, len(ranges))
+	err := Parallelize(ctx, len(ranges), func(w int) error {
+		partials[w] = miller(ranges[w][0], ranges[w][1])
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	acc := partials[0]
+	for w := 1; w < len(partials); w++ {
+		acc = acc.mul(partials[w])
+	}
+	return altbn128PointT{curve, curve.backend.finalExp(acc)}, nil
+}
+
+// PairingCheck returns true if the product of pairings is the identity in GT.
+func (curve *altbn128) PairingCheck(g1Points []Point, g2Points []Point) bool {
+	prod, ok := curve.PairingProduct(g1Points, g2Points)
+	return ok && prod.Equals(curve.gtIdentity)
+}
+
+type altbn128PreparedG2 struct {
+	pt *altbn128Point2
+	// lines are the lines of the Miller loop with pt, or nil if pt is infinity
+	lines []altbnLine
+}
+
+func (prepared *altbn128PreparedG2) G2() Point {
+	return prepared.pt
+}
+
+// PrepareG2 computes the lines of the Miller loop with the point in advance.
+// Pairings with prepared points then evaluate the lines in Montgomery form in
+// this package, rather than in the Miller loop of the backend, and only use the
+// backend for the final exponentiation. The google backend has no separate
+// final exponentiation, so its pairings with prepared points cost the same.
+func (curve *altbn128) PrepareG2(g2Point Point) (PreparedG2, bool) {
+	pt, ok := g2Point.(*altbn128Point2)
+	if !ok || pt.curve != curve {
+		return nil, false
+	}
+	prepared := &altbn128PreparedG2{pt: pt}
+	if pt.IsInfinity() {
+		return prepared, true
+	}
+	coords := pt.ToAffineCoords()
+	q := newAltbnTwistPoint(&complexNum{coords[0], coords[1]}, &complexNum{coords[2], coords[3]})
+	prepared.lines = altbnPrepareLines(q)
+	return prepared, true
+}
+
+// PairingProductPrepared is PairingProduct with prepared points of G2. The
+// Miller loops of the pairings in each chunk share their squarings.
+func (curve *altbn128) PairingProductPrepared(g1Points []Point, g2Points []PreparedG2) (PointT, bool) {
+	if len(g1Points) != len(g2Points) {
+		return nil, false
+	}
+	pts1 := make([]bn256G1, 0, len(g1Points))
+	pts2 := make([]bn256G2, 0, len(g2Points))
+	lines := make([][]altbnLine, 0, len(g2Points))
+	for i := 0; i < len(g1Points); i++ {
+		pt1, ok1 := g1Points[i].(*altbn128Point1)
+		pt2, ok2 := g2Points[i].(*altbn128PreparedG2)
+		if !ok1 || !ok2 || pt1.curve != curve || pt2.pt.curve != curve {
+			return nil, false
+		}
+		if pt1.IsInfinity() || pt2.lines == nil {
+			continue
+		}
+		pts1 = append(pts1, pt1.point)
+		pts2 = append(pts2, pt2.pt.point)
+		lines = append(lines, pt2.lines)
+	}
+	ctx := context.Background()
+	millers := func(start, end int) bn256GT {
+		f, _ := curve.backend.gtFromBytes(altbnMultiMiller(pts1[start:end], lines[start:end]))
+		return f
+	}
+	if _, ok := curve.backend.(bn256PairingOnly); ok {
+		millers = curve.millerLoops(ctx, pts1, pts2)
+	}
+	prod, err := curve.pairingProduct(ctx, len(pts1), millers)
+	return prod, err == nil
+}
+
+// PairingCheckPrepared is PairingCheck with prepared points of G2.
+func (curve *altbn128) PairingCheckPrepared(g1Points []Point, g2Points []PreparedG2) bool {
+	prod, ok := curve.PairingProductPrepared(g1Points, g2Points)
+	return ok && prod.Equals(curve.gtIdentity)
 }
 
 // ToAffineCoords returns the affine coordinate representation of the point
 // in the form: [X, Y]
 func (g1Point *altbn128Point1) ToAffineCoords() []*big.Int {
-	Bytestream := g1Point.point.Marshal()
+	Bytestream := g1Point.point.bytes()
 	xBytes, yBytes := Bytestream[:32], Bytestream[32:64]
 	x := new(big.Int).SetBytes(xBytes)
 	y := new(big.Int).SetBytes(yBytes)
 	return []*big.Int{x, y}
 }
 
+// IsOnCurve checks that the point satisfies y^2 = x^3 + 3, or is the point at infinity.
+func (g1Point *altbn128Point1) IsOnCurve() bool {
+	coords := g1Point.ToAffineCoords()
+	return altbnG1IsOnCurve(coords[0], coords[1])
+}
+
+// IsInSubgroup checks that the point is in G1. Since the cofactor is one, this is
+// the same as being on the curve.
+func (g1Point *altbn128Point1) IsInSubgroup() bool {
+	return g1Point.IsOnCurve()
+}
+
+func (g1Point *altbn128Point1) IsInfinity() bool {
+	return g1Point.Equals(g1Point.curve.GetG1Infinity())
+}
+
 // MakeG2Point expects coords to be of the form: [x0, x1, y0, y1],
 // where X = x0 * i + x1, and Y = y0 * i + y1
-// check does nothing, since the upstream repository always checks if the point
-// is on the curve
+// check has no effect on which points are accepted. If it is set, the point is
+// first checked to be on the twist curve, which only rejects such points
+// sooner. The subgroup check, which closes small-subgroup attacks on public keys,
+// always runs in the backend's g2FromBytes: cloudflare and google multiply the
+// point by the group order in Unmarshal, and the reference backend uses
+// altbnG2IsInSubgroup. The faster altbnG2IsInSubgroup isn't run on top of the
+// upstream check, since it can't replace it.
 func (curve *altbn128) MakeG2Point(coords []*big.Int, check bool) (Point, bool) {
 	if len(coords) != 4 {
 		return nil, false
 	}
+	if check && !altbnG2IsOnCurve(coords) {
+		return nil, false
+	}
 	x0Bytes, x1Bytes := pad32Bytes(coords[0].Bytes()), pad32Bytes(coords[1].Bytes())
 	y0Bytes, y1Bytes := pad32Bytes(coords[2].Bytes()), pad32Bytes(coords[3].Bytes())
 	ret := make([]byte, 128)
@@ -169,41 +421,51 @@ func (curve *altbn128) MakeG2Point(coords []*big.Int, check bool) (Point, bool)
 	copy(ret[32:], x1Bytes)
 	copy(ret[64:], y0Bytes)
 	copy(ret[96:], y1Bytes)
-	result := new(bn256.G2)
-	var ok error
-	_, ok = result.Unmarshal(ret)
-	if ok != nil {
+	result, ok := curve.backend.g2FromBytes(ret)
+	if !ok {
 		return nil, false
 	}
-	return &altbn128Point2{result}, true
+	return &altbn128Point2{curve, result}, true
 }
 
 func (g2Point *altbn128Point2) Add(otherPoint2 Point) (Point, bool) {
-	if other, ok := (otherPoint2).(*altbn128Point2); ok {
-		sum := new(bn256.G2).Add(g2Point.point, other.point)
-		ret := &altbn128Point2{sum}
+	if other, ok := (otherPoint2).(*altbn128Point2); ok && other.curve == g2Point.curve {
+		sum := g2Point.point.add(other.point)
+		ret := &altbn128Point2{g2Point.curve, sum}
 		return ret, true
 	}
 	return nil, false
 }
 
 func (g2Point *altbn128Point2) Copy() Point {
-	result := new(bn256.G2)
-	result.Unmarshal(g2Point.point.Marshal())
-	return &altbn128Point2{result}
+	result, _ := g2Point.curve.backend.g2FromBytes(g2Point.point.bytes())
+	return &altbn128Point2{g2Point.curve, result}
 }
 
 func (g2Point *altbn128Point2) Equals(otherPoint2 Point) bool {
-	if other, ok := (otherPoint2).(*altbn128Point2); ok {
-		return bytes.Equal(g2Point.point.Marshal(), other.point.Marshal())
+	if other, ok := (otherPoint2).(*altbn128Point2); ok && other.curve == g2Point.curve {
+		return bytes.Equal(g2Point.point.bytes(), other.point.bytes())
 	}
 	return false
 }
 
+func (g2Point *altbn128Point2) pointCurve() CurveSystem {
+	return g2Point.curve
+}
+
+// Marshal returns the 64 byte compressed encoding of the point, xi followed by xr.
+// The highest bit of xi is set if yi > q / 2, and the highest bit of xr is set if
+// yr > q / 2. The point at infinity is encoded with only the second highest bit
+// of xi set.
 func (g2Point *altbn128Point2) Marshal() []byte {
 	coords := g2Point.ToAffineCoords()
 	xiBytes := pad32Bytes(coords[0].Bytes())
 	xrBytes := pad32Bytes(coords[1].Bytes())
+	if g2Point.IsInfinity() {
+		xBytes := make([]byte, 64)
+		xBytes[0] = altbnInfinityFlag
+		return xBytes
+	}
 	y2 := &complexNum{coords[2], coords[3]}
 	y2.Exp(y2, two, altbnG1Q)
 	coords[2].Mul(coords[2], two)
@@ -220,15 +482,36 @@ func (g2Point *altbn128Point2) Marshal() []byte {
 	return xBytes
 }
 
+// MarshalCompressedStandard returns the 64 byte compressed encoding used by
+// gnark-crypto and other BN254 libraries, xi followed by xr, with the same flags
+// as G1 in the highest byte. y = yi * i + yr is lexicographically largest if yi
+// is, or if yi = 0 and yr is.
+func (g2Point *altbn128Point2) MarshalCompressedStandard() []byte {
+	xBytes := make([]byte, 64)
+	if g2Point.IsInfinity() {
+		xBytes[0] = altbnCompressedInfinity
+		return xBytes
+	}
+	coords := g2Point.ToAffineCoords()
+	copy(xBytes[:32], pad32Bytes(coords[0].Bytes()))
+	copy(xBytes[32:], pad32Bytes(coords[1].Bytes()))
+	if complexLexicographicallyLargest(&complexNum{coords[2], coords[3]}, altbnG1Q) {
+		xBytes[0] |= altbnCompressedLargest
+	} else {
+		xBytes[0] |= altbnCompressedSmallest
+	}
+	return xBytes
+}
+
 func (g2Point *altbn128Point2) MarshalUncompressed() []byte {
-	return g2Point.point.Marshal()
+	return g2Point.point.bytes()
 }
 
 func (g2Point *altbn128Point2) Negate() *altbn128Point2 {
 	coords := g2Point.ToAffineCoords()
-	coords[2].Sub(altbnG1Q, coords[2])
-	coords[3].Sub(altbnG1Q, coords[3])
-	newPt, _ := Altbn128.MakeG2Point(coords, false)
+	coords[2].Sub(altbnG1Q, coords[2]).Mod(coords[2], altbnG1Q)
+	coords[3].Sub(altbnG1Q, coords[3]).Mod(coords[3], altbnG1Q)
+	newPt, _ := g2Point.curve.MakeG2Point(coords, false)
 	return newPt.(*altbn128Point2)
 }
 
@@ -239,19 +522,19 @@ func (g2Point *altbn128Point2) Mul(scalar *big.Int) Point {
 		g2Point = g2Point.Negate()
 		scalar2.Mul(scalar, big.NewInt(-1))
 	} else if cmp == 0 {
-		return Altbn128.GetG2Infinity()
+		return g2Point.curve.GetG2Infinity()
 	} else {
 		scalar2 = scalar
 	}
-	prod := new(bn256.G2).ScalarMult(g2Point.point, scalar2)
-	ret := &altbn128Point2{prod}
+	prod := g2Point.point.mul(scalar2)
+	ret := &altbn128Point2{g2Point.curve, prod}
 	return ret
 }
 
 // ToAffineCoords returns the affine coordinate representation of the point
 // in the form: [x0, x1, y0, y1], where X = x0 * u + x1, and Y = y0 * u + y1
 func (g2Point *altbn128Point2) ToAffineCoords() []*big.Int {
-	Bytestream := g2Point.point.Marshal()
+	Bytestream := g2Point.point.bytes()
 	x0Bytes, x1Bytes := Bytestream[:32], Bytestream[32:64]
 	y0Bytes, y1Bytes := Bytestream[64:96], Bytestream[96:128]
 	x0 := new(big.Int).SetBytes(x0Bytes)
@@ -261,57 +544,170 @@ func (g2Point *altbn128Point2) ToAffineCoords() []*big.Int {
 	return []*big.Int{x0, x1, y0, y1}
 }
 
+// IsOnCurve checks that the point satisfies y^2 = x^3 + b / xi over Fp2, or is
+// the point at infinity.
+func (g2Point *altbn128Point2) IsOnCurve() bool {
+	return altbnG2IsOnCurve(g2Point.ToAffineCoords())
+}
+
+// IsInSubgroup checks that the point is in G2, with altbnG2IsInSubgroup.
+func (g2Point *altbn128Point2) IsInSubgroup() bool {
+	return altbnG2IsInSubgroup(g2Point.ToAffineCoords())
+}
+
+func (g2Point *altbn128Point2) IsInfinity() bool {
+	return g2Point.Equals(g2Point.curve.GetG2Infinity())
+}
+
 func (gTPoint altbn128PointT) Add(otherPointT PointT) (PointT, bool) {
-	if other, ok := (otherPointT).(altbn128PointT); ok {
-		sum := new(bn256.GT).Add(gTPoint.point, other.point)
-		ret := altbn128PointT{sum}
+	if other, ok := (otherPointT).(altbn128PointT); ok && other.curve == gTPoint.curve {
+		sum := gTPoint.point.mul(other.point)
+		ret := altbn128PointT{gTPoint.curve, sum}
 		return ret, true
 	}
 	return nil, false
 }
 
 func (gTPoint altbn128PointT) Copy() PointT {
-	result := new(bn256.GT)
-	result.Unmarshal(gTPoint.point.Marshal())
-	return &altbn128PointT{result}
+	result, _ := gTPoint.curve.backend.gtFromBytes(gTPoint.point.bytes())
+	return altbn128PointT{gTPoint.curve, result}
+}
+
+// Inverse returns the conjugate of the element, which is its inverse in GT.
+func (gTPoint altbn128PointT) Inverse() PointT {
+	return altbn128PointT{gTPoint.curve, gTPoint.point.inverse()}
+}
+
+func (gTPoint altbn128PointT) pointCurve() CurveSystem {
+	return gTPoint.curve
 }
 
 func (gTPoint altbn128PointT) Marshal() []byte {
-	return gTPoint.point.Marshal()
+	return gTPoint.point.bytes()
+}
+
+// MarshalCompressed returns the 192 byte compression of the element to the torus T2.
+func (gTPoint altbn128PointT) MarshalCompressed() []byte {
+	return altbnGTTower.compress(gTPoint.point.bytes())
+}
+
+// ToAffineCoords returns the 12 coefficients of the element over Fp, in the order
+// of Marshal. With Fp12 = Fp6[w], Fp6 = Fp2[v] and Fp2 = Fp[i], these are the
+// coefficients of w v^2 i, w v^2, w v i, w v, w i, w, v^2 i, v^2, v i, v, i and 1.
+func (gTPoint altbn128PointT) ToAffineCoords() []*big.Int {
+	coords, _ := altbnGTTower.coords(gTPoint.point.bytes())
+	return coords
 }
 
 func (gTPoint altbn128PointT) Equals(otherPointT PointT) bool {
-	if other, ok := (otherPointT).(altbn128PointT); ok {
+	if other, ok := (otherPointT).(altbn128PointT); ok && other.curve == gTPoint.curve {
 		return bytes.Equal(gTPoint.Marshal(), other.Marshal())
 	}
 	return false
 }
 
+// Mul exponentiates the element by scalar, which may be negative.
 func (gTPoint altbn128PointT) Mul(scalar *big.Int) PointT {
-	prod := new(bn256.GT).ScalarMult(gTPoint.point, scalar)
-	ret := altbn128PointT{prod}
-	return ret
+	k := new(big.Int).Mod(scalar, altbnG1Order)
+	return altbn128PointT{gTPoint.curve, gTPoint.curve.gtExp(gTPoint.point, k)}
+}
+
+// gtExp returns f^k for f in GT and 0 <= k < r. On GT, the Frobenius map
+// f -> f^p is cheap, and it equals exponentiation by lambda = p mod r, which
+// has about half as many bits as r. So k is split as k0 + k1 lambda, and
+// f^k = f^k0 * (f^p)^k1 is computed with half the squarings.
+func (curve *altbn128) gtExp(f bn256GT, k *big.Int) bn256GT {
+	k0, k1 := new(big.Int).DivMod(k, altbnGTLambda, new(big.Int))
+	k0, k1 = k1, k0
+	return curve.gtMultiExp([]bn256GT{f, curve.gtFrobenius(f)}, []*big.Int{k0, k1})
+}
+
+// gtMultiExp returns the product of bases[i]^ks[i] for ks[i] >= 0. Since
+// inverses in GT are conjugates, which are free, the exponents are written in
+// signed digits (wNAF), which needs fewer multiplications than binary. The
+// squarings are shared between the exponents. The whole computation is done in
+// altbnGTTower, where elements of GT have a cyclotomic squaring that is faster
+// than the generic squaring of the backend.
+func (curve *altbn128) gtMultiExp(bases []bn256GT, ks []*big.Int) bn256GT {
+	t := altbnGTTower
+	digits := make([][]int, len(ks))
+	tables := make([][]fp12Mont, len(ks))
+	maxLen := 0
+	for j := range ks {
+		digits[j] = wnaf(ks[j], altbnGTWindow)
+		if len(digits[j]) > maxLen {
+			maxLen = len(digits[j])
+		}
+		// tables[j] holds the odd powers b, b^3, b^5, ...
+		tables[j] = make([]fp12Mont, 1<<(altbnGTWindow-2))
+		tables[j][0] = t.montFromBytes(bases[j].bytes())
+		var squared fp12Mont
+		t.cyclotomicSquare(&squared, &tables[j][0])
+		for i := 1; i < len(tables[j]); i++ {
+			t.montMul(&tables[j][i], &tables[j][i-1], &squared)
+		}
+	}
+	acc := t.montOne()
+	for i := maxLen - 1; i >= 0; i-- {
+		t.cyclotomicSquare(&acc, &acc)
+		for j := range digits {
+			if i >= len(digits[j]) || digits[j][i] == 0 {
+				continue
+			}
+			term := tables[j][absInt(digits[j][i])/2]
+			if digits[j][i] < 0 {
+				t.montConjugate(&term, &term)
+			}
+			t.montMul(&acc, &acc, &term)
+		}
+	}
+	result, _ := curve.backend.gtFromBytes(t.montBytes(&acc))
+	return result
 }
 
+// gtFrobenius returns f^p, which is computed with the reference backend.
+func (curve *altbn128) gtFrobenius(f bn256GT) bn256GT {
+	ref, _ := Bn256Reference.gtFromBytes(f.bytes())
+	result, _ := curve.backend.gtFromBytes(ref.(referenceGT).frobenius().bytes())
+	return result
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// UnmarshalG1 accepts both the 32 byte compressed and 64 byte uncompressed encodings.
+// The infinity flag is only accepted with every other bit unset. For
+// compatibility, a compressed encoding with x = 0 is also read as the point
+// at infinity. data is not modified.
 func (curve *altbn128) UnmarshalG1(data []byte) (Point, bool) {
 	if data == nil || (len(data) != 64 && len(data) != 32) {
 		return nil, false
 	}
+	data = append([]byte{}, data...)
 	if len(data) == 64 { // No point compression
-		curvePoint := new(bn256.G1)
-		if _, ok := curvePoint.Unmarshal(data); ok == nil {
-			return &altbn128Point1{curvePoint}, true
+		if curvePoint, ok := curve.backend.g1FromBytes(data); ok {
+			return &altbn128Point1{curve, curvePoint}, true
 		}
 	} else if len(data) == 32 { // Point compression
+		if data[0]&altbnInfinityFlag != 0 {
+			if !isAltbnInfinityEncoding(data) {
+				return nil, false
+			}
+			return curve.GetG1Infinity(), true
+		}
 		ySgn := (data[0] >= 128)
 		if ySgn {
 			data[0] -= 128
 		}
 		x := new(big.Int).SetBytes(data)
 		if x.Cmp(zero) == 0 {
-			return Altbn128.GetG1Infinity(), true
+			return curve.GetG1Infinity(), true
 		}
-		y := Altbn128.g1XToYSquared(x)
+		y := curve.g1XToYSquared(x)
 		// Underlying library already checks that y is on the curve, thus isQuadRes isn't checked here
 		y = calcQuadRes(y, altbnG1Q)
 		doubleY := new(big.Int).Mul(y, two)
@@ -322,68 +718,244 @@ func (curve *altbn128) UnmarshalG1(data []byte) (Point, bool) {
 		} else if !ySgn && cmpRes == 1 {
 			y.Sub(altbnG1Q, y)
 		}
-		return Altbn128.MakeG1Point([]*big.Int{x, y}, true)
+		return curve.MakeG1Point([]*big.Int{x, y}, true)
 	}
 	return nil, false
 }
 
+// UnmarshalG2 accepts both the 64 byte compressed and 128 byte uncompressed encodings,
+// and checks that the point is in G2, with the backend's subgroup check as in
+// MakeG2Point. The infinity flag is only accepted with
+// every other bit unset. For compatibility, a compressed encoding with x = 0 is
+// also read as the point at infinity. data is not modified.
 func (curve *altbn128) UnmarshalG2(data []byte) (Point, bool) {
 	if data == nil || (len(data) != 64 && len(data) != 128) {
 		return nil, false
 	}
+	data = append([]byte{}, data...)
 	if len(data) == 128 { // No point compression
-		curvePoint := new(bn256.G2)
-		if _, ok := curvePoint.Unmarshal(data); ok == nil {
-			return &altbn128Point2{curvePoint}, true
+		coords := make([]*big.Int, 4)
+		for i := 0; i < 4; i++ {
+			coords[i] = new(big.Int).SetBytes(data[32*i : 32*(i+1)])
 		}
+		return curve.MakeG2Point(coords, true)
 	} else if len(data) == 64 { // Point compression
-		xiBytes := data[:32]
-		xrBytes := data[32:]
-		yiSgn := (xiBytes[0] >= 128)
-		yrSgn := (xrBytes[0] >= 128)
-		if yiSgn {
-			xiBytes[0] -= 128
+		compressed, infinity, ok := parseAltbnCompressedG2(data)
+		if !ok {
+			return nil, false
+		} else if infinity {
+			return curve.GetG2Infinity(), true
 		}
-		if yrSgn {
-			xrBytes[0] -= 128
+		// Underlying library already checks that y is on the curve, thus isQuadRes isn't checked here
+		y := calcComplexQuadRes(curve.g2XToYSquared(compressed.x), altbnG1Q)
+		return curve.decompressG2(compressed, y)
+	}
+	return nil, false
+}
+
+// altbnCompressedG2 is a point of G2 other than infinity in the compressed
+// encoding of Marshal, which gives x and whether each part of y is above q / 2.
+type altbnCompressedG2 struct {
+	x            *complexNum
+	yiSgn, yrSgn bool
+}
+
+// parseAltbnCompressedG2 reads the 64 bytes of data, which are not modified,
+// and returns whether they are the point at infinity. For compatibility, x = 0
+// is read as infinity, as well as the infinity flag, which is rejected if any
+// other bit is set.
+func parseAltbnCompressedG2(data []byte) (compressed altbnCompressedG2, infinity bool, ok bool) {
+	if data[0]&altbnInfinityFlag != 0 {
+		return altbnCompressedG2{}, true, isAltbnInfinityEncoding(data)
+	}
+	xiBytes := append([]byte{}, data[:32]...)
+	xrBytes := append([]byte{}, data[32:]...)
+	yiSgn := (xiBytes[0] >= 128)
+	yrSgn := (xrBytes[0] >= 128)
+	if yiSgn {
+		xiBytes[0] -= 128
+	}
+	if yrSgn {
+		xrBytes[0] -= 128
+	}
+	xi := new(big.Int).SetBytes(xiBytes)
+	xr := new(big.Int).SetBytes(xrBytes)
+	if xi.Cmp(zero) == 0 && xr.Cmp(zero) == 0 {
+		return altbnCompressedG2{}, true, true
+	}
+	return altbnCompressedG2{&complexNum{xi, xr}, yiSgn, yrSgn}, false, true
+}
+
+// isAltbnInfinityEncoding returns whether data is the encoding of the point at
+// infinity by Marshal, the infinity flag followed by zeroes.
+func isAltbnInfinityEncoding(data []byte) bool {
+	if data[0] != altbnInfinityFlag {
+		return false
+	}
+	for _, b := range data[1:] {
+		if b != 0 {
+			return false
 		}
-		xi := new(big.Int).SetBytes(xiBytes)
-		xr := new(big.Int).SetBytes(xrBytes)
-		if xi.Cmp(zero) == 0 && xr.Cmp(zero) == 0 {
-			return Altbn128.MakeG2Point([]*big.Int{zero, zero, zero, zero}, false)
+	}
+	return true
+}
+
+// decompressG2 picks the square root y or -y given by the signs, and checks
+// the point. y is modified.
+func (curve *altbn128) decompressG2(compressed altbnCompressedG2, y *complexNum) (Point, bool) {
+	doubleYRe := new(big.Int).Mul(y.re, two)
+	doubleYIm := new(big.Int).Mul(y.im, two)
+	cmpResRe := doubleYRe.Cmp(altbnG1Q)
+	cmpResIm := doubleYIm.Cmp(altbnG1Q)
+	if compressed.yiSgn && cmpResIm == -1 {
+		y.im.Sub(altbnG1Q, y.im)
+	} else if !compressed.yiSgn && cmpResIm == 1 {
+		y.im.Sub(altbnG1Q, y.im)
+	}
+	if compressed.yrSgn && cmpResRe == -1 {
+		y.re.Sub(altbnG1Q, y.re)
+	} else if !compressed.yrSgn && cmpResRe == 1 {
+		y.re.Sub(altbnG1Q, y.re)
+	}
+	x := compressed.x
+	return curve.MakeG2Point([]*big.Int{x.im, x.re, y.im, y.re}, true)
+}
+
+// UnmarshalG1Batch is UnmarshalG1 for every element of data, in parallel.
+// Decompression in G1 doesn't need an inversion, so there is nothing to share.
+func (curve *altbn128) UnmarshalG1Batch(data [][]byte) ([]Point, []error) {
+	return unmarshalBatch(data, curve.UnmarshalG1)
+}
+
+// UnmarshalG2Batch is UnmarshalG2 for every element of data. The square roots
+// of the compressed points are computed in parallel, and the inversions which
+// they end with are shared with Montgomery's trick. The points are then
+// checked in parallel, which takes most of the time.
+func (curve *altbn128) UnmarshalG2Batch(data [][]byte) ([]Point, []error) {
+	compressed := make([]altbnCompressedG2, len(data))
+	roots := make([]*complexNum, len(data))
+	dens := make([]*fieldElement, len(data))
+	Parallelize(context.Background(), len(data), func(i int) error {
+		dens[i] = &fieldElement{f: altbnFq}
+		if len(data[i]) != 64 {
+			return nil
 		}
-		x := &complexNum{xi, xr}
-		y := Altbn128.g2XToYSquared(x)
-		// Underlying library already checks that y is on the curve, thus isQuadRes isn't checked here
-		y = calcComplexQuadRes(y, altbnG1Q)
-		doubleYRe := new(big.Int).Mul(y.re, two)
-		doubleYIm := new(big.Int).Mul(y.im, two)
-		cmpResRe := doubleYRe.Cmp(altbnG1Q)
-		cmpResIm := doubleYIm.Cmp(altbnG1Q)
-		if yiSgn && cmpResIm == -1 {
-			y.im.Sub(altbnG1Q, y.im)
-		} else if !yiSgn && cmpResIm == 1 {
-			y.im.Sub(altbnG1Q, y.im)
+		var infinity, ok bool
+		if compressed[i], infinity, ok = parseAltbnCompressedG2(data[i]); infinity || !ok {
+			return nil
 		}
-		if yrSgn && cmpResRe == -1 {
-			y.re.Sub(altbnG1Q, y.re)
-		} else if !yrSgn && cmpResRe == 1 {
-			y.re.Sub(altbnG1Q, y.re)
+		var den *big.Int
+		roots[i], den = calcComplexQuadResUnscaled(curve.g2XToYSquared(compressed[i].x), altbnG1Q)
+		dens[i].n.Set(den)
+		return nil
+	})
+	inverses := batchInverse(dens)
+	decompress := func(i int) (Point, bool) {
+		if roots[i] == nil {
+			return curve.UnmarshalG2(data[i])
 		}
-		return Altbn128.MakeG2Point([]*big.Int{x.im, x.re, y.im, y.re}, false)
+		y := roots[i]
+		y.im.Mul(y.im, &inverses[i].n)
+		y.im.Mod(y.im, altbnG1Q)
+		return curve.decompressG2(compressed[i], y)
 	}
-	return nil, false
+	return decodeBatch(len(data), decompress)
+}
+
+// UnmarshalG1CompressedStandard decodes the output of MarshalCompressedStandard.
+// Encodings with other flags, x >= q, or non-zero bytes after the infinity flag
+// are rejected. data is not modified.
+func (curve *altbn128) UnmarshalG1CompressedStandard(data []byte) (Point, bool) {
+	if len(data) != 32 {
+		return nil, false
+	}
+	flag := data[0] & altbnCompressedMask
+	x := new(big.Int).SetBytes(append([]byte{data[0] &^ altbnCompressedMask}, data[1:]...))
+	if flag == altbnCompressedInfinity && x.Sign() == 0 {
+		return curve.GetG1Infinity(), true
+	} else if (flag != altbnCompressedSmallest && flag != altbnCompressedLargest) || x.Cmp(altbnG1Q) >= 0 {
+		return nil, false
+	}
+	ySqr := new(big.Int).Mod(curve.g1XToYSquared(x), altbnG1Q)
+	y := calcQuadRes(ySqr, altbnG1Q)
+	if parity(y, altbnG1Q) != (flag == altbnCompressedLargest) {
+		y.Sub(altbnG1Q, y)
+	}
+	return curve.MakeG1Point([]*big.Int{x, y}, true)
+}
+
+// UnmarshalG2CompressedStandard decodes the output of MarshalCompressedStandard,
+// and checks that the point is in G2. Encodings with other flags, coordinates
+// >= q, or non-zero bytes after the infinity flag are rejected. data is not modified.
+func (curve *altbn128) UnmarshalG2CompressedStandard(data []byte) (Point, bool) {
+	if len(data) != 64 {
+		return nil, false
+	}
+	flag := data[0] & altbnCompressedMask
+	xi := new(big.Int).SetBytes(append([]byte{data[0] &^ altbnCompressedMask}, data[1:32]...))
+	xr := new(big.Int).SetBytes(data[32:])
+	if flag == altbnCompressedInfinity && xi.Sign() == 0 && xr.Sign() == 0 {
+		return curve.GetG2Infinity(), true
+	} else if (flag != altbnCompressedSmallest && flag != altbnCompressedLargest) ||
+		xi.Cmp(altbnG1Q) >= 0 || xr.Cmp(altbnG1Q) >= 0 {
+		return nil, false
+	}
+	x := &complexNum{xi, xr}
+	y := calcComplexQuadRes(curve.g2XToYSquared(x), altbnG1Q)
+	if complexLexicographicallyLargest(y, altbnG1Q) != (flag == altbnCompressedLargest) {
+		y.Sub(getComplexZero(), y, altbnG1Q)
+	}
+	return curve.MakeG2Point([]*big.Int{x.im, x.re, y.im, y.re}, true)
+}
+
+// UnmarshalG1Strict only accepts the canonical encodings produced by Marshal and
+// MarshalUncompressed. data is not modified.
+func (curve *altbn128) UnmarshalG1Strict(data []byte) (Point, bool) {
+	return unmarshalCanonical(data, curve.UnmarshalG1, 32)
 }
 
+// UnmarshalG2Strict only accepts the canonical encodings produced by Marshal and
+// MarshalUncompressed. data is not modified.
+func (curve *altbn128) UnmarshalG2Strict(data []byte) (Point, bool) {
+	return unmarshalCanonical(data, curve.UnmarshalG2, 64)
+}
+
+// UnmarshalGT decodes the output of Marshal, and checks that the element is in GT,
+// by raising it to the group order.
 func (curve *altbn128) UnmarshalGT(data []byte) (PointT, bool) {
 	if data == nil || len(data) != 384 {
 		return nil, false
 	}
-	curvePoint := new(bn256.GT)
-	if _, ok := curvePoint.Unmarshal(data); ok == nil {
-		return altbn128PointT{curvePoint}, true
+	if _, ok := altbnGTTower.coords(data); !ok {
+		return nil, false
 	}
-	return nil, false
+	curvePoint, ok := curve.backend.gtFromBytes(data)
+	if !ok {
+		return nil, false
+	}
+	order := curvePoint.exp(altbnG1Order)
+	if !bytes.Equal(order.bytes(), curve.gtIdentity.Marshal()) {
+		return nil, false
+	}
+	return altbn128PointT{curve, curvePoint}, true
+}
+
+// UnmarshalGTCompressed decodes the output of MarshalCompressed.
+func (curve *altbn128) UnmarshalGTCompressed(data []byte) (PointT, bool) {
+	decompressed, ok := altbnGTTower.decompress(data)
+	if !ok {
+		return nil, false
+	}
+	return curve.UnmarshalGT(decompressed)
+}
+
+// MakeGTPoint expects the coefficients returned by ToAffineCoords.
+func (curve *altbn128) MakeGTPoint(coords []*big.Int) (PointT, bool) {
+	data, ok := altbnGTTower.fromCoords(coords)
+	if !ok {
+		return nil, false
+	}
+	return curve.UnmarshalGT(data)
 }
 
 func (curve *altbn128) getG1A() *big.Int {
@@ -407,13 +979,21 @@ func (curve *altbn128) GetG1Order() *big.Int {
 }
 
 func (curve *altbn128) g1XToYSquared(x *big.Int) *big.Int {
+	return altbnG1XToYSquared(x)
+}
+
+func (curve *altbn128) g2XToYSquared(x *complexNum) *complexNum {
+	return altbnG2XToYSquared(x)
+}
+
+func altbnG1XToYSquared(x *big.Int) *big.Int {
 	result := new(big.Int)
 	result.Exp(x, three, altbnG1Q)
 	result.Add(result, altbnG1B)
 	return result
 }
 
-func (curve *altbn128) g2XToYSquared(x *complexNum) *complexNum {
+func altbnG2XToYSquared(x *complexNum) *complexNum {
 	result := getComplexZero()
 	result.Exp(x, three, altbnG1Q)
 	result.Add(result, altbnG2B, altbnG1Q)
@@ -421,11 +1001,19 @@ func (curve *altbn128) g2XToYSquared(x *complexNum) *complexNum {
 }
 
 func (curve *altbn128) GetG1() Point {
-	return altbnG1
+	return curve.g1
 }
 
 func (curve *altbn128) GetG2() Point {
-	return altbnG2
+	return curve.g2
+}
+
+func (curve *altbn128) G1BaseMul(k *big.Int) Point {
+	return curve.g1Table.mul(k)
+}
+
+func (curve *altbn128) G2BaseMul(k *big.Int) Point {
+	return curve.g2Table.mul(k)
 }
 
 func (curve *altbn128) GetG1Infinity() (pt Point) {
@@ -439,11 +1027,11 @@ func (curve *altbn128) GetG2Infinity() Point {
 }
 
 func (curve *altbn128) GetGTIdentity() PointT {
-	return altbnGTIdentity
+	return curve.gtIdentity
 }
 
 func (curve *altbn128) GetGT() PointT {
-	return altbnGT
+	return curve.gt
 }
 
 func (curve *altbn128) getG1Cofactor() *big.Int {
@@ -458,24 +1046,77 @@ func (curve *altbn128) getFTHashParams() (*big.Int, *big.Int) {
 var altbnG1B = big.NewInt(3)
 var altbnG1Q, _ = new(big.Int).SetString("21888242871839275222246405745257275088696311157297823662689037894645226208583", 10)
 var altbnG1QDiv2 = new(big.Int).Div(altbnG1Q, two)
+var altbnFq = newField(altbnG1Q)
 
 var altbnG2BRe, _ = new(big.Int).SetString("19485874751759354771024239261021720505790618469301721065564631296452457478373", 10)
 var altbnG2BIm, _ = new(big.Int).SetString("266929791119991161246907387137283842545076965332900288569378510910307636690", 10)
 var altbnG2B = &complexNum{altbnG2BIm, altbnG2BRe}
 
+var altbnG2DST = []byte("BLS_SIG_BN254G2_XMD:SHA-256_SVDW_RO_NUL_")
+
+// BN parameter u, where p = 36u^4 + 36u^3 + 24u^2 + 6u + 1
+var altbnU, _ = new(big.Int).SetString("4965661367192848881", 10)
+var altbnSixUSquared = new(big.Int).Mul(new(big.Int).Mul(altbnU, altbnU), big.NewInt(6))
+
+//precomputed xi^((p-1)/3) and xi^((p-1)/2) in Fp2, where xi = i + 9
+var altbnXiToPMinus1Over3 = getComplexZero().Exp(&complexNum{big.NewInt(1), big.NewInt(9)},
+	new(big.Int).Div(new(big.Int).Sub(altbnG1Q, one), three), altbnG1Q)
+var altbnXiToPMinus1Over2 = getComplexZero().Exp(&complexNum{big.NewInt(1), big.NewInt(9)},
+	new(big.Int).Div(new(big.Int).Sub(altbnG1Q, one), two), altbnG1Q)
+
+// Flag for the point at infinity in the compressed encodings, which is unused by
+// coordinates since q < 2^254.
+const altbnInfinityFlag = 64
+
+// Flags in the highest byte of the standard compressed encoding
+const altbnCompressedMask = 0xC0
+const altbnCompressedSmallest = 0x80
+const altbnCompressedLargest = 0xC0
+const altbnCompressedInfinity = 0x40
+
+// Below this many pairs per core, the Miller loops are not worth parallelizing
+const altbnMinPairsPerWorker = 4
+
+// The window size of the signed digit exponentiation in GT
+const altbnGTWindow = 5
+
+// p mod r, the exponent which the Frobenius map applies to GT
+var altbnGTLambda = new(big.Int).Mod(altbnG1Q, altbnG1Order)
+
+// altbnFrobeniusGammas[j] = xi^(j (p - 1) / 6)
+var altbnFrobeniusGammas = func() (gammas [6]*complexNum) {
+	gammas[0] = &complexNum{big.NewInt(0), big.NewInt(1)}
+	gammas[1] = getComplexZero().Exp(&complexNum{big.NewInt(1), big.NewInt(9)},
+		new(big.Int).Div(new(big.Int).Sub(altbnG1Q, one), big.NewInt(6)), altbnG1Q)
+	for j := 2; j < 6; j++ {
+		gammas[j] = getComplexZero().Mul(gammas[j-1], gammas[1], altbnG1Q)
+	}
+	return
+}()
+
 //precomputed Z = (-1 + sqrt(-3))/2 in Fq
 var altbnZ, _ = new(big.Int).SetString("2203960485148121921418603742825762020974279258880205651966", 10)
 
 //precomputed sqrt(-3) in Fq
 var altbnSqrtn3, _ = new(big.Int).SetString("4407920970296243842837207485651524041948558517760411303933", 10)
 
-var altbnG1 = &altbn128Point1{new(bn256.G1).ScalarBaseMult(one)}
-var altbnG2 = &altbn128Point2{new(bn256.G2).ScalarBaseMult(one)}
-var altbnGT, _ = Altbn128.Pair(altbnG1, altbnG2)
+// The generator of G2 from EIP-197, in the form [x0, x1, y0, y1]. The generator
+// of G1 is (1, 2).
+var altbnG2Generator = func() []*big.Int {
+	coords := make([]*big.Int, 4)
+	for i, c := range []string{
+		"11559732032986387107991004021392285783925812861821192530917403151452391805634",
+		"10857046999023057135944570762232829481370756359578518086990519993285655852781",
+		"4082367875863433681332203403145435568316851327593401208105741076214120093531",
+		"8495653923123431417604973247489272438418190587263600148770280649306958101930",
+	} {
+		coords[i], _ = new(big.Int).SetString(c, 10)
+	}
+	return coords
+}()
 
 // Ensure zero has been created
 var z = zero
-var altbnGTIdentity, _ = Altbn128.Pair(Altbn128.GetG1(), Altbn128.GetG2Infinity())
 
 var altbnG1Order, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
 
@@ -512,6 +1153,209 @@ func (curve *altbn128) HashToG1(message []byte) Point {
 	return p
 }
 
+// HashToG2 hashes a message to a point on G2 of Altbn128 with the
+// BN254G2_XMD:SHA-256_SVDW_RO_ suite of RFC 9380, under the domain separation
+// tag of a basic BLS signature ciphersuite with signatures in G2.
+// The return value is the altbn_128 library's internel representation for points.
+func (curve *altbn128) HashToG2(message []byte) Point {
+	p, _ := curve.MakeG2Point(AltbnHashToCurveG2(message, altbnG2DST), false)
+	return p
+}
+
+// altbnTwistPoint is a point on the twist curve y^2 = x^3 + b / xi in Jacobian
+// coordinates, (X, Y, Z) representing (X / Z^2, Y / Z^3). The bn256 library
+// rejects points outside of G2, so cofactor clearing is done with these.
+type altbnTwistPoint struct {
+	x, y, z *complexNum
+}
+
+func newAltbnTwistPoint(x, y *complexNum) *altbnTwistPoint {
+	return &altbnTwistPoint{x, y, &complexNum{big.NewInt(0), big.NewInt(1)}}
+}
+
+func getAltbnTwistInfinity() *altbnTwistPoint {
+	return &altbnTwistPoint{getComplexZero(), getComplexZero(), getComplexZero()}
+}
+
+func (pt *altbnTwistPoint) isInfinity() bool {
+	return pt.z.IsZero()
+}
+
+// double uses the dbl-2009-l formulas for curves with a = 0.
+func (pt *altbnTwistPoint) double() *altbnTwistPoint {
+	q := altbnG1Q
+	a := getComplexZero().Square(pt.x, q)
+	b := getComplexZero().Square(pt.y, q)
+	c := getComplexZero().Square(b, q)
+	d := getComplexZero().Add(pt.x, b, q)
+	d.Square(d, q).Sub(d, a, q).Sub(d, c, q)
+	d.Add(d, d, q)
+	e := getComplexZero().Add(a, a, q)
+	e.Add(e, a, q)
+	f := getComplexZero().Square(e, q)
+
+	x3 := getComplexZero().Sub(f, d, q)
+	x3.Sub(x3, d, q)
+	c.Add(c, c, q)
+	c.Add(c, c, q)
+	c.Add(c, c, q)
+	y3 := getComplexZero().Sub(d, x3, q)
+	y3.Mul(y3, e, q).Sub(y3, c, q)
+	z3 := getComplexZero().Mul(pt.y, pt.z, q)
+	z3.Add(z3, z3, q)
+	return &altbnTwistPoint{x3, y3, z3}
+}
+
+// add uses the add-2007-bl formulas, falling back to doubling when both
+// inputs are the same point.
+func (pt *altbnTwistPoint) add(other *altbnTwistPoint) *altbnTwistPoint {
+	if pt.isInfinity() {
+		return other
+	} else if other.isInfinity() {
+		return pt
+	}
+	q := altbnG1Q
+	z1z1 := getComplexZero().Square(pt.z, q)
+	z2z2 := getComplexZero().Square(other.z, q)
+	u1 := getComplexZero().Mul(pt.x, z2z2, q)
+	u2 := getComplexZero().Mul(other.x, z1z1, q)
+	s1 := getComplexZero().Mul(pt.y, other.z, q)
+	s1.Mul(s1, z2z2, q)
+	s2 := getComplexZero().Mul(other.y, pt.z, q)
+	s2.Mul(s2, z1z1, q)
+	h := getComplexZero().Sub(u2, u1, q)
+	r := getComplexZero().Sub(s2, s1, q)
+	if h.IsZero() {
+		if r.IsZero() {
+			return pt.double()
+		}
+		return getAltbnTwistInfinity()
+	}
+	i := getComplexZero().Add(h, h, q)
+	i.Square(i, q)
+	j := getComplexZero().Mul(h, i, q)
+	r.Add(r, r, q)
+	v := getComplexZero().Mul(u1, i, q)
+
+	x3 := getComplexZero().Square(r, q)
+	x3.Sub(x3, j, q).Sub(x3, v, q).Sub(x3, v, q)
+	y3 := getComplexZero().Sub(v, x3, q)
+	y3.Mul(y3, r, q)
+	s1.Mul(s1, j, q)
+	y3.Sub(y3, s1, q).Sub(y3, s1, q)
+	z3 := getComplexZero().Add(pt.z, other.z, q)
+	z3.Square(z3, q).Sub(z3, z1z1, q).Sub(z3, z2z2, q).Mul(z3, h, q)
+	return &altbnTwistPoint{x3, y3, z3}
+}
+
+// mul is double and add scalar multiplication, for non-negative scalars.
+func (pt *altbnTwistPoint) mul(scalar *big.Int) *altbnTwistPoint {
+	result := getAltbnTwistInfinity()
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		result = result.double()
+		if scalar.Bit(i) == 1 {
+			result = result.add(pt)
+		}
+	}
+	return result
+}
+
+// psi is the untwist-Frobenius-twist endomorphism on the twist curve,
+// psi(x, y) = (conj(x) * xi^((p-1)/3), conj(y) * xi^((p-1)/2)), where xi = i + 9.
+// Since conjugation is a field automorphism, it can be applied directly to the
+// Jacobian coordinates. On G2, it acts as multiplication by p.
+func (pt *altbnTwistPoint) psi() *altbnTwistPoint {
+	q := altbnG1Q
+	x := getComplexZero().Conjugate(pt.x)
+	y := getComplexZero().Conjugate(pt.y)
+	z := getComplexZero().Conjugate(pt.z)
+	x.Mul(x, altbnXiToPMinus1Over3, q)
+	y.Mul(y, altbnXiToPMinus1Over2, q)
+	z.im.Mod(z.im, q)
+	return &altbnTwistPoint{x, y, z}
+}
+
+// clearCofactor maps a point on the twist curve into G2. Multiplying by the
+// cofactor directly would take a 254 bit scalar multiplication. Instead this
+// uses the method of Fuentes-Castaneda, Knapp and Rodriguez-Henriquez from
+// "Faster hashing to G2", which computes a multiple of the cofactor as
+// [u]P + psi([3u]P) + psi^2([u]P) + psi^3(P), with u the 63 bit BN parameter.
+func (pt *altbnTwistPoint) clearCofactor() *altbnTwistPoint {
+	uP := pt.mul(altbnU)
+	threeUP := uP.double().add(uP)
+	result := uP.add(threeUP.psi())
+	result = result.add(uP.psi().psi())
+	return result.add(pt.psi().psi().psi())
+}
+
+// toAffineCoords returns [x0, x1, y0, y1], where X = x0 * i + x1 and
+// Y = y0 * i + y1, with the point at infinity mapped to all zeroes.
+func (pt *altbnTwistPoint) toAffineCoords() []*big.Int {
+	if pt.isInfinity() {
+		return []*big.Int{big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)}
+	}
+	q := altbnG1Q
+	zInv := getComplexZero().Inverse(pt.z, q)
+	zInv2 := getComplexZero().Square(zInv, q)
+	x := getComplexZero().Mul(pt.x, zInv2, q)
+	y := getComplexZero().Mul(pt.y, zInv2, q)
+	y.Mul(y, zInv, q)
+	return []*big.Int{x.im, x.re, y.im, y.re}
+}
+
+// altbnG1IsOnCurve checks that y^2 = x^3 + 3 with x, y in Fq, or that the
+// coordinates are both zero, which represents the point at infinity.
+func altbnG1IsOnCurve(x, y *big.Int) bool {
+	if x.Cmp(altbnG1Q) >= 0 || y.Cmp(altbnG1Q) >= 0 || x.Sign() < 0 || y.Sign() < 0 {
+		return false
+	} else if x.Sign() == 0 && y.Sign() == 0 {
+		return true
+	}
+	ySqr := new(big.Int).Exp(y, two, altbnG1Q)
+	return ySqr.Cmp(new(big.Int).Mod(altbnG1XToYSquared(x), altbnG1Q)) == 0
+}
+
+// altbnG2IsOnCurve checks that coords, of the form [x0, x1, y0, y1], satisfy
+// y^2 = x^3 + b / xi over Fp2, or are all zero, which represents the point at infinity.
+func altbnG2IsOnCurve(coords []*big.Int) bool {
+	allZero := true
+	for _, c := range coords {
+		if c.Sign() < 0 || c.Cmp(altbnG1Q) >= 0 {
+			return false
+		}
+		allZero = allZero && c.Sign() == 0
+	}
+	if allZero {
+		return true
+	}
+	x := &complexNum{coords[0], coords[1]}
+	y := &complexNum{coords[2], coords[3]}
+	return getComplexZero().Square(y, altbnG1Q).Equals(altbnG2XToYSquared(x))
+}
+
+// altbnG2IsInSubgroup checks that a point on the twist curve, with coords of the
+// form [x0, x1, y0, y1], is in G2. Rather than multiplying by the 254 bit group
+// order, this uses that a point Q on the twist is in G2 if and only if
+// psi(Q) = [6u^2]Q, from "Co-factor clearing and subgroup membership testing on
+// pairing-friendly curves" by El Housni, Guillevic and Piellard.
+// The scalar 6u^2 has 126 bits.
+func altbnG2IsInSubgroup(coords []*big.Int) bool {
+	x := &complexNum{coords[0], coords[1]}
+	y := &complexNum{coords[2], coords[3]}
+	if x.IsZero() && y.IsZero() {
+		return true
+	}
+	pt := newAltbnTwistPoint(x, y)
+	lhs := pt.psi().toAffineCoords()
+	rhs := pt.mul(altbnSixUSquared).toAffineCoords()
+	for i := 0; i < 4; i++ {
+		if lhs[i].Cmp(rhs[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // EthereumSum256 returns the Keccak3-256 digest of the data. This is because Ethereum
 // uses a non-standard hashing algo.
 func EthereumSum256(data []byte) (digest [32]byte) {
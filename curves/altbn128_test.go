@@ -4,6 +4,7 @@
 package curves
 
 import (
+	"crypto/rand"
 	"math/big"
 	"testing"
 
@@ -23,7 +24,7 @@ func TestEthereumHash(t *testing.T) {
 	coords2 := pt.ToAffineCoords()
 	assert.True(t, coords[0].Cmp(coords2[0]) == 0 && coords[1].Cmp(coords2[1]) == 0, "Conversion of point to coordinates is not working")
 
-	coords = altbnG2.ToAffineCoords()
+	coords = curve.GetG2().ToAffineCoords()
 	knownxi, _ := new(big.Int).SetString("11559732032986387107991004021392285783925812861821192530917403151452391805634", 10)
 	knownxr, _ := new(big.Int).SetString("10857046999023057135944570762232829481370756359578518086990519993285655852781", 10)
 	knownyi, _ := new(big.Int).SetString("4082367875863433681332203403145435568316851327593401208105741076214120093531", 10)
@@ -37,3 +38,34 @@ func TestEthereumHash(t *testing.T) {
 	altG2, _ := curve.MakeG2Point(coords, false)
 	assert.True(t, altG2.Equals(curve.GetG2()), "MakeG2Point Failed")
 }
+
+func TestAltbnG2SubgroupCheck(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		msg := make([]byte, 32)
+		rand.Read(msg)
+		// A point on the twist before cofactor clearing is almost never in G2
+		u := hashToField(Altbn128, msg, []byte("BGLS-TEST"), 2)
+		twistPt := mapToTwistSvdw(altbnSvdwParamsG2, &complexNum{u[1], u[0]})
+		coords := twistPt.toAffineCoords()
+		assert.True(t, altbnG2IsOnCurve(coords), "hashed point is not on the twist")
+		assert.False(t, altbnG2IsInSubgroup(coords), "point outside of G2 passed the subgroup check")
+		data := make([]byte, 0, 128)
+		for _, c := range coords {
+			data = append(data, pad32Bytes(c.Bytes())...)
+		}
+		// The curve leaves the subgroup check to g2FromBytes, so every backend
+		// must reject the point
+		for _, backend := range bn256Backends {
+			curve := NewAltbn128(backend)
+			_, ok := curve.MakeG2Point(coords, true)
+			assert.False(t, ok, "MakeG2Point accepted a point outside of G2 on "+backend.Name())
+			_, ok = curve.UnmarshalG2(data)
+			assert.False(t, ok, "UnmarshalG2 accepted a point outside of G2 on "+backend.Name())
+			_, errs := curve.UnmarshalG2Batch([][]byte{data})
+			assert.Error(t, errs[0], "UnmarshalG2Batch accepted a point outside of G2 on "+backend.Name())
+		}
+
+		cleared := twistPt.clearCofactor().toAffineCoords()
+		assert.True(t, altbnG2IsInSubgroup(cleared), "point with cleared cofactor failed the subgroup check")
+	}
+}
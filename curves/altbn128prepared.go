@@ -0,0 +1,119 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// altbnLoopNAF is 6u + 2 in non-adjacent form, least significant digit first.
+// It has 22 non-zero digits instead of the 37 ones of the binary form, so the
+// Miller loop adds fewer lines.
+var altbnLoopNAF = func() []int8 {
+	var digits []int8
+	k := new(big.Int).Set(referenceLoopCount)
+	for k.Sign() > 0 {
+		var d int8
+		// An odd k is 1 or 3 mod 4, and the digit is 1 or -1 respectively, so
+		// that the next digit is zero
+		if k.Bit(0) == 1 {
+			d = 1
+			if k.Bit(1) == 1 {
+				d = -1
+			}
+			k.Sub(k, big.NewInt(int64(d)))
+		}
+		digits = append(digits, d)
+		k.Rsh(k, 1)
+	}
+	return digits
+}()
+
+// altbnLine is a line on the twist with slope lambda through T, with
+// c = lambda xT - yT, in Montgomery form. Its value at P is
+// yP - lambda xP w + c w^3, as described in referenceLineCoeffs.at.
+type altbnLine struct {
+	negLambda, c fp2Mont
+}
+
+// altbnPrepareLines computes the lines of the Miller loop with Q in the order
+// in which they are multiplied in, for the same pairing as the reference
+// backend, but with the loop over altbnLoopNAF. Q must be in G2 and not be the
+// point at infinity.
+func altbnPrepareLines(q *altbnTwistPoint) []altbnLine {
+	f := altbnGTTower.mont
+	qx, qy := referenceTwistAffine(q)
+	negQy := getComplexZero().Sub(getComplexZero(), qy, altbnG1Q)
+	tx, ty := qx, qy
+	lines := make([]altbnLine, 0, 2*len(altbnLoopNAF))
+	addLine := func(x, y *complexNum) {
+		var line referenceLineCoeffs
+		line, tx, ty = referenceLine(tx, ty, x, y)
+		negLambda := getComplexZero().Sub(getComplexZero(), line.lambda, altbnG1Q)
+		lines = append(lines, altbnLine{f.fp2FromComplex(negLambda), f.fp2FromComplex(line.c)})
+	}
+	for i := len(altbnLoopNAF) - 2; i >= 0; i-- {
+		addLine(tx, ty)
+		switch altbnLoopNAF[i] {
+		case 1:
+			addLine(qx, qy)
+		case -1:
+			addLine(qx, negQy)
+		}
+	}
+	q1x, q1y := referenceTwistAffine(q.psi())
+	q2x, q2y := referenceTwistAffine(q.psi().psi())
+	q2y.Sub(getComplexZero(), q2y, altbnG1Q)
+	addLine(q1x, q1y)
+	addLine(q2x, q2y)
+	return lines
+}
+
+// altbnMultiMiller returns the product of the Miller loops with the prepared
+// lines[i] at the points pts[i] of G1, none of which may be the point at
+// infinity, serialized as an element of GT. The loops share their squarings.
+// Each line is divided by yP, and negative digits of altbnLoopNAF leave out
+// vertical lines. These factors are in Fp6, where the final exponentiation
+// maps them to one.
+func altbnMultiMiller(pts []bn256G1, lines [][]altbnLine) []byte {
+	t := altbnGTTower
+	f := t.mont
+	// The value of a line at P divided by yP is
+	// 1 + (-lambda xP / yP + c / yP v) w
+	xOverY := make([]fpMont, len(pts))
+	yInv := make([]fpMont, len(pts))
+	for i, p := range pts {
+		data := p.bytes()
+		y := new(big.Int).SetBytes(data[32:])
+		y.ModInverse(y, altbnG1Q)
+		x := new(big.Int).SetBytes(data[:32])
+		x.Mul(x, y).Mod(x, altbnG1Q)
+		xOverY[i] = f.fromBig(x)
+		yInv[i] = f.fromBig(y)
+	}
+	acc := t.montOne()
+	next := 0
+	mulLines := func() {
+		for i := range pts {
+			line := &lines[i][next]
+			var b0, b1 fp2Mont
+			f.mul(&b0.re, &line.negLambda.re, &xOverY[i])
+			f.mul(&b0.im, &line.negLambda.im, &xOverY[i])
+			f.mul(&b1.re, &line.c.re, &yInv[i])
+			f.mul(&b1.im, &line.c.im, &yInv[i])
+			t.montMulByLine(&acc, &acc, &b0, &b1)
+		}
+		next++
+	}
+	for i := len(altbnLoopNAF) - 2; i >= 0; i-- {
+		t.montSquare(&acc, &acc)
+		mulLines()
+		if altbnLoopNAF[i] != 0 {
+			mulLines()
+		}
+	}
+	mulLines()
+	mulLines()
+	return t.montBytes(&acc)
+}
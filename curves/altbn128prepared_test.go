@@ -0,0 +1,43 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAltbnLoopNAF(t *testing.T) {
+	sum := new(big.Int)
+	for i := len(altbnLoopNAF) - 1; i >= 0; i-- {
+		sum.Lsh(sum, 1).Add(sum, big.NewInt(int64(altbnLoopNAF[i])))
+		if i > 0 {
+			assert.False(t, altbnLoopNAF[i] != 0 && altbnLoopNAF[i-1] != 0, "adjacent non-zero digits")
+		}
+	}
+	assert.Equal(t, referenceLoopCount, sum)
+	assert.Equal(t, int8(1), altbnLoopNAF[len(altbnLoopNAF)-1])
+}
+
+// TestAltbnMultiMiller checks the shared Miller loop against the Miller loops of
+// the reference backend, after the final exponentiation.
+func TestAltbnMultiMiller(t *testing.T) {
+	curve := newAltbn128(Bn256Reference)
+	a, b := big.NewInt(5), big.NewInt(7)
+	g1s := []Point{curve.GetG1().Mul(a), curve.GetG1()}
+	g2s := []Point{curve.GetG2(), curve.GetG2().Mul(b)}
+	pts1 := make([]bn256G1, len(g1s))
+	lines := make([][]altbnLine, len(g2s))
+	for i := range g1s {
+		pts1[i] = g1s[i].(*altbn128Point1).point
+		prepared, _ := curve.PrepareG2(g2s[i])
+		lines[i] = prepared.(*altbn128PreparedG2).lines
+	}
+	f, ok := Bn256Reference.gtFromBytes(altbnMultiMiller(pts1, lines))
+	assert.True(t, ok)
+	expected, _ := curve.PairingProduct(g1s, g2s)
+	assert.Equal(t, expected.Marshal(), altbn128PointT{curve, Bn256Reference.finalExp(f)}.Marshal())
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// baseMulWindow is the number of scalar bits handled by each row of a fixed
+// base table. For a 255 bit group order, a table has 51 rows of 31 points, and
+// a multiplication takes one addition per row, without any doublings.
+const baseMulWindow = 5
+
+// fixedBaseTable holds j * 2^(w * i) * base in rows[i][j - 1], for every
+// window i of a scalar less than the group order, and every digit 1 <= j < 2^w.
+// The points in the table are never returned, so that callers can't modify them.
+type fixedBaseTable struct {
+	order    *big.Int
+	infinity func() Point
+	rows     [][]Point
+}
+
+// newFixedBaseTable builds the table of multiples of base. If normalize isn't
+// nil, it is called on each row, so that a curve can convert the points to
+// affine coordinates, which are faster to add.
+func newFixedBaseTable(base Point, order *big.Int, infinity func() Point,
+	normalize func([]Point)) *fixedBaseTable {
+	numRows := (order.BitLen() + baseMulWindow - 1) / baseMulWindow
+	table := &fixedBaseTable{order, infinity, make([][]Point, numRows)}
+	rowBase := base
+	for i := range table.rows {
+		row := make([]Point, 1<<baseMulWindow-1)
+		row[0] = rowBase
+		for j := 1; j < len(row); j++ {
+			row[j], _ = row[j-1].Add(rowBase)
+		}
+		table.rows[i] = row
+		// 2^w * rowBase = (2^w - 1) * rowBase + rowBase
+		rowBase, _ = row[len(row)-1].Add(rowBase)
+		if normalize != nil {
+			normalize(row)
+		}
+	}
+	return table
+}
+
+// mul returns k * base. k is reduced mod the group order, so it may be
+// negative or larger than the order.
+func (table *fixedBaseTable) mul(k *big.Int) Point {
+	scalar := new(big.Int).Mod(k, table.order)
+	// Starting from a new point at infinity means that the result is always a
+	// new point, even if only one digit is non-zero.
+	sum := table.infinity()
+	for i, row := range table.rows {
+		digit := 0
+		for j := baseMulWindow - 1; j >= 0; j-- {
+			digit = (digit << 1) | int(scalar.Bit(i*baseMulWindow+j))
+		}
+		if digit != 0 {
+			sum, _ = sum.Add(row[digit-1])
+		}
+	}
+	return sum
+}
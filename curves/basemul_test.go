@@ -0,0 +1,65 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseMul(t *testing.T) {
+	for _, curve := range curves {
+		order := curve.GetG1Order()
+		scalars := []*big.Int{zero, one, big.NewInt(-1), big.NewInt(31), big.NewInt(32), order,
+			new(big.Int).Add(order, one), new(big.Int).Sub(order, one), new(big.Int).Lsh(one, 300)}
+		for i := 0; i < 10; i++ {
+			k, _ := rand.Int(rand.Reader, order)
+			scalars = append(scalars, k)
+		}
+		for _, k := range scalars {
+			assert.True(t, curve.G1BaseMul(k).Equals(curve.GetG1().Mul(k)),
+				curve.Name()+" G1BaseMul disagrees with Mul for "+k.String())
+			assert.True(t, curve.G2BaseMul(k).Equals(curve.GetG2().Mul(k)),
+				curve.Name()+" G2BaseMul disagrees with Mul for "+k.String())
+		}
+		assert.True(t, curve.G1BaseMul(zero).IsInfinity())
+		assert.True(t, curve.G2BaseMul(order).IsInfinity())
+
+		// The result doesn't alias the table
+		pt := curve.G1BaseMul(one)
+		pt.Marshal()
+		sum, _ := pt.Add(pt)
+		assert.True(t, sum.Equals(curve.G1BaseMul(two)))
+		assert.True(t, curve.G1BaseMul(one).Equals(curve.GetG1()))
+	}
+}
+
+func BenchmarkBaseMul(b *testing.B) {
+	for _, curve := range curves {
+		k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		b.Run(curve.Name()+"/G1BaseMul", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.G1BaseMul(k)
+			}
+		})
+		b.Run(curve.Name()+"/G1Mul", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.GetG1().Mul(k)
+			}
+		})
+		b.Run(curve.Name()+"/G2BaseMul", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.G2BaseMul(k)
+			}
+		})
+		b.Run(curve.Name()+"/G2Mul", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.GetG2().Mul(k)
+			}
+		})
+	}
+}
@@ -0,0 +1,575 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"context"
+	"math/big"
+
+	bls "github.com/kilic/bls12-381"
+)
+
+type bls12381 struct {
+}
+
+type bls12381Point1 struct {
+	point *bls.PointG1
+}
+
+type bls12381Point2 struct {
+	point *bls.PointG2
+}
+
+type bls12381PointT struct {
+	point *bls.E
+}
+
+// Bls12381 is the instance for the BLS12-381 curve, with all of its functions.
+var Bls12381 = &bls12381{}
+
+// The upstream group and engine types keep scratch space internally, so they
+// are not safe for concurrent use. A fresh instance is created for every
+// operation, as the bgls functions parallelize across points.
+
+// Returns the name of the curve
+func (curve *bls12381) Name() string {
+	return "bls12381"
+}
+
+// MakeG1Point expects coords to be of the form [X, Y]. (0,0) is the point at infinity.
+// The upstream library always ensures that the point is on the curve, if check
+// is set it is additionally ensured that the point lies in the prime order subgroup.
+func (curve *bls12381) MakeG1Point(coords []*big.Int, check bool) (Point, bool) {
+	if len(coords) != 2 {
+		return nil, false
+	}
+	ret := make([]byte, 96)
+	copy(ret[:48], pad48Bytes(coords[0].Bytes()))
+	copy(ret[48:], pad48Bytes(coords[1].Bytes()))
+	g := bls.NewG1()
+	result, err := g.FromBytes(ret)
+	if err != nil {
+		return nil, false
+	}
+	if check && !g.InCorrectSubgroup(result) {
+		return nil, false
+	}
+	return &bls12381Point1{result}, true
+}
+
+func (g1Point *bls12381Point1) Add(otherPoint1 Point) (Point, bool) {
+	if other, ok := (otherPoint1).(*bls12381Point1); ok {
+		sum := bls.NewG1().Add(new(bls.PointG1), g1Point.point, other.point)
+		ret := &bls12381Point1{sum}
+		return ret, true
+	}
+	return nil, false
+}
+
+func (g1Point *bls12381Point1) Copy() Point {
+	return &bls12381Point1{new(bls.PointG1).Set(g1Point.point)}
+}
+
+func (g1Point *bls12381Point1) Equals(otherPoint1 Point) bool {
+	if other, ok := (otherPoint1).(*bls12381Point1); ok {
+		return bls.NewG1().Equal(g1Point.point, other.point)
+	}
+	return false
+}
+
+func (g1Point *bls12381Point1) pointCurve() CurveSystem {
+	return Bls12381
+}
+
+// Marshal returns the 48 byte compressed form of the point, following the
+// zcash serialization format.
+func (g1Point *bls12381Point1) Marshal() []byte {
+	return bls.NewG1().ToCompressed(new(bls.PointG1).Set(g1Point.point))
+}
+
+// MarshalCompressedStandard is the same as Marshal, since that already uses the
+// zcash encoding with compression, infinity and sign flags.
+func (g1Point *bls12381Point1) MarshalCompressedStandard() []byte {
+	return g1Point.Marshal()
+}
+
+// MarshalUncompressed returns the 96 byte uncompressed form of the point,
+// following the zcash serialization format.
+func (g1Point *bls12381Point1) MarshalUncompressed() []byte {
+	return bls.NewG1().ToUncompressed(new(bls.PointG1).Set(g1Point.point))
+}
+
+func pad48Bytes(xBytes []byte) []byte {
+	if len(xBytes) < 48 {
+		rawBytes := make([]byte, 48)
+		copy(rawBytes[48-len(xBytes):], xBytes)
+		return rawBytes
+	}
+	return xBytes
+}
+
+func (g1Point *bls12381Point1) Mul(scalar *big.Int) Point {
+	// Reducing the scalar mod the group order handles negative scalars as well
+	k := new(big.Int).Mod(scalar, bls12381G1Order)
+	prod := bls.NewG1().MulScalarBig(new(bls.PointG1), g1Point.point, k)
+	return &bls12381Point1{prod}
+}
+
+// ToAffineCoords returns the affine coordinate representation of the point
+// in the form: [X, Y]
+func (g1Point *bls12381Point1) ToAffineCoords() []*big.Int {
+	Bytestream := bls.NewG1().ToBytes(new(bls.PointG1).Set(g1Point.point))
+	x := new(big.Int).SetBytes(Bytestream[:48])
+	y := new(big.Int).SetBytes(Bytestream[48:])
+	return []*big.Int{x, y}
+}
+
+func (g1Point *bls12381Point1) IsOnCurve() bool {
+	return bls.NewG1().IsOnCurve(new(bls.PointG1).Set(g1Point.point))
+}
+
+// IsInSubgroup checks that the point is in G1, using the endomorphism based
+// check from "Faster Subgroup Checks for BLS12-381" by Bowe.
+func (g1Point *bls12381Point1) IsInSubgroup() bool {
+	return bls.NewG1().InCorrectSubgroup(g1Point.point)
+}
+
+func (g1Point *bls12381Point1) IsInfinity() bool {
+	return bls.NewG1().IsZero(g1Point.point)
+}
+
+// MakeG2Point expects coords to be of the form: [x0, x1, y0, y1],
+// where X = x0 * i + x1, and Y = y0 * i + y1. If check is set, it is ensured
+// that the point lies in the prime order subgroup.
+func (curve *bls12381) MakeG2Point(coords []*big.Int, check bool) (Point, bool) {
+	if len(coords) != 4 {
+		return nil, false
+	}
+	ret := make([]byte, 192)
+	for i := 0; i < 4; i++ {
+		copy(ret[48*i:], pad48Bytes(coords[i].Bytes()))
+	}
+	g := bls.NewG2()
+	result, err := g.FromBytes(ret)
+	if err != nil {
+		return nil, false
+	}
+	if check && !g.InCorrectSubgroup(result) {
+		return nil, false
+	}
+	return &bls12381Point2{result}, true
+}
+
+func (g2Point *bls12381Point2) Add(otherPoint2 Point) (Point, bool) {
+	if other, ok := (otherPoint2).(*bls12381Point2); ok {
+		sum := bls.NewG2().Add(new(bls.PointG2), g2Point.point, other.point)
+		ret := &bls12381Point2{sum}
+		return ret, true
+	}
+	return nil, false
+}
+
+func (g2Point *bls12381Point2) Copy() Point {
+	return &bls12381Point2{new(bls.PointG2).Set(g2Point.point)}
+}
+
+func (g2Point *bls12381Point2) Equals(otherPoint2 Point) bool {
+	if other, ok := (otherPoint2).(*bls12381Point2); ok {
+		return bls.NewG2().Equal(g2Point.point, other.point)
+	}
+	return false
+}
+
+func (g2Point *bls12381Point2) pointCurve() CurveSystem {
+	return Bls12381
+}
+
+// Marshal returns the 96 byte compressed form of the point, following the
+// zcash serialization format.
+func (g2Point *bls12381Point2) Marshal() []byte {
+	return bls.NewG2().ToCompressed(new(bls.PointG2).Set(g2Point.point))
+}
+
+// MarshalCompressedStandard is the same as Marshal, since that already uses the
+// zcash encoding with compression, infinity and sign flags.
+func (g2Point *bls12381Point2) MarshalCompressedStandard() []byte {
+	return g2Point.Marshal()
+}
+
+// MarshalUncompressed returns the 192 byte uncompressed form of the point,
+// following the zcash serialization format.
+func (g2Point *bls12381Point2) MarshalUncompressed() []byte {
+	return bls.NewG2().ToUncompressed(new(bls.PointG2).Set(g2Point.point))
+}
+
+func (g2Point *bls12381Point2) Mul(scalar *big.Int) Point {
+	k := new(big.Int).Mod(scalar, bls12381G1Order)
+	prod := bls.NewG2().MulScalarBig(new(bls.PointG2), g2Point.point, k)
+	return &bls12381Point2{prod}
+}
+
+// ToAffineCoords returns the affine coordinate representation of the point
+// in the form: [x0, x1, y0, y1], where X = x0 * u + x1, and Y = y0 * u + y1
+func (g2Point *bls12381Point2) ToAffineCoords() []*big.Int {
+	Bytestream := bls.NewG2().ToBytes(new(bls.PointG2).Set(g2Point.point))
+	coords := make([]*big.Int, 4)
+	for i := 0; i < 4; i++ {
+		coords[i] = new(big.Int).SetBytes(Bytestream[48*i : 48*(i+1)])
+	}
+	return coords
+}
+
+func (g2Point *bls12381Point2) IsOnCurve() bool {
+	return bls.NewG2().IsOnCurve(new(bls.PointG2).Set(g2Point.point))
+}
+
+// IsInSubgroup checks that the point is in G2, using the endomorphism based
+// check from "Faster Subgroup Checks for BLS12-381" by Bowe.
+func (g2Point *bls12381Point2) IsInSubgroup() bool {
+	return bls.NewG2().InCorrectSubgroup(g2Point.point)
+}
+
+func (g2Point *bls12381Point2) IsInfinity() bool {
+	return bls.NewG2().IsZero(g2Point.point)
+}
+
+func (gTPoint bls12381PointT) Add(otherPointT PointT) (PointT, bool) {
+	if other, ok := (otherPointT).(bls12381PointT); ok {
+		sum := new(bls.E)
+		bls.NewGT().Mul(sum, gTPoint.point, other.point)
+		return bls12381PointT{sum}, true
+	}
+	return nil, false
+}
+
+func (gTPoint bls12381PointT) Copy() PointT {
+	return bls12381PointT{new(bls.E).Set(gTPoint.point)}
+}
+
+// Inverse returns the inverse of the element in GT.
+func (gTPoint bls12381PointT) Inverse() PointT {
+	inv := new(bls.E)
+	bls.NewGT().Inverse(inv, gTPoint.point)
+	return bls12381PointT{inv}
+}
+
+func (gTPoint bls12381PointT) pointCurve() CurveSystem {
+	return Bls12381
+}
+
+func (gTPoint bls12381PointT) Marshal() []byte {
+	return bls.NewGT().ToBytes(gTPoint.point)
+}
+
+// MarshalCompressed returns the 288 byte compression of the element to the torus T2.
+func (gTPoint bls12381PointT) MarshalCompressed() []byte {
+	return bls12381GTTower.compress(gTPoint.Marshal())
+}
+
+// ToAffineCoords returns the 12 coefficients of the element over Fp, in the order
+// of Marshal, which is the same as for altbn128.
+func (gTPoint bls12381PointT) ToAffineCoords() []*big.Int {
+	coords, _ := bls12381GTTower.coords(gTPoint.Marshal())
+	return coords
+}
+
+func (gTPoint bls12381PointT) Equals(otherPointT PointT) bool {
+	if other, ok := (otherPointT).(bls12381PointT); ok {
+		return gTPoint.point.Equal(other.point)
+	}
+	return false
+}
+
+// Mul exponentiates the element by scalar, which may be negative, using
+// cyclotomic squarings.
+func (gTPoint bls12381PointT) Mul(scalar *big.Int) PointT {
+	k := new(big.Int).Mod(scalar, bls12381G1Order)
+	prod := new(bls.E)
+	bls.NewGT().Exp(prod, gTPoint.point, k)
+	return bls12381PointT{prod}
+}
+
+func (curve *bls12381) Pair(g1Point Point, g2Point Point) (PointT, bool) {
+	return curve.PairingProduct([]Point{g1Point}, []Point{g2Point})
+}
+
+// PairingProduct computes the product of pairings with a shared final exponentiation.
+func (curve *bls12381) PairingProduct(g1Points []Point, g2Points []Point) (PointT, bool) {
+	if len(g1Points) != len(g2Points) {
+		return nil, false
+	}
+	engine := bls.NewEngine()
+	for i := 0; i < len(g1Points); i++ {
+		pt1, ok1 := g1Points[i].(*bls12381Point1)
+		pt2, ok2 := g2Points[i].(*bls12381Point2)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		// AddPair converts the points to affine form in place, so copies are used
+		engine.AddPair(new(bls.PointG1).Set(pt1.point), new(bls.PointG2).Set(pt2.point))
+	}
+	return bls12381PointT{engine.Result()}, true
+}
+
+// PairingProductContext is PairingProduct, which returns an error if ctx is
+// already done. The pairing engine can't be interrupted once it has started.
+func (curve *bls12381) PairingProductContext(ctx context.Context, g1Points []Point, g2Points []Point) (PointT, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	prod, ok := curve.PairingProduct(g1Points, g2Points)
+	if !ok {
+		return nil, errInvalidPairingInput
+	}
+	return prod, nil
+}
+
+// PairingCheck returns true if the product of pairings is the identity in GT.
+func (curve *bls12381) PairingCheck(g1Points []Point, g2Points []Point) bool {
+	prod, ok := curve.PairingProduct(g1Points, g2Points)
+	return ok && prod.Equals(curve.GetGTIdentity())
+}
+
+// UnmarshalG1Batch is UnmarshalG1 for every element of data, in parallel.
+// BLS12-381 doesn't batch any of the work, since the library decompresses and
+// checks each point on its own, with its own square root and inversion.
+func (curve *bls12381) UnmarshalG1Batch(data [][]byte) ([]Point, []error) {
+	return unmarshalBatch(data, curve.UnmarshalG1)
+}
+
+// UnmarshalG2Batch is UnmarshalG2 for every element of data, in parallel. As
+// with UnmarshalG1Batch, nothing is shared between the points.
+func (curve *bls12381) UnmarshalG2Batch(data [][]byte) ([]Point, []error) {
+	return unmarshalBatch(data, curve.UnmarshalG2)
+}
+
+// UnmarshalG1 accepts both the 48 byte compressed and 96 byte uncompressed encodings.
+// Both ensure that the point lies in the prime order subgroup.
+func (curve *bls12381) UnmarshalG1(data []byte) (Point, bool) {
+	if data == nil || (len(data) != 96 && len(data) != 48) {
+		return nil, false
+	}
+	var pt *bls.PointG1
+	var err error
+	if len(data) == 96 {
+		pt, err = bls.NewG1().FromUncompressed(data)
+	} else {
+		pt, err = bls.NewG1().FromCompressed(data)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return &bls12381Point1{pt}, true
+}
+
+// UnmarshalG2 accepts both the 96 byte compressed and 192 byte uncompressed encodings.
+// Both ensure that the point lies in the prime order subgroup.
+func (curve *bls12381) UnmarshalG2(data []byte) (Point, bool) {
+	if data == nil || (len(data) != 192 && len(data) != 96) {
+		return nil, false
+	}
+	var pt *bls.PointG2
+	var err error
+	if len(data) == 192 {
+		pt, err = bls.NewG2().FromUncompressed(data)
+	} else {
+		pt, err = bls.NewG2().FromCompressed(data)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return &bls12381Point2{pt}, true
+}
+
+// UnmarshalG1CompressedStandard decodes the 48 byte zcash encoding.
+func (curve *bls12381) UnmarshalG1CompressedStandard(data []byte) (Point, bool) {
+	if len(data) != 48 {
+		return nil, false
+	}
+	return curve.UnmarshalG1(data)
+}
+
+// UnmarshalG2CompressedStandard decodes the 96 byte zcash encoding.
+func (curve *bls12381) UnmarshalG2CompressedStandard(data []byte) (Point, bool) {
+	if len(data) != 96 {
+		return nil, false
+	}
+	return curve.UnmarshalG2(data)
+}
+
+// UnmarshalG1Strict only accepts the canonical encodings produced by Marshal and
+// MarshalUncompressed. data is not modified.
+func (curve *bls12381) UnmarshalG1Strict(data []byte) (Point, bool) {
+	return unmarshalCanonical(data, curve.UnmarshalG1, 48)
+}
+
+// UnmarshalG2Strict only accepts the canonical encodings produced by Marshal and
+// MarshalUncompressed. data is not modified.
+func (curve *bls12381) UnmarshalG2Strict(data []byte) (Point, bool) {
+	return unmarshalCanonical(data, curve.UnmarshalG2, 96)
+}
+
+func (curve *bls12381) UnmarshalGT(data []byte) (PointT, bool) {
+	if data == nil || len(data) != 576 {
+		return nil, false
+	}
+	e, err := bls.NewGT().FromBytes(data)
+	if err != nil {
+		return nil, false
+	}
+	return bls12381PointT{e}, true
+}
+
+// UnmarshalGTCompressed decodes the output of MarshalCompressed.
+func (curve *bls12381) UnmarshalGTCompressed(data []byte) (PointT, bool) {
+	decompressed, ok := bls12381GTTower.decompress(data)
+	if !ok {
+		return nil, false
+	}
+	return curve.UnmarshalGT(decompressed)
+}
+
+// MakeGTPoint expects the coefficients returned by ToAffineCoords.
+func (curve *bls12381) MakeGTPoint(coords []*big.Int) (PointT, bool) {
+	data, ok := bls12381GTTower.fromCoords(coords)
+	if !ok {
+		return nil, false
+	}
+	return curve.UnmarshalGT(data)
+}
+
+func (curve *bls12381) getG1A() *big.Int {
+	return zero
+}
+
+func (curve *bls12381) getG1B() *big.Int {
+	return bls12381G1B
+}
+
+func (curve *bls12381) GetG1Q() *big.Int {
+	return bls12381G1Q
+}
+
+func (curve *bls12381) GetG1Order() *big.Int {
+	return bls12381G1Order
+}
+
+func (curve *bls12381) g1XToYSquared(x *big.Int) *big.Int {
+	result := new(big.Int)
+	result.Exp(x, three, bls12381G1Q)
+	result.Add(result, bls12381G1B)
+	result.Mod(result, bls12381G1Q)
+	return result
+}
+
+func (curve *bls12381) GetG1() Point {
+	return bls12381G1
+}
+
+func (curve *bls12381) GetG2() Point {
+	return bls12381G2
+}
+
+func (curve *bls12381) GetGT() PointT {
+	return bls12381GT
+}
+
+func (curve *bls12381) G1BaseMul(k *big.Int) Point {
+	return bls12381G1Table.mul(k)
+}
+
+func (curve *bls12381) G2BaseMul(k *big.Int) Point {
+	return bls12381G2Table.mul(k)
+}
+
+func (curve *bls12381) GetG1Infinity() Point {
+	return &bls12381Point1{bls.NewG1().Zero()}
+}
+
+func (curve *bls12381) GetG2Infinity() Point {
+	return &bls12381Point2{bls.NewG2().Zero()}
+}
+
+func (curve *bls12381) GetGTIdentity() PointT {
+	return bls12381PointT{bls.NewGT().New()}
+}
+
+func (curve *bls12381) getG1Cofactor() *big.Int {
+	return bls12381G1Cofactor
+}
+
+func (curve *bls12381) getFTHashParams() (*big.Int, *big.Int) {
+	return bls12381Sqrtn3, bls12381Z
+}
+
+// HashToG1 hashes a message to a point on G1 using the
+// BLS12381G1_XMD:SHA-256_SSWU_RO_ suite from the IETF hash to curve draft,
+// with the domain separation tag of the basic BLS signature ciphersuite.
+func (curve *bls12381) HashToG1(message []byte) Point {
+	pt, err := bls.NewG1().HashToCurve(message, bls12381G1DST)
+	if err != nil {
+		return nil
+	}
+	return &bls12381Point1{pt}
+}
+
+// HashToG2 hashes a message to a point on G2 using the
+// BLS12381G2_XMD:SHA-256_SSWU_RO_ suite from the IETF hash to curve draft,
+// with the domain separation tag of the basic BLS signature ciphersuite.
+func (curve *bls12381) HashToG2(message []byte) Point {
+	pt, err := bls.NewG2().HashToCurve(message, bls12381G2DST)
+	if err != nil {
+		return nil
+	}
+	return &bls12381Point2{pt}
+}
+
+// Bls12381HashToG1WithDST returns a function which hashes messages to G1 on
+// Bls12381 with the BLS12381G1_XMD:SHA-256_SSWU_RO_ suite, under the given
+// domain separation tag.
+func Bls12381HashToG1WithDST(dst []byte) func([]byte) Point {
+	dstCopy := append([]byte{}, dst...)
+	return func(message []byte) Point {
+		pt, err := bls.NewG1().HashToCurve(message, dstCopy)
+		if err != nil {
+			return nil
+		}
+		return &bls12381Point1{pt}
+	}
+}
+
+//curve specific constants
+var bls12381G1B = big.NewInt(4)
+var bls12381G1Q, _ = new(big.Int).SetString("4002409555221667393417789825735904156556882819939007885332058136124031650490837864442687629129015664037894272559787", 10)
+var bls12381G1Order, _ = new(big.Int).SetString("52435875175126190479447740508185965837690552500527637822603658699938581184513", 10)
+var bls12381G1Cofactor, _ = new(big.Int).SetString("76329603384216526031706109802092473003", 10)
+
+//precomputed Z = (-1 + sqrt(-3))/2 in Fq
+var bls12381Z, _ = new(big.Int).SetString("793479390729215512621379701633421447060886740281060493010456487427281649075476305620758731620350", 10)
+
+//precomputed sqrt(-3) in Fq
+var bls12381Sqrtn3, _ = new(big.Int).SetString("1586958781458431025242759403266842894121773480562120986020912974854563298150952611241517463240701", 10)
+
+var bls12381G1DST = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_")
+var bls12381G2DST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_NUL_")
+
+var bls12381G1 = &bls12381Point1{bls.NewG1().One()}
+var bls12381G2 = &bls12381Point2{bls.NewG2().One()}
+var bls12381GT, _ = Bls12381.Pair(bls12381G1, bls12381G2)
+var bls12381G1Table = newFixedBaseTable(bls12381G1, bls12381G1Order, Bls12381.GetG1Infinity,
+	func(row []Point) {
+		points := make([]*bls.PointG1, len(row))
+		for i := range row {
+			points[i] = row[i].(*bls12381Point1).point
+		}
+		bls.NewG1().AffineBatch(points)
+	})
+var bls12381G2Table = newFixedBaseTable(bls12381G2, bls12381G1Order, Bls12381.GetG2Infinity,
+	func(row []Point) {
+		points := make([]*bls.PointG2, len(row))
+		for i := range row {
+			points[i] = row[i].(*bls12381Point2).point
+		}
+		bls.NewG2().AffineBatch(points)
+	})
@@ -0,0 +1,246 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+	google "github.com/ethereum/go-ethereum/crypto/bn256/google"
+)
+
+// Bn256Backend is an implementation of the groups and the pairing of altbn128,
+// which NewAltbn128 builds the curve over. Values are exchanged with a backend
+// in the layouts of the Ethereum precompiles: 64 bytes for G1, 128 bytes for
+// G2, and 384 bytes for GT, with all zeros for the point at infinity. All of
+// the encodings, hashing and subgroup checks are done by the curve, so the
+// backends only need to agree on the group operations.
+type Bn256Backend interface {
+	Name() string
+	// g1FromBytes and g2FromBytes must reject coordinates which aren't less than
+	// q, and points which aren't on the curve. g2FromBytes must also reject
+	// points outside of G2. gtFromBytes only needs to check the length.
+	g1FromBytes([]byte) (bn256G1, bool)
+	g2FromBytes([]byte) (bn256G2, bool)
+	gtFromBytes([]byte) (bn256GT, bool)
+	// miller returns an element of Fp12 whose final exponentiation is the
+	// pairing of two points other than infinity. Backends without a separate
+	// Miller loop may return the pairing, and make finalExp the identity.
+	miller(bn256G1, bn256G2) bn256GT
+	finalExp(bn256GT) bn256GT
+}
+
+// bn256PairingOnly is implemented by backends without a separate Miller loop,
+// whose miller returns the pairing. Pairings with prepared points can't use the
+// lines in this package with them, and go through miller instead.
+type bn256PairingOnly interface {
+	pairingOnly()
+}
+
+// bn256G1 and bn256G2 are points, and bn256GT is an element of Fp12. Their
+// methods don't modify the receiver or the arguments. Scalars are non-negative.
+type bn256G1 interface {
+	add(bn256G1) bn256G1
+	mul(*big.Int) bn256G1
+	bytes() []byte
+}
+
+type bn256G2 interface {
+	add(bn256G2) bn256G2
+	mul(*big.Int) bn256G2
+	bytes() []byte
+}
+
+type bn256GT interface {
+	mul(bn256GT) bn256GT
+	exp(*big.Int) bn256GT
+	// inverse is only required to be correct for elements of GT, where it may
+	// be computed as the conjugate.
+	inverse() bn256GT
+	bytes() []byte
+}
+
+// Bn256Cloudflare is go-ethereum's crypto/bn256/cloudflare, which uses assembly
+// on amd64 and arm64. It is the backend of Altbn128.
+var Bn256Cloudflare Bn256Backend = cloudflareBackend{}
+
+// Bn256Google is go-ethereum's crypto/bn256/google, the original pure Go
+// implementation, which has no separate Miller loop.
+var Bn256Google Bn256Backend = googleBackend{}
+
+type cloudflareBackend struct{}
+type cloudflareG1 struct{ p *bn256.G1 }
+type cloudflareG2 struct{ p *bn256.G2 }
+type cloudflareGT struct{ p *bn256.GT }
+
+func (cloudflareBackend) Name() string {
+	return "cloudflare"
+}
+
+func (cloudflareBackend) g1FromBytes(data []byte) (bn256G1, bool) {
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	return cloudflareG1{p}, true
+}
+
+func (cloudflareBackend) g2FromBytes(data []byte) (bn256G2, bool) {
+	p := new(bn256.G2)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	return cloudflareG2{p}, true
+}
+
+func (cloudflareBackend) gtFromBytes(data []byte) (bn256GT, bool) {
+	p := new(bn256.GT)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	return cloudflareGT{p}, true
+}
+
+func (cloudflareBackend) miller(g1 bn256G1, g2 bn256G2) bn256GT {
+	return cloudflareGT{bn256.Miller(g1.(cloudflareG1).p, g2.(cloudflareG2).p)}
+}
+
+func (cloudflareBackend) finalExp(f bn256GT) bn256GT {
+	return cloudflareGT{new(bn256.GT).Set(f.(cloudflareGT).p).Finalize()}
+}
+
+func (a cloudflareG1) add(b bn256G1) bn256G1 {
+	return cloudflareG1{new(bn256.G1).Add(a.p, b.(cloudflareG1).p)}
+}
+
+func (a cloudflareG1) mul(k *big.Int) bn256G1 {
+	return cloudflareG1{new(bn256.G1).ScalarMult(a.p, k)}
+}
+
+func (a cloudflareG1) bytes() []byte {
+	return a.p.Marshal()
+}
+
+func (a cloudflareG2) add(b bn256G2) bn256G2 {
+	return cloudflareG2{new(bn256.G2).Add(a.p, b.(cloudflareG2).p)}
+}
+
+func (a cloudflareG2) mul(k *big.Int) bn256G2 {
+	return cloudflareG2{new(bn256.G2).ScalarMult(a.p, k)}
+}
+
+func (a cloudflareG2) bytes() []byte {
+	return a.p.Marshal()
+}
+
+func (a cloudflareGT) mul(b bn256GT) bn256GT {
+	return cloudflareGT{new(bn256.GT).Add(a.p, b.(cloudflareGT).p)}
+}
+
+func (a cloudflareGT) exp(k *big.Int) bn256GT {
+	return cloudflareGT{new(bn256.GT).ScalarMult(a.p, k)}
+}
+
+func (a cloudflareGT) inverse() bn256GT {
+	return cloudflareGT{new(bn256.GT).Neg(a.p)}
+}
+
+func (a cloudflareGT) bytes() []byte {
+	return a.p.Marshal()
+}
+
+type googleBackend struct{}
+type googleG1 struct{ p *google.G1 }
+type googleG2 struct{ p *google.G2 }
+type googleGT struct{ p *google.GT }
+
+func (googleBackend) Name() string {
+	return "google"
+}
+
+func (googleBackend) g1FromBytes(data []byte) (bn256G1, bool) {
+	p := new(google.G1)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	return googleG1{p}, true
+}
+
+func (googleBackend) g2FromBytes(data []byte) (bn256G2, bool) {
+	p := new(google.G2)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, false
+	}
+	return googleG2{p}, true
+}
+
+func (googleBackend) gtFromBytes(data []byte) (bn256GT, bool) {
+	p, ok := new(google.GT).Unmarshal(data)
+	if !ok {
+		return nil, false
+	}
+	return googleGT{p}, true
+}
+
+func (googleBackend) miller(g1 bn256G1, g2 bn256G2) bn256GT {
+	return googleGT{google.Pair(g1.(googleG1).p, g2.(googleG2).p)}
+}
+
+func (googleBackend) finalExp(f bn256GT) bn256GT {
+	return f
+}
+
+func (googleBackend) pairingOnly() {}
+
+// The addition of the google library fails when both points are equal, so
+// that case is handled by doubling.
+func (a googleG1) add(b bn256G1) bn256G1 {
+	other := b.(googleG1)
+	if bytes.Equal(a.p.Marshal(), other.p.Marshal()) {
+		return googleG1{new(google.G1).ScalarMult(a.p, two)}
+	}
+	return googleG1{new(google.G1).Add(a.p, other.p)}
+}
+
+// Reducing the scalar ensures that the multiplication never adds equal points.
+func (a googleG1) mul(k *big.Int) bn256G1 {
+	return googleG1{new(google.G1).ScalarMult(a.p, new(big.Int).Mod(k, google.Order))}
+}
+
+func (a googleG1) bytes() []byte {
+	return a.p.Marshal()
+}
+
+func (a googleG2) add(b bn256G2) bn256G2 {
+	other := b.(googleG2)
+	if bytes.Equal(a.p.Marshal(), other.p.Marshal()) {
+		return googleG2{new(google.G2).ScalarMult(a.p, two)}
+	}
+	return googleG2{new(google.G2).Add(a.p, other.p)}
+}
+
+func (a googleG2) mul(k *big.Int) bn256G2 {
+	return googleG2{new(google.G2).ScalarMult(a.p, new(big.Int).Mod(k, google.Order))}
+}
+
+func (a googleG2) bytes() []byte {
+	return a.p.Marshal()
+}
+
+func (a googleGT) mul(b bn256GT) bn256GT {
+	return googleGT{new(google.GT).Add(a.p, b.(googleGT).p)}
+}
+
+func (a googleGT) exp(k *big.Int) bn256GT {
+	return googleGT{new(google.GT).ScalarMult(a.p, k)}
+}
+
+func (a googleGT) inverse() bn256GT {
+	return googleGT{new(google.GT).Neg(a.p)}
+}
+
+func (a googleGT) bytes() []byte {
+	return a.p.Marshal()
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var bn256Backends = []Bn256Backend{Bn256Cloudflare, Bn256Google, Bn256Reference}
+
+// TestBn256Differential runs random operations on altbn128 over every backend,
+// and checks that the encodings of the results are the same.
+func TestBn256Differential(t *testing.T) {
+	backendCurves := make([]CurveSystem, len(bn256Backends))
+	for i, backend := range bn256Backends {
+		backendCurves[i] = NewAltbn128(backend)
+	}
+	assert.True(t, NewAltbn128(Bn256Cloudflare) == Altbn128)
+	assert.True(t, NewAltbn128(Bn256Reference) == backendCurves[2], "curves aren't cached")
+	// same checks that op gives the same encoding on every curve, which it
+	// returns, and that the curves are equal to the first.
+	same := func(name string, op func(curve CurveSystem) []byte) []byte {
+		expected := op(backendCurves[0])
+		for i := 1; i < len(backendCurves); i++ {
+			assert.Equal(t, expected, op(backendCurves[i]), name+" differs on "+bn256Backends[i].Name())
+		}
+		return expected
+	}
+	random := func() *big.Int {
+		k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+		return k
+	}
+
+	for i := 0; i < 3; i++ {
+		a, b := random(), random()
+		same("G1 Mul", func(curve CurveSystem) []byte { return curve.GetG1().Mul(a).MarshalUncompressed() })
+		same("G2 Mul", func(curve CurveSystem) []byte { return curve.GetG2().Mul(a).MarshalUncompressed() })
+		same("G1 BaseMul", func(curve CurveSystem) []byte { return curve.G1BaseMul(a).MarshalUncompressed() })
+		same("G2 BaseMul", func(curve CurveSystem) []byte { return curve.G2BaseMul(a).MarshalUncompressed() })
+		same("G1 Add", func(curve CurveSystem) []byte {
+			sum, _ := curve.GetG1().Mul(a).Add(curve.GetG1().Mul(b))
+			return sum.MarshalUncompressed()
+		})
+		same("G2 Add", func(curve CurveSystem) []byte {
+			sum, _ := curve.GetG2().Mul(a).Add(curve.GetG2().Mul(b))
+			return sum.MarshalUncompressed()
+		})
+		same("G1 Double", func(curve CurveSystem) []byte {
+			sum, _ := curve.GetG1().Mul(a).Add(curve.GetG1().Mul(a))
+			return sum.MarshalUncompressed()
+		})
+		same("G2 Double", func(curve CurveSystem) []byte {
+			sum, _ := curve.GetG2().Mul(a).Add(curve.GetG2().Mul(a))
+			return sum.MarshalUncompressed()
+		})
+		same("G1 Negate", func(curve CurveSystem) []byte {
+			sum, _ := curve.GetG1().Mul(a).Add(curve.GetG1().Mul(new(big.Int).Neg(a)))
+			return sum.MarshalUncompressed()
+		})
+
+		// Encodings made by one backend are decoded by the others
+		enc1 := same("G1 Marshal", func(curve CurveSystem) []byte { return curve.GetG1().Mul(b).Marshal() })
+		enc2 := same("G2 Marshal", func(curve CurveSystem) []byte { return curve.GetG2().Mul(b).Marshal() })
+		same("G1 Unmarshal", func(curve CurveSystem) []byte {
+			pt, ok := curve.UnmarshalG1(enc1)
+			assert.True(t, ok)
+			return pt.MarshalUncompressed()
+		})
+		same("G2 Unmarshal", func(curve CurveSystem) []byte {
+			pt, ok := curve.UnmarshalG2(enc2)
+			assert.True(t, ok)
+			return pt.MarshalUncompressed()
+		})
+		garbage := make([]byte, 64)
+		rand.Read(garbage)
+		garbage[0] &= 0x3f
+		same("G1 Unmarshal garbage", func(curve CurveSystem) []byte {
+			if pt, ok := curve.UnmarshalG1(garbage); ok {
+				return pt.MarshalUncompressed()
+			}
+			return nil
+		})
+
+		same("Pair", func(curve CurveSystem) []byte {
+			f, _ := curve.Pair(curve.GetG1().Mul(a), curve.GetG2().Mul(b))
+			return f.Marshal()
+		})
+		gtEnc := same("GT Mul", func(curve CurveSystem) []byte { return curve.GetGT().Mul(a).Marshal() })
+		same("GT Unmarshal", func(curve CurveSystem) []byte {
+			f, ok := curve.UnmarshalGT(gtEnc)
+			assert.True(t, ok)
+			return f.Inverse().Marshal()
+		})
+		same("PairingProduct", func(curve CurveSystem) []byte {
+			g1s := []Point{curve.GetG1().Mul(a), curve.GetG1(), curve.GetG1Infinity()}
+			g2s := []Point{curve.GetG2(), curve.GetG2().Mul(b), curve.GetG2()}
+			f, ok := curve.PairingProduct(g1s, g2s)
+			assert.True(t, ok)
+			return f.Marshal()
+		})
+	}
+}
+
+func TestBn256BackendsDontMix(t *testing.T) {
+	google := newAltbn128(Bn256Google)
+	_, ok := Altbn128.GetG1().Add(google.GetG1())
+	assert.False(t, ok, "points over different backends were added")
+	_, ok = google.Pair(Altbn128.GetG1(), google.GetG2())
+	assert.False(t, ok, "points over different backends were paired")
+	assert.False(t, Altbn128.GetG2().Equals(google.GetG2()))
+	converted, ok := google.UnmarshalG2(Altbn128.GetG2().Marshal())
+	assert.True(t, ok && converted.Equals(google.GetG2()))
+	_, ok = google.PrepareG2(Altbn128.GetG2())
+	assert.False(t, ok, "a point over another backend was prepared")
+	prepared, _ := Altbn128.PrepareG2(Altbn128.GetG2())
+	_, ok = google.PairingProductPrepared([]Point{google.GetG1()}, []PreparedG2{prepared})
+	assert.False(t, ok, "a point prepared over another backend was paired")
+}
+
+func BenchmarkBn256Pair(b *testing.B) {
+	for _, backend := range bn256Backends {
+		curve := NewAltbn128(backend)
+		b.Run(backend.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.Pair(curve.GetG1(), curve.GetG2())
+			}
+		})
+	}
+}
+
+// BenchmarkBn256PairPrepared pairs with a prepared point of G2, whose lines
+// are evaluated in this package on every backend but google.
+func BenchmarkBn256PairPrepared(b *testing.B) {
+	for _, backend := range bn256Backends {
+		curve := newAltbn128(backend)
+		prepared, _ := curve.PrepareG2(curve.GetG2())
+		b.Run(backend.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.PairingProductPrepared([]Point{curve.GetG1()}, []PreparedG2{prepared})
+			}
+		})
+	}
+}
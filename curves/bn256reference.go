@@ -0,0 +1,335 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// Bn256Reference is a straightforward implementation of altbn128 in pure Go,
+// using big.Int arithmetic, affine coordinates on G1, and the tower of gtTower
+// for GT. The pairing is the optimal ate pairing, with a binary Miller loop and
+// a naive final exponentiation. It is much slower than the other backends, and
+// is meant for cross checking them, and for platforms where their results are
+// in doubt.
+var Bn256Reference Bn256Backend = referenceBackend{}
+
+type referenceBackend struct{}
+
+// referenceG1 is a point in affine coordinates, with x = y = 0 for infinity.
+type referenceG1 struct {
+	x, y *big.Int
+}
+
+type referenceG2 struct {
+	pt *altbnTwistPoint
+}
+
+// referenceGT is g0 + g1 w in Fp12.
+type referenceGT struct {
+	g0, g1 fp6
+}
+
+// (p^4 - p^2 + 1) / r, the hard part of the final exponentiation
+var referenceHardExponent = func() *big.Int {
+	p2 := new(big.Int).Mul(altbnG1Q, altbnG1Q)
+	e := new(big.Int).Mul(p2, p2)
+	e.Sub(e, p2).Add(e, one)
+	return e.Div(e, altbnG1Order)
+}()
+
+// 6u + 2, the length of the Miller loop
+var referenceLoopCount = new(big.Int).Add(new(big.Int).Mul(altbnU, big.NewInt(6)), two)
+
+func (referenceBackend) Name() string {
+	return "reference"
+}
+
+func (referenceBackend) g1FromBytes(data []byte) (bn256G1, bool) {
+	if len(data) != 64 {
+		return nil, false
+	}
+	x := new(big.Int).SetBytes(data[:32])
+	y := new(big.Int).SetBytes(data[32:])
+	if !altbnG1IsOnCurve(x, y) {
+		return nil, false
+	}
+	return referenceG1{x, y}, true
+}
+
+func (referenceBackend) g2FromBytes(data []byte) (bn256G2, bool) {
+	if len(data) != 128 {
+		return nil, false
+	}
+	coords := make([]*big.Int, 4)
+	for i := range coords {
+		coords[i] = new(big.Int).SetBytes(data[32*i : 32*(i+1)])
+	}
+	if !altbnG2IsOnCurve(coords) || !altbnG2IsInSubgroup(coords) {
+		return nil, false
+	}
+	if coords[0].Sign() == 0 && coords[1].Sign() == 0 && coords[2].Sign() == 0 && coords[3].Sign() == 0 {
+		return referenceG2{getAltbnTwistInfinity()}, true
+	}
+	return referenceG2{newAltbnTwistPoint(&complexNum{coords[0], coords[1]}, &complexNum{coords[2], coords[3]})}, true
+}
+
+func (referenceBackend) gtFromBytes(data []byte) (bn256GT, bool) {
+	t := altbnGTTower
+	if len(data) != 12*t.byteLen {
+		return nil, false
+	}
+	g1, ok1 := t.fp6FromBytes(data[:6*t.byteLen])
+	g0, ok0 := t.fp6FromBytes(data[6*t.byteLen:])
+	if !ok0 || !ok1 {
+		return nil, false
+	}
+	return referenceGT{g0, g1}, true
+}
+
+// miller computes f_{6u+2,Q}(P) l_{T,psi(Q)}(P) l_{T+psi(Q),-psi^2(Q)}(P), where
+// T = [6u+2]Q, with the lines evaluated at the untwisted points. The vertical
+// lines are left out, since they are in Fp6, and the final exponentiation maps
+// them to one.
+func (b referenceBackend) miller(g1 bn256G1, g2 bn256G2) bn256GT {
+	return b.prepare(g2)(g1)
+}
+
+// prepare computes the lines of the Miller loop with Q, in the order in which
+// they are multiplied in. Evaluating a line at P then takes two multiplications
+// in Fp, instead of the inversion which finds its slope.
+func (referenceBackend) prepare(g2 bn256G2) func(bn256G1) bn256GT {
+	q := g2.(referenceG2).pt
+	qx, qy := referenceTwistAffine(q)
+	tx, ty := qx, qy
+	lines := make([]referenceLineCoeffs, 0, 2*referenceLoopCount.BitLen())
+	var line referenceLineCoeffs
+	for i := referenceLoopCount.BitLen() - 2; i >= 0; i-- {
+		line, tx, ty = referenceLine(tx, ty, tx, ty)
+		lines = append(lines, line)
+		if referenceLoopCount.Bit(i) == 1 {
+			line, tx, ty = referenceLine(tx, ty, qx, qy)
+			lines = append(lines, line)
+		}
+	}
+	q1x, q1y := referenceTwistAffine(q.psi())
+	q2x, q2y := referenceTwistAffine(q.psi().psi())
+	q2y.Sub(getComplexZero(), q2y, altbnG1Q)
+	line, tx, ty = referenceLine(tx, ty, q1x, q1y)
+	lines = append(lines, line)
+	line, _, _ = referenceLine(tx, ty, q2x, q2y)
+	lines = append(lines, line)
+
+	return func(g1 bn256G1) bn256GT {
+		p := g1.(referenceG1)
+		f := referenceGTOne()
+		next := 0
+		for i := referenceLoopCount.BitLen() - 2; i >= 0; i-- {
+			f = f.square().mulGT(lines[next].at(p))
+			next++
+			if referenceLoopCount.Bit(i) == 1 {
+				f = f.mulGT(lines[next].at(p))
+				next++
+			}
+		}
+		return f.mulGT(lines[next].at(p)).mulGT(lines[next+1].at(p))
+	}
+}
+
+// finalExp raises f to (p^12 - 1) / r. The easy part, (p^6 - 1)(p^2 + 1), takes
+// a conjugate, an inverse and a Frobenius map. The hard part is computed with
+// square and multiply.
+func (referenceBackend) finalExp(f bn256GT) bn256GT {
+	g := f.(referenceGT)
+	g = g.conjugate().mulGT(g.inverse().(referenceGT))
+	g = g.frobenius().frobenius().mulGT(g)
+	return g.exp(referenceHardExponent)
+}
+
+// referenceLineCoeffs is a line on the twist with slope lambda through T, and
+// c = lambda xT - yT.
+type referenceLineCoeffs struct {
+	lambda, c *complexNum
+}
+
+// referenceLine returns the line through T and Q on the twist, or the tangent
+// at T if they are the same, as well as T + Q.
+func referenceLine(tx, ty, qx, qy *complexNum) (referenceLineCoeffs, *complexNum, *complexNum) {
+	q := altbnG1Q
+	var lambda *complexNum
+	if tx.Equals(qx) && ty.Equals(qy) {
+		// lambda = 3 xT^2 / (2 yT)
+		num := getComplexZero().Square(tx, q)
+		num.Mul(num, &complexNum{big.NewInt(0), three}, q)
+		den := getComplexZero().Add(ty, ty, q)
+		lambda = num.Mul(num, den.Inverse(den, q), q)
+	} else {
+		num := getComplexZero().Sub(qy, ty, q)
+		den := getComplexZero().Sub(qx, tx, q)
+		lambda = num.Mul(num, den.Inverse(den, q), q)
+	}
+	// x3 = lambda^2 - xT - xQ, y3 = lambda (xT - x3) - yT
+	x3 := getComplexZero().Square(lambda, q)
+	x3.Sub(x3, tx, q).Sub(x3, qx, q)
+	y3 := getComplexZero().Sub(tx, x3, q)
+	y3.Mul(y3, lambda, q).Sub(y3, ty, q)
+
+	c := getComplexZero().Mul(lambda, tx, q)
+	c.Sub(c, ty, q)
+	return referenceLineCoeffs{lambda, c}, x3, y3
+}
+
+// at evaluates the line at P. The line through the untwisted points
+// (xT w^2, yT w^3) and (xQ w^2, yQ w^3) is yP - lambda xP w + (lambda xT - yT) w^3,
+// and w^3 = v w.
+func (line referenceLineCoeffs) at(pt referenceG1) referenceGT {
+	q := altbnG1Q
+	c0 := &complexNum{new(big.Int).Mul(line.lambda.im, pt.x), new(big.Int).Mul(line.lambda.re, pt.x)}
+	c0.im.Neg(c0.im).Mod(c0.im, q)
+	c0.re.Neg(c0.re).Mod(c0.re, q)
+	return referenceGT{
+		fp6{&complexNum{big.NewInt(0), new(big.Int).Set(pt.y)}, getComplexZero(), getComplexZero()},
+		fp6{c0, line.c, getComplexZero()},
+	}
+}
+
+func referenceTwistAffine(pt *altbnTwistPoint) (x, y *complexNum) {
+	coords := pt.toAffineCoords()
+	return &complexNum{coords[0], coords[1]}, &complexNum{coords[2], coords[3]}
+}
+
+func (a referenceG1) isInfinity() bool {
+	return a.x.Sign() == 0 && a.y.Sign() == 0
+}
+
+func (a referenceG1) add(other bn256G1) bn256G1 {
+	b := other.(referenceG1)
+	if a.isInfinity() {
+		return b
+	} else if b.isInfinity() {
+		return a
+	}
+	q := altbnG1Q
+	var lambda *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return referenceG1{new(big.Int), new(big.Int)}
+		}
+		// lambda = 3 x^2 / (2 y)
+		lambda = new(big.Int).Mul(a.x, a.x)
+		lambda.Mul(lambda, three)
+		lambda.Mul(lambda, new(big.Int).ModInverse(new(big.Int).Add(a.y, a.y), q))
+	} else {
+		dx := new(big.Int).Sub(b.x, a.x)
+		lambda = new(big.Int).Sub(b.y, a.y)
+		lambda.Mul(lambda, dx.ModInverse(dx.Mod(dx, q), q))
+	}
+	lambda.Mod(lambda, q)
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, a.x).Sub(x3, b.x).Mod(x3, q)
+	y3 := new(big.Int).Sub(a.x, x3)
+	y3.Mul(y3, lambda).Sub(y3, a.y).Mod(y3, q)
+	return referenceG1{x3, y3}
+}
+
+func (a referenceG1) mul(k *big.Int) bn256G1 {
+	var result bn256G1 = referenceG1{new(big.Int), new(big.Int)}
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = result.add(result)
+		if k.Bit(i) == 1 {
+			result = result.add(a)
+		}
+	}
+	return result
+}
+
+func (a referenceG1) bytes() []byte {
+	return append(pad32Bytes(a.x.Bytes()), pad32Bytes(a.y.Bytes())...)
+}
+
+func (a referenceG2) add(other bn256G2) bn256G2 {
+	return referenceG2{a.pt.add(other.(referenceG2).pt)}
+}
+
+// mul uses GLS, since g2FromBytes only accepts points in G2.
+func (a referenceG2) mul(k *big.Int) bn256G2 {
+	return referenceG2{a.pt.mulGLS(k)}
+}
+
+func (a referenceG2) bytes() []byte {
+	data := make([]byte, 0, 128)
+	for _, c := range a.pt.toAffineCoords() {
+		data = append(data, pad32Bytes(c.Bytes())...)
+	}
+	return data
+}
+
+func referenceGTOne() referenceGT {
+	t := altbnGTTower
+	return referenceGT{t.fp6One(), fp6{getComplexZero(), getComplexZero(), getComplexZero()}}
+}
+
+// referenceFp6MulByV returns v a = xi a2 + a0 v + a1 v^2.
+func referenceFp6MulByV(a fp6) fp6 {
+	return fp6{getComplexZero().Mul(a[2], altbnGTTower.xi, altbnG1Q), a[0], a[1]}
+}
+
+// mulGT returns (a0 + a1 w)(b0 + b1 w) = a0 b0 + a1 b1 v + ((a0 + a1)(b0 + b1) - a0 b0 - a1 b1) w.
+func (a referenceGT) mulGT(b referenceGT) referenceGT {
+	t := altbnGTTower
+	a0b0 := t.fp6Mul(a.g0, b.g0)
+	a1b1 := t.fp6Mul(a.g1, b.g1)
+	cross := t.fp6Mul(t.fp6Add(a.g0, a.g1), t.fp6Add(b.g0, b.g1))
+	cross = t.fp6Sub(t.fp6Sub(cross, a0b0), a1b1)
+	return referenceGT{t.fp6Add(a0b0, referenceFp6MulByV(a1b1)), cross}
+}
+
+func (a referenceGT) square() referenceGT {
+	return a.mulGT(a)
+}
+
+func (a referenceGT) conjugate() referenceGT {
+	t := altbnGTTower
+	zero6 := fp6{getComplexZero(), getComplexZero(), getComplexZero()}
+	return referenceGT{a.g0, t.fp6Sub(zero6, a.g1)}
+}
+
+// frobenius returns a^p, as in altbnGTFrobenius.
+func (a referenceGT) frobenius() referenceGT {
+	var g0, g1 fp6
+	for i := 0; i < 3; i++ {
+		g0[i] = getComplexZero().Mul(getComplexZero().Conjugate(a.g0[i]), altbnFrobeniusGammas[2*i], altbnG1Q)
+		g1[i] = getComplexZero().Mul(getComplexZero().Conjugate(a.g1[i]), altbnFrobeniusGammas[2*i+1], altbnG1Q)
+	}
+	return referenceGT{g0, g1}
+}
+
+func (a referenceGT) mul(b bn256GT) bn256GT {
+	return a.mulGT(b.(referenceGT))
+}
+
+func (a referenceGT) exp(k *big.Int) bn256GT {
+	result := referenceGTOne()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = result.square()
+		if k.Bit(i) == 1 {
+			result = result.mulGT(a)
+		}
+	}
+	return result
+}
+
+// inverse returns (a0 - a1 w) / (a0^2 - a1^2 v), for any non-zero a.
+func (a referenceGT) inverse() bn256GT {
+	t := altbnGTTower
+	den := t.fp6Sub(t.fp6Mul(a.g0, a.g0), referenceFp6MulByV(t.fp6Mul(a.g1, a.g1)))
+	den = t.fp6Inverse(den)
+	conj := a.conjugate()
+	return referenceGT{t.fp6Mul(conj.g0, den), t.fp6Mul(conj.g1, den)}
+}
+
+func (a referenceGT) bytes() []byte {
+	t := altbnGTTower
+	return append(t.fp6Bytes(a.g1), t.fp6Bytes(a.g0)...)
+}
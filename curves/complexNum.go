@@ -25,6 +25,32 @@ func (result *complexNum) Add(num *complexNum, other *complexNum, p *big.Int) *c
 	return result
 }
 
+func (result *complexNum) Sub(num *complexNum, other *complexNum, p *big.Int) *complexNum {
+	result.im.Sub(num.im, other.im)
+	result.re.Sub(num.re, other.re)
+	result.im.Mod(result.im, p)
+	result.re.Mod(result.re, p)
+	return result
+}
+
+// Inverse sets result to num^(-1) = conj(num) / (re^2 + im^2).
+// num must be non-zero.
+func (result *complexNum) Inverse(num *complexNum, p *big.Int) *complexNum {
+	norm := new(big.Int).Mul(num.re, num.re)
+	norm.Add(norm, new(big.Int).Mul(num.im, num.im))
+	norm.ModInverse(norm, p)
+	real := new(big.Int).Mul(num.re, norm)
+	imag := new(big.Int).Mul(num.im, norm)
+	imag.Neg(imag)
+	result.re = real.Mod(real, p)
+	result.im = imag.Mod(imag, p)
+	return result
+}
+
+func (result *complexNum) IsZero() bool {
+	return result.im.Sign() == 0 && result.re.Sign() == 0
+}
+
 func (result *complexNum) Conjugate(num *complexNum) *complexNum {
 	result.re.Set(num.re)
 	result.im.Sub(zero, num.im)
@@ -91,3 +117,12 @@ func (result *complexNum) Equals(other *complexNum) bool {
 	}
 	return true
 }
+
+// complexLexicographicallyLargest compares num = im * i + re with its negation,
+// comparing im first, and then re if im is zero.
+func complexLexicographicallyLargest(num *complexNum, p *big.Int) bool {
+	if num.im.Sign() != 0 {
+		return parity(num.im, p)
+	}
+	return parity(num.re, p)
+}
@@ -0,0 +1,27 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves_test
+
+import (
+	"testing"
+
+	"github.com/orbs-network/bgls/curves"
+	"github.com/orbs-network/bgls/curves/curvetest"
+)
+
+func TestConformance(t *testing.T) {
+	for _, curve := range curves.RegisteredCurves() {
+		t.Run(curve.Name(), func(t *testing.T) {
+			curvetest.RunConformance(t, curve)
+		})
+	}
+}
+
+func TestBn256BackendConformance(t *testing.T) {
+	for _, backend := range []curves.Bn256Backend{curves.Bn256Google, curves.Bn256Reference} {
+		t.Run(backend.Name(), func(t *testing.T) {
+			curvetest.RunConformance(t, curves.NewAltbn128(backend))
+		})
+	}
+}
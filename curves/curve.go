@@ -4,6 +4,9 @@
 package curves
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"math/big"
 )
 
@@ -14,26 +17,47 @@ type CurveSystem interface {
 
 	MakeG1Point([]*big.Int, bool) (Point, bool)
 	MakeG2Point([]*big.Int, bool) (Point, bool)
-
-	// GTToAffineCoords(PointT) (*big.Int, *big.Int)
+	// MakeGTPoint expects the 12 coefficients over Fp returned by
+	// PointT.ToAffineCoords, and checks that the element is in GT.
+	MakeGTPoint([]*big.Int) (PointT, bool)
 
 	UnmarshalG1([]byte) (Point, bool)
 	UnmarshalG2([]byte) (Point, bool)
 	UnmarshalGT([]byte) (PointT, bool)
+	// UnmarshalGTCompressed decodes the output of PointT.MarshalCompressed
+	UnmarshalGTCompressed([]byte) (PointT, bool)
+	// Strict decoding only accepts the canonical encoding of each point, as
+	// output by Marshal or MarshalUncompressed.
+	UnmarshalG1Strict([]byte) (Point, bool)
+	UnmarshalG2Strict([]byte) (Point, bool)
+	// Decoders for the output of Point.MarshalCompressedStandard
+	UnmarshalG1CompressedStandard([]byte) (Point, bool)
+	UnmarshalG2CompressedStandard([]byte) (Point, bool)
+	// UnmarshalG1Batch and UnmarshalG2Batch are UnmarshalG1 and UnmarshalG2 for
+	// many points at once, which run in parallel. Altbn128 also shares the
+	// inversions of compressed G2 points. The error of each point is nil if it
+	// was decoded.
+	UnmarshalG1Batch([][]byte) ([]Point, []error)
+	UnmarshalG2Batch([][]byte) ([]Point, []error)
 
 	GetG1() Point
 	GetG2() Point
 	GetGT() PointT
 
+	// G1BaseMul and G2BaseMul return k * GetG1() and k * GetG2(), using tables
+	// of multiples of the generators which are built at init.
+	G1BaseMul(k *big.Int) Point
+	G2BaseMul(k *big.Int) Point
+
 	GetG1Infinity() Point
 	GetG2Infinity() Point
 	GetGTIdentity() PointT
 
 	HashToG1(message []byte) Point
+	HashToG2(message []byte) Point
 
 	GetG1Q() *big.Int
 	GetG1Order() *big.Int
-	// getGTQ() *big.Int
 
 	getG1Cofactor() *big.Int
 
@@ -46,6 +70,29 @@ type CurveSystem interface {
 	Pair(Point, Point) (PointT, bool)
 	// Product of Pairings
 	PairingProduct([]Point, []Point) (PointT, bool)
+	// PairingProductContext is PairingProduct, which gives up once ctx is done
+	PairingProductContext(context.Context, []Point, []Point) (PointT, error)
+	// PairingCheck returns true if the product of pairings is the identity in GT
+	PairingCheck([]Point, []Point) bool
+}
+
+// G2Preparer is a CurveSystem which can compute the lines of its Miller loop
+// with a point of G2 in advance, so that they are reused by every pairing with
+// that point. Altbn128 and the curves of NewAltbn128 implement it. BLS12-381
+// doesn't, since its library doesn't expose a separate final exponentiation.
+type G2Preparer interface {
+	CurveSystem
+	PrepareG2(Point) (PreparedG2, bool)
+	// PairingProductPrepared and PairingCheckPrepared are PairingProduct and
+	// PairingCheck, with points of G2 which have been prepared.
+	PairingProductPrepared([]Point, []PreparedG2) (PointT, bool)
+	PairingCheckPrepared([]Point, []PreparedG2) bool
+}
+
+// PreparedG2 is a point of G2 which has been prepared for pairings by
+// G2Preparer.PrepareG2. It can be used concurrently.
+type PreparedG2 interface {
+	G2() Point
 }
 
 // Point is a way to represent a point on G1 or G2, in the first two elliptic curves.
@@ -54,135 +101,74 @@ type Point interface {
 	Copy() Point
 	Equals(Point) bool
 	Marshal() []byte
+	// MarshalCompressedStandard uses the compressed encoding shared with other
+	// libraries for the curve, rather than the legacy format of Marshal
+	MarshalCompressedStandard() []byte
 	MarshalUncompressed() []byte
 	Mul(*big.Int) Point
 	ToAffineCoords() []*big.Int
+	IsOnCurve() bool
+	// IsInSubgroup checks that the point is in the prime order subgroup
+	IsInSubgroup() bool
+	IsInfinity() bool
 }
 
-// PointT is a way to represent a point on GT, in the target group
+// PointT is a way to represent a point on GT, in the target group. GT is a
+// subgroup of the multiplicative group of Fp12, of order GetG1Order(), but it is
+// written additively, to match G1 and G2. So Add multiplies, and Mul exponentiates.
 type PointT interface {
 	Add(PointT) (PointT, bool)
 	Copy() PointT
 	Equals(PointT) bool
+	// Inverse returns the inverse in GT, which is the conjugate over Fp6
+	Inverse() PointT
 	Marshal() []byte
+	// MarshalCompressed returns the compression of the element to the torus T2,
+	// which is half the size of Marshal
+	MarshalCompressed() []byte
 	Mul(*big.Int) PointT
-	// ToAffineCoords() (*big.Int, *big.Int)
+	// ToAffineCoords returns the 12 coefficients of the element over Fp,
+	// in the order in which Marshal writes them
+	ToAffineCoords() []*big.Int
 }
 
 // AggregatePoints takes the sum of points.
 func AggregatePoints(points []Point) Point {
-	if len(points) == 2 { // No parallelization needed
-		aggPoint, _ := points[0].Add(points[1])
-		return aggPoint
-	}
-	// Aggregate all the points together using concurrency
-	c := make(chan Point)
-
-	// Initialize aggPoint to an array with elements being the sum of two
-	// adjacent Points.
-	counter := 0
-	for i := 0; i < len(points); i += 2 {
-		go concurrentAggregatePoints(i, points, c)
-		counter++
-	}
-	aggPoint := make([]Point, counter)
-	for i := 0; i < counter; i++ {
-		aggPoint[i] = <-c
-	}
-
-	// Keep on aggregating every pair of points until only one aggregate point remains
-	for {
-		counter = 0
-		if len(aggPoint) == 1 {
-			break
-		}
-		for i := 0; i < len(aggPoint); i += 2 {
-			go concurrentAggregatePoints(i, aggPoint, c)
-			counter++
-		}
-		nxtAggPoint := make([]Point, counter)
-		for i := 0; i < counter; i++ {
-			nxtAggPoint[i] = <-c
-		}
-		aggPoint = nxtAggPoint
-	}
-	return aggPoint[0]
+	sum, _ := AggregatePointsContext(context.Background(), points)
+	return sum
 }
 
-// concurrentAggregatePoints handles the channel for concurrent Aggregation of points.
-// It only adds the element at points[start] and points[start + 1], and sends it through the channel
-func concurrentAggregatePoints(start int, points []Point, c chan Point) {
-	if start+1 >= len(points) {
-		c <- points[start]
-		return
-	}
-	summed, _ := points[start].Add(points[start+1])
-	c <- summed
+// AggregatePointsContext takes the sum of points. The points are split into
+// chunks, which are summed in parallel on the executor shared by this package.
+// An error is returned if there are no points, if they aren't all in the same
+// group, or if ctx is done before the sum is computed.
+func AggregatePointsContext(ctx context.Context, points []Point) (Point, error) {
+	if len(points) == 0 {
+		return nil, errNoPoints
+	}
+	ranges := chunks(len(points), parallelism(), minPointsPerChunk)
+	partials := make([]Point, len(ranges))
+	err := Parallelize(ctx, len(ranges), func(i int) error {
+		sum, err := sumPoints(points[ranges[i][0]:ranges[i][1]])
+		partials[i] = sum
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sumPoints(partials)
 }
 
-// concurrentPairingProduct computes a set of pairings in parallel,
-// and then takes their product again using concurrency.
-func concurrentPairingProduct(curve CurveSystem, points1 []Point, points2 []Point) (PointT, bool) {
-	if len(points1) != len(points2) {
-		return nil, false
-	}
-	// Compute all the pairings in parallel
-	c := make(chan PointT)
-	pairedPoints := make([]PointT, len(points1))
-	for i := 0; i < len(pairedPoints); i++ {
-		go concurrentPair(curve, points1[i], points2[i], c)
-	}
-	for i := 0; i < len(pairedPoints); i++ {
-		pairedPoints[i] = <-c
-		if pairedPoints[i] == nil {
-			return nil, false
-		}
-	}
-	counter := 0
-	// Set aggPairedPoints to an array with elements being the sum of two
-	// adjacent Points.
-	for i := 0; i < len(pairedPoints); i += 2 {
-		go concurrentAggregatePointTs(i, pairedPoints, c)
-		counter++
-	}
-	aggPairedPoints := make([]PointT, counter)
-	for i := 0; i < counter; i++ {
-		aggPairedPoints[i] = <-c
-	}
-
-	// Keep on aggregating every pair of points until only one aggregate point remains
-	for {
-		counter = 0
-		if len(aggPairedPoints) == 1 {
-			break
-		}
-		for i := 0; i < len(aggPairedPoints); i += 2 {
-			go concurrentAggregatePointTs(i, aggPairedPoints, c)
-			counter++
-		}
-		nxtPairedPoints := make([]PointT, counter)
-		for i := 0; i < counter; i++ {
-			nxtPairedPoints[i] = <-c
+// sumPoints adds points serially.
+func sumPoints(points []Point) (Point, error) {
+	sum := points[0]
+	for i := 1; i < len(points); i++ {
+		var ok bool
+		if sum, ok = sum.Add(points[i]); !ok {
+			return nil, errIncompatiblePoints
 		}
-		aggPairedPoints = nxtPairedPoints
-	}
-	return aggPairedPoints[0], true
-}
-
-// concurrentAggregatePoints handles the channel for concurrent Aggregation of points.
-// It only adds the element at points[start] and points[start + 1], and sends it through the channel
-func concurrentAggregatePointTs(start int, points []PointT, c chan PointT) {
-	if start+1 >= len(points) {
-		c <- points[start]
-		return
 	}
-	summed, _ := points[start].Add(points[start+1])
-	c <- summed
-}
-
-type indexedPoint struct {
-	index int
-	pt    Point
+	return sum, nil
 }
 
 // ScalePoints takes a set of points, and a set of multiples, and returns a
@@ -194,30 +180,62 @@ func ScalePoints(pts []Point, factors []*big.Int) (newKeys []Point) {
 		return nil
 	}
 	newKeys = make([]Point, len(pts))
-	c := make(chan *indexedPoint)
-	for i := 0; i < len(pts); i++ {
-		go concurrentScale(pts[i], factors[i], i, c)
-	}
-	for i := 0; i < len(pts); i++ {
-		pt := <-c
-		newKeys[pt.index] = pt.pt
-	}
+	Parallelize(context.Background(), len(pts), func(i int) error {
+		if factors[i] == nil {
+			newKeys[i] = pts[i].Copy()
+		} else {
+			newKeys[i] = pts[i].Mul(factors[i])
+		}
+		return nil
+	})
 	return newKeys
 }
 
-func concurrentScale(key Point, factor *big.Int, index int, c chan *indexedPoint) {
-	if factor == nil {
-		c <- &indexedPoint{index, key.Copy()}
+// unmarshalCanonical decodes data, and accepts the point only if encoding it again
+// gives back data. This rejects out of range coordinates, unused bits that are set,
+// inconsistent sign bits and alternative encodings of infinity.
+func unmarshalCanonical(data []byte, unmarshal func([]byte) (Point, bool), compressedLen int) (Point, bool) {
+	pt, ok := unmarshal(data)
+	if !ok {
+		return nil, false
+	}
+	var encoded []byte
+	if len(data) == compressedLen {
+		encoded = pt.Marshal()
 	} else {
-		c <- &indexedPoint{index, key.Mul(factor)}
+		encoded = pt.MarshalUncompressed()
+	}
+	if !bytes.Equal(encoded, data) {
+		return nil, false
 	}
+	return pt, true
 }
 
-// concurrentPair pairs pt with key, and sends the result down the channel.
-func concurrentPair(curve CurveSystem, pt1 Point, pt2 Point, c chan PointT) {
-	if targetPoint, ok := curve.Pair(pt1, pt2); ok {
-		c <- targetPoint
-		return
-	}
-	c <- nil
+// Below this many points per goroutine, additions are not worth parallelizing
+const minPointsPerChunk = 16
+
+var errNoPoints = errors.New("no points were provided")
+var errIncompatiblePoints = errors.New("points are not in the same group")
+var errInvalidPairingInput = errors.New("pairing inputs are not matching G1 and G2 points")
+var errInvalidPointEncoding = errors.New("invalid point encoding")
+
+// unmarshalBatch decodes each element of data with unmarshal, in parallel on
+// the shared executor.
+func unmarshalBatch(data [][]byte, unmarshal func([]byte) (Point, bool)) ([]Point, []error) {
+	return decodeBatch(len(data), func(i int) (Point, bool) { return unmarshal(data[i]) })
+}
+
+// decodeBatch calls decode(i) for every i in [0, n), in parallel on the shared
+// executor, and collects the points and their errors.
+func decodeBatch(n int, decode func(i int) (Point, bool)) ([]Point, []error) {
+	points := make([]Point, n)
+	errs := make([]error, n)
+	Parallelize(context.Background(), n, func(i int) error {
+		var ok bool
+		if points[i], ok = decode(i); !ok {
+			points[i], errs[i] = nil, errInvalidPointEncoding
+		}
+		return nil
+	})
+	return points, errs
 }
@@ -18,7 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var curves = []CurveSystem{Altbn128}
+var curves = []CurveSystem{Altbn128, Bls12381}
 
 func TestMarshal(t *testing.T) {
 	for _, curve := range curves {
@@ -117,6 +117,33 @@ func TestMakePoint(t *testing.T) {
 	}
 }
 
+func TestPointValidation(t *testing.T) {
+	for _, curve := range curves {
+		for i := 0; i < 5; i++ {
+			scalar, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			for _, pt := range []Point{curve.GetG1().Mul(scalar), curve.GetG2().Mul(scalar)} {
+				assert.True(t, pt.IsOnCurve(), curve.Name()+" point is not on the curve")
+				assert.True(t, pt.IsInSubgroup(), curve.Name()+" point is not in the subgroup")
+				assert.False(t, pt.IsInfinity(), curve.Name()+" point is infinity")
+			}
+		}
+		for _, pt := range []Point{curve.GetG1Infinity(), curve.GetG2Infinity()} {
+			assert.True(t, pt.IsInfinity(), curve.Name()+" infinity is not infinity")
+			assert.True(t, pt.IsOnCurve() && pt.IsInSubgroup(), curve.Name()+" infinity is not valid")
+		}
+
+		// Off curve points are rejected when check is set
+		coords := curve.GetG1().ToAffineCoords()
+		coords[1].Add(coords[1], one)
+		_, ok := curve.MakeG1Point(coords, true)
+		assert.False(t, ok, curve.Name()+" accepted an off curve G1 point")
+		coords = curve.GetG2().ToAffineCoords()
+		coords[3].Add(coords[3], one)
+		_, ok = curve.MakeG2Point(coords, true)
+		assert.False(t, ok, curve.Name()+" accepted an off curve G2 point")
+	}
+}
+
 func TestMul(t *testing.T) {
 	// TODO: Create known test cases specific to each curve from another library.
 	for _, curve := range curves {
@@ -140,12 +167,78 @@ func TestMul(t *testing.T) {
 	}
 }
 
+func TestMultiScalarMul(t *testing.T) {
+	for _, curve := range curves {
+		for _, n := range []int{1, 2, 7, 70} {
+			for _, g := range []Point{curve.GetG1(), curve.GetG2()} {
+				points := make([]Point, n)
+				scalars := make([]*big.Int, n)
+				for i := 0; i < n; i++ {
+					k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+					points[i] = g.Mul(k)
+					scalars[i], _ = rand.Int(rand.Reader, curve.GetG1Order())
+				}
+				expected := AggregatePoints(append(ScalePoints(points, scalars), g.Mul(zero)))
+				assert.True(t, MultiScalarMul(points, scalars).Equals(expected),
+					curve.Name()+" multi scalar multiplication is incorrect")
+
+				// Short scalars, as used by HAE, with nil, zero and negative scalars mixed in
+				for i := 0; i < n; i++ {
+					scalars[i], _ = rand.Int(rand.Reader, new(big.Int).Lsh(one, 128))
+				}
+				scalars[0] = nil
+				if n > 2 {
+					scalars[1] = zero
+					scalars[2] = big.NewInt(-5)
+				}
+				expected = AggregatePoints(append(ScalePoints(points, scalars), g.Mul(zero)))
+				assert.True(t, MultiScalarMul(points, scalars).Equals(expected),
+					curve.Name()+" multi scalar multiplication with short scalars is incorrect")
+			}
+		}
+		zeros := []*big.Int{zero, zero}
+		assert.True(t, MultiScalarMul([]Point{curve.GetG2(), curve.GetG2()}, zeros).Equals(curve.GetG2Infinity()),
+			curve.Name()+" multi scalar multiplication by zero is not infinity")
+		assert.Nil(t, MultiScalarMul([]Point{curve.GetG1()}, zeros))
+
+		// A G1 and a G2 point can't be summed, whichever path the scalars take
+		mixed := []Point{curve.GetG1(), curve.GetG2()}
+		for _, scalars := range [][]*big.Int{{big.NewInt(3), big.NewInt(5)}, {nil, nil}, {big.NewInt(-3), big.NewInt(5)}} {
+			assert.Nil(t, MultiScalarMul(mixed, scalars),
+				curve.Name()+" multi scalar multiplication of G1 and G2 points is not nil")
+		}
+	}
+}
+
+func BenchmarkMultiScalarMul(b *testing.B) {
+	for _, curve := range curves {
+		points := make([]Point, 128)
+		scalars := make([]*big.Int, 128)
+		for i := 0; i < len(points); i++ {
+			k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			points[i] = curve.GetG1().Mul(k)
+			scalars[i], _ = rand.Int(rand.Reader, curve.GetG1Order())
+		}
+		b.Run(curve.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				MultiScalarMul(points, scalars)
+			}
+		})
+		b.Run(curve.Name()+"/separate", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sum := points[0].Mul(scalars[0])
+				for j := 1; j < len(points); j++ {
+					sum, _ = sum.Add(points[j].Mul(scalars[j]))
+				}
+			}
+		})
+	}
+}
+
 func TestPairingProd(t *testing.T) {
-	// TODO: Make upstream libraries include proper product of pairing functionality
 	for _, curve := range curves {
-		numTests := 5
-		for i := 0; i < numTests; i++ {
-			numPoints := 5
+		// The larger sizes exercise splitting the Miller loops across cores
+		for _, numPoints := range []int{1, 5, 9, 40} {
 			points1 := make([]Point, numPoints)
 			points2 := make([]Point, numPoints)
 			prod := curve.GetGTIdentity()
@@ -160,7 +253,23 @@ func TestPairingProd(t *testing.T) {
 			}
 			pairCheck, _ := curve.PairingProduct(points1, points2)
 			assert.True(t, pairCheck.Equals(prod))
+
+			// Adding e(-sum, g2) makes the product the identity
+			sum := AggregatePoints(append([]Point{curve.GetG1Infinity()}, points1...))
+			for j := 0; j < numPoints; j++ {
+				points2[j] = curve.GetG2()
+			}
+			assert.False(t, curve.PairingCheck(points1, points2))
+			negSum := sum.Mul(big.NewInt(-1))
+			assert.True(t, curve.PairingCheck(append(points1, negSum), append(points2, curve.GetG2())),
+				curve.Name()+" pairing check failed")
 		}
+		pairCheck, ok := curve.PairingProduct([]Point{curve.GetG1Infinity(), curve.GetG1()},
+			[]Point{curve.GetG2(), curve.GetG2Infinity()})
+		assert.True(t, ok && pairCheck.Equals(curve.GetGTIdentity()), "pairing with infinity is not the identity")
+		_, ok = curve.PairingProduct([]Point{curve.GetG1()}, []Point{})
+		assert.False(t, ok)
+		assert.False(t, curve.PairingCheck([]Point{curve.GetG2()}, []Point{curve.GetG2()}))
 	}
 }
 
@@ -264,6 +373,97 @@ func generateG1HashVectors(curve CurveSystem) {
 	ioutil.WriteFile("testcases/"+curve.Name()+"G1Hash.dat", output, 0644)
 }
 
+func TestFouqueTibouchi(t *testing.T) {
+	for _, curve := range curves {
+		for i := 0; i < 10; i++ {
+			u, _ := rand.Int(rand.Reader, curve.GetG1Q())
+			pt1, ok1 := sw(curve, u, nil)
+			pt2, ok2 := sw(curve, u, rand.Reader)
+			assert.True(t, ok1 && ok2, curve.Name()+" encoding returned an invalid point")
+			assert.True(t, pt1.Equals(pt2), curve.Name()+" blinded encoding differs from the unblinded one")
+		}
+		dst := []byte("BGLS-TEST-FT")
+		msg := make([]byte, 64)
+		rand.Read(msg)
+		pt := HashToG1FouqueTibouchi(curve, msg, dst)
+		assert.True(t, pt.Equals(HashToG1FouqueTibouchiWithDST(curve, dst)(msg)),
+			curve.Name()+" Fouque-Tibouchi hashing is not deterministic")
+		assert.True(t, pt.Mul(curve.GetG1Order()).Equals(curve.GetG1Infinity()),
+			curve.Name()+" Fouque-Tibouchi hash is not in G1")
+		assert.False(t, pt.Equals(HashToG1FouqueTibouchi(curve, msg, []byte("BGLS-TEST-FT2"))),
+			curve.Name()+" hashes under distinct DSTs are equal")
+	}
+}
+
+func TestHashToG2(t *testing.T) {
+	for _, curve := range curves {
+		for i := 0; i < 10; i++ {
+			msg := make([]byte, 64)
+			rand.Read(msg)
+			pt := curve.HashToG2(msg)
+			assert.True(t, pt.Equals(curve.HashToG2(msg)), curve.Name()+" hashing to G2 is not deterministic")
+			assert.False(t, pt.Equals(curve.GetG2Infinity()), curve.Name()+" hashed to infinity")
+			assert.True(t, pt.Mul(curve.GetG1Order()).Equals(curve.GetG2Infinity()),
+				curve.Name()+" hashed point is not in G2")
+		}
+	}
+}
+
+func TestG2HashVectors(t *testing.T) {
+	for _, curve := range curves {
+		// Says whether or not to generate test vectors
+		generate := false
+		if generate {
+			generateG2HashVectors(curve)
+		}
+		file, err := os.Open("testcases/" + curve.Name() + "G2Hash.dat")
+		if err != nil {
+			t.Error(err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := scanner.Text()
+			s := strings.Split(line, ",")
+			msg, err1 := b64.StdEncoding.DecodeString(s[0])
+			marshalledPt, err2 := b64.StdEncoding.DecodeString(s[1])
+			if err1 != nil || err2 != nil {
+				t.Error("Incorrectly formatted test vector file")
+			}
+			chkPt := curve.HashToG2(msg)
+			pt, ok := curve.UnmarshalG2(marshalledPt)
+			if !ok {
+				t.Error("Error in unmarshalling point")
+			}
+			assert.True(t, pt.Equals(chkPt))
+		}
+
+		if err := scanner.Err(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func generateG2HashVectors(curve CurveSystem) {
+	NumberOfTests := 10
+	msgSize := 64
+	output := make([]byte, 0, NumberOfTests*(msgSize+192))
+	for i := 0; i < NumberOfTests; i++ {
+		msg := make([]byte, msgSize)
+		_, _ = rand.Read(msg)
+		pt := curve.HashToG2(msg)
+		// base64(msg),base64(Uncompressed Marshal of HashToG2(msg))
+		mutativeAppend(&output, []byte(b64.StdEncoding.EncodeToString(msg)))
+		mutativeAppend(&output, []byte(","))
+		mutativeAppend(&output, []byte(b64.StdEncoding.EncodeToString(pt.MarshalUncompressed())))
+		mutativeAppend(&output, []byte("\n"))
+	}
+	// Delete old file it exists
+	os.Remove("testcases/" + curve.Name() + "G2Hash.dat")
+	ioutil.WriteFile("testcases/"+curve.Name()+"G2Hash.dat", output, 0644)
+}
+
 // Mutatively appends msg to s. This is used to avoid having to reallocate more memory for s.
 func mutativeAppend(s *[]byte, msg []byte) {
 	*s = append(*s, msg...)
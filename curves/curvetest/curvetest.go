@@ -0,0 +1,264 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package curvetest checks that an implementation of curves.CurveSystem
+// behaves like the curves in this repository, so that it can be used as a
+// drop in replacement for them in bgls and dkg.
+package curvetest
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+// RunConformance runs the conformance checks against curve, each as a subtest of t.
+func RunConformance(t *testing.T, curve curves.CurveSystem) {
+	t.Run("GroupLaws", func(t *testing.T) { testGroupLaws(t, curve) })
+	t.Run("Scalars", func(t *testing.T) { testScalars(t, curve) })
+	t.Run("Infinity", func(t *testing.T) { testInfinity(t, curve) })
+	t.Run("Encodings", func(t *testing.T) { testEncodings(t, curve) })
+	t.Run("Bilinearity", func(t *testing.T) { testBilinearity(t, curve) })
+	t.Run("PairingProduct", func(t *testing.T) { testPairingProduct(t, curve) })
+	if preparer, ok := curve.(curves.G2Preparer); ok {
+		t.Run("PreparedG2", func(t *testing.T) { testPreparedG2(t, preparer) })
+	}
+	t.Run("Hashing", func(t *testing.T) { testHashing(t, curve) })
+}
+
+// group is G1 or G2 of a curve
+type group struct {
+	name      string
+	generator curves.Point
+	infinity  curves.Point
+	baseMul   func(*big.Int) curves.Point
+	unmarshal func([]byte) (curves.Point, bool)
+	strict    func([]byte) (curves.Point, bool)
+	standard  func([]byte) (curves.Point, bool)
+	make      func([]*big.Int, bool) (curves.Point, bool)
+	hash      func([]byte) curves.Point
+}
+
+func groups(curve curves.CurveSystem) []group {
+	return []group{
+		{"G1", curve.GetG1(), curve.GetG1Infinity(), curve.G1BaseMul, curve.UnmarshalG1, curve.UnmarshalG1Strict,
+			curve.UnmarshalG1CompressedStandard, curve.MakeG1Point, curve.HashToG1},
+		{"G2", curve.GetG2(), curve.GetG2Infinity(), curve.G2BaseMul, curve.UnmarshalG2, curve.UnmarshalG2Strict,
+			curve.UnmarshalG2CompressedStandard, curve.MakeG2Point, curve.HashToG2},
+	}
+}
+
+func randomScalar(t *testing.T, curve curves.CurveSystem) *big.Int {
+	k, err := rand.Int(rand.Reader, curve.GetG1Order())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func add(t *testing.T, a, b curves.Point) curves.Point {
+	sum, ok := a.Add(b)
+	if !ok {
+		t.Fatal("adding two points of the same group failed")
+	}
+	return sum
+}
+
+func testGroupLaws(t *testing.T, curve curves.CurveSystem) {
+	for _, g := range groups(curve) {
+		assert.True(t, g.generator.IsOnCurve() && g.generator.IsInSubgroup(), g.name+" generator is not in the group")
+		for i := 0; i < 4; i++ {
+			a := g.generator.Mul(randomScalar(t, curve))
+			b := g.generator.Mul(randomScalar(t, curve))
+			c := g.generator.Mul(randomScalar(t, curve))
+			assert.True(t, add(t, add(t, a, b), c).Equals(add(t, a, add(t, b, c))), g.name+" addition is not associative")
+			assert.True(t, add(t, a, b).Equals(add(t, b, a)), g.name+" addition is not commutative")
+			assert.True(t, add(t, a, g.infinity).Equals(a), g.name+" infinity is not the identity")
+			assert.True(t, add(t, a, a).Equals(a.Mul(big.NewInt(2))), g.name+" doubling is inconsistent with Mul")
+			assert.True(t, add(t, a, a.Mul(big.NewInt(-1))).IsInfinity(), g.name+" -P + P is not infinity")
+			assert.True(t, a.Copy().Equals(a), g.name+" Copy is not equal")
+			assert.False(t, a.Equals(add(t, a, g.generator)), g.name+" P equals P + generator")
+		}
+		other := groups(curve)[0].generator
+		if g.name == "G1" {
+			other = groups(curve)[1].generator
+		}
+		_, ok := g.generator.Add(other)
+		assert.False(t, ok, g.name+" allowed adding points of different groups")
+	}
+}
+
+func testScalars(t *testing.T, curve curves.CurveSystem) {
+	order := curve.GetG1Order()
+	for _, g := range groups(curve) {
+		assert.True(t, g.generator.Mul(order).IsInfinity(), g.name+" generator does not have order r")
+		assert.True(t, g.generator.Mul(big.NewInt(0)).IsInfinity(), g.name+" 0 * P is not infinity")
+		assert.True(t, g.generator.Mul(big.NewInt(1)).Equals(g.generator), g.name+" 1 * P != P")
+		for i := 0; i < 4; i++ {
+			a, b := randomScalar(t, curve), randomScalar(t, curve)
+			p := g.generator.Mul(randomScalar(t, curve))
+			sum := new(big.Int).Add(a, b)
+			assert.True(t, p.Mul(sum).Equals(add(t, p.Mul(a), p.Mul(b))), g.name+" Mul is not distributive")
+			product := new(big.Int).Mul(a, b)
+			assert.True(t, p.Mul(product).Equals(p.Mul(a).Mul(b)), g.name+" Mul is not associative")
+			negated := new(big.Int).Neg(a)
+			assert.True(t, add(t, p.Mul(negated), p.Mul(a)).IsInfinity(), g.name+" -a * P is not the inverse of a * P")
+			assert.True(t, p.Mul(negated).Equals(p.Mul(new(big.Int).Sub(order, a))), g.name+" -a * P != (r - a) * P")
+			assert.True(t, p.Mul(new(big.Int).Add(a, order)).Equals(p.Mul(a)), g.name+" (a + r) * P != a * P")
+			assert.True(t, g.baseMul(a).Equals(g.generator.Mul(a)), g.name+" base multiplication is inconsistent with Mul")
+			assert.True(t, g.baseMul(negated).Equals(g.generator.Mul(negated)), g.name+" base multiplication mishandles negative scalars")
+		}
+		assert.True(t, g.baseMul(big.NewInt(0)).IsInfinity(), g.name+" base multiplication by 0 is not infinity")
+	}
+}
+
+func testInfinity(t *testing.T, curve curves.CurveSystem) {
+	for _, g := range groups(curve) {
+		assert.True(t, g.infinity.IsInfinity(), g.name+" infinity is not infinity")
+		assert.True(t, g.infinity.IsOnCurve() && g.infinity.IsInSubgroup(), g.name+" infinity is not in the group")
+		assert.False(t, g.generator.IsInfinity(), g.name+" generator is infinity")
+		assert.True(t, add(t, g.infinity, g.infinity).IsInfinity(), g.name+" infinity + infinity is not infinity")
+		assert.True(t, g.infinity.Mul(randomScalar(t, curve)).IsInfinity(), g.name+" k * infinity is not infinity")
+		assert.True(t, g.infinity.Mul(big.NewInt(-1)).IsInfinity(), g.name+" -infinity is not infinity")
+		for _, data := range [][]byte{g.infinity.Marshal(), g.infinity.MarshalUncompressed()} {
+			recovered, ok := g.unmarshal(data)
+			assert.True(t, ok && recovered.IsInfinity(), g.name+" infinity does not round trip")
+		}
+		recovered, ok := g.standard(g.infinity.MarshalCompressedStandard())
+		assert.True(t, ok && recovered.IsInfinity(), g.name+" infinity does not round trip through the standard encoding")
+	}
+	id := curve.GetGTIdentity()
+	for _, pairing := range [][2]curves.Point{{curve.GetG1Infinity(), curve.GetG2()}, {curve.GetG1(), curve.GetG2Infinity()}} {
+		result, ok := curve.Pair(pairing[0], pairing[1])
+		assert.True(t, ok && result.Equals(id), "a pairing with infinity is not the identity")
+	}
+}
+
+func testEncodings(t *testing.T, curve curves.CurveSystem) {
+	for _, g := range groups(curve) {
+		for i := 0; i < 4; i++ {
+			p := g.generator.Mul(randomScalar(t, curve))
+			compressed, uncompressed := p.Marshal(), p.MarshalUncompressed()
+			assert.True(t, len(compressed) < len(uncompressed), g.name+" compressed encoding is not shorter")
+			for _, data := range [][]byte{compressed, uncompressed} {
+				recovered, ok := g.unmarshal(data)
+				assert.True(t, ok && recovered.Equals(p), g.name+" encoding does not round trip")
+				recovered, ok = g.strict(data)
+				assert.True(t, ok && recovered.Equals(p), g.name+" strict decoding rejected a canonical encoding")
+				_, ok = g.unmarshal(data[1:])
+				assert.False(t, ok, g.name+" decoded an encoding of the wrong length")
+			}
+			recovered, ok := g.standard(p.MarshalCompressedStandard())
+			assert.True(t, ok && recovered.Equals(p), g.name+" standard encoding does not round trip")
+			recovered, ok = g.make(p.ToAffineCoords(), true)
+			assert.True(t, ok && recovered.Equals(p), g.name+" affine coordinates do not round trip")
+		}
+	}
+	for i := 0; i < 2; i++ {
+		f, _ := curve.Pair(curve.GetG1().Mul(randomScalar(t, curve)), curve.GetG2())
+		recovered, ok := curve.UnmarshalGT(f.Marshal())
+		assert.True(t, ok && recovered.Equals(f), "GT encoding does not round trip")
+		recovered, ok = curve.UnmarshalGTCompressed(f.MarshalCompressed())
+		assert.True(t, ok && recovered.Equals(f), "GT compression does not round trip")
+		recovered, ok = curve.MakeGTPoint(f.ToAffineCoords())
+		assert.True(t, ok && recovered.Equals(f), "GT coordinates do not round trip")
+	}
+}
+
+func testBilinearity(t *testing.T, curve curves.CurveSystem) {
+	gt, ok := curve.Pair(curve.GetG1(), curve.GetG2())
+	assert.True(t, ok && gt.Equals(curve.GetGT()), "GetGT is not the pairing of the generators")
+	assert.False(t, gt.Equals(curve.GetGTIdentity()), "the pairing is degenerate")
+	assert.True(t, gt.Mul(curve.GetG1Order()).Equals(curve.GetGTIdentity()), "GT does not have order r")
+	for i := 0; i < 3; i++ {
+		a, b := randomScalar(t, curve), randomScalar(t, curve)
+		ab := new(big.Int).Mul(a, b)
+		left, _ := curve.Pair(curve.GetG1().Mul(a), curve.GetG2().Mul(b))
+		assert.True(t, left.Equals(gt.Mul(ab)), "e(aP, bQ) != e(P, Q)^(ab)")
+		right, _ := curve.Pair(curve.GetG1().Mul(ab), curve.GetG2())
+		assert.True(t, left.Equals(right), "e(aP, bQ) != e(abP, Q)")
+
+		p := curve.GetG1().Mul(a)
+		q1, q2 := curve.GetG2().Mul(b), curve.GetG2().Mul(randomScalar(t, curve))
+		sum, _ := curve.Pair(p, add(t, q1, q2))
+		e1, _ := curve.Pair(p, q1)
+		e2, _ := curve.Pair(p, q2)
+		product, _ := e1.Add(e2)
+		assert.True(t, sum.Equals(product), "e(P, Q1 + Q2) != e(P, Q1) e(P, Q2)")
+		identity, _ := e1.Add(e1.Inverse())
+		assert.True(t, identity.Equals(curve.GetGTIdentity()), "f * f^-1 is not the identity in GT")
+	}
+	_, ok = curve.Pair(curve.GetG2(), curve.GetG1())
+	assert.False(t, ok, "Pair accepted arguments in the wrong order")
+}
+
+func testPairingProduct(t *testing.T, curve curves.CurveSystem) {
+	for _, n := range []int{1, 2, 5} {
+		g1s := make([]curves.Point, n)
+		g2s := make([]curves.Point, n)
+		expected := curve.GetGTIdentity()
+		for i := 0; i < n; i++ {
+			g1s[i] = curve.GetG1().Mul(randomScalar(t, curve))
+			g2s[i] = curve.GetG2().Mul(randomScalar(t, curve))
+			e, _ := curve.Pair(g1s[i], g2s[i])
+			expected, _ = expected.Add(e)
+		}
+		product, ok := curve.PairingProduct(g1s, g2s)
+		assert.True(t, ok && product.Equals(expected), "PairingProduct is not the product of Pair")
+		assert.False(t, curve.PairingCheck(g1s, g2s), "PairingCheck accepted a product which isn't one")
+	}
+	a := randomScalar(t, curve)
+	g1s := []curves.Point{curve.GetG1().Mul(a), curve.GetG1().Mul(new(big.Int).Neg(a))}
+	g2s := []curves.Point{curve.GetG2(), curve.GetG2()}
+	assert.True(t, curve.PairingCheck(g1s, g2s), "PairingCheck rejected e(aP, Q) e(-aP, Q)")
+	_, ok := curve.PairingProduct(g1s, g2s[:1])
+	assert.False(t, ok, "PairingProduct accepted slices of different lengths")
+}
+
+func testPreparedG2(t *testing.T, curve curves.G2Preparer) {
+	g2s := []curves.Point{curve.GetG2().Mul(randomScalar(t, curve)), curve.GetG2Infinity(), curve.GetG2()}
+	prepared := make([]curves.PreparedG2, len(g2s))
+	for i, g2 := range g2s {
+		var ok bool
+		prepared[i], ok = curve.PrepareG2(g2)
+		assert.True(t, ok, "PrepareG2 rejected a point of G2")
+		assert.True(t, prepared[i].G2().Equals(g2), "PreparedG2 doesn't return its point")
+	}
+	_, ok := curve.PrepareG2(curve.GetG1())
+	assert.False(t, ok, "PrepareG2 accepted a point of G1")
+
+	// The prepared points are reused with different points of G1
+	for j := 0; j < 2; j++ {
+		g1s := []curves.Point{curve.GetG1().Mul(randomScalar(t, curve)), curve.GetG1(), curve.GetG1Infinity()}
+		expected, _ := curve.PairingProduct(g1s, g2s)
+		product, ok := curve.PairingProductPrepared(g1s, prepared)
+		assert.True(t, ok && product.Equals(expected), "PairingProductPrepared differs from PairingProduct")
+		assert.False(t, curve.PairingCheckPrepared(g1s, prepared), "PairingCheckPrepared accepted a product which isn't one")
+	}
+	a := randomScalar(t, curve)
+	g1s := []curves.Point{curve.GetG1().Mul(a), curve.GetG1().Mul(new(big.Int).Neg(a))}
+	assert.True(t, curve.PairingCheckPrepared(g1s, []curves.PreparedG2{prepared[2], prepared[2]}),
+		"PairingCheckPrepared rejected e(aP, Q) e(-aP, Q)")
+	_, ok = curve.PairingProductPrepared(g1s, prepared[:1])
+	assert.False(t, ok, "PairingProductPrepared accepted slices of different lengths")
+}
+
+func testHashing(t *testing.T, curve curves.CurveSystem) {
+	for _, g := range groups(curve) {
+		msg := make([]byte, 32)
+		for i := 0; i < 4; i++ {
+			if _, err := rand.Read(msg); err != nil {
+				t.Fatal(err)
+			}
+			h := g.hash(msg)
+			assert.True(t, h.Equals(g.hash(append([]byte{}, msg...))), g.name+" hashing is not deterministic")
+			assert.True(t, h.IsOnCurve() && h.IsInSubgroup(), g.name+" hash is not in the group")
+			assert.False(t, h.IsInfinity(), g.name+" hash is infinity")
+			assert.False(t, h.Equals(g.hash(append(msg, 0))), g.name+" distinct messages hash to the same point")
+		}
+		assert.True(t, g.hash(nil).Equals(g.hash([]byte{})), g.name+" nil and empty messages hash differently")
+	}
+}
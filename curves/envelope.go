@@ -0,0 +1,176 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+)
+
+// An envelope is a self describing encoding of a point, a GT element or a
+// scalar, which records the curve it belongs to. It consists of
+//
+//	version (1 byte) || curve ID (2 bytes, big endian) || kind (1 byte) || payload
+//
+// The payload of a point is its canonical compressed encoding, from Marshal,
+// and it is decoded strictly. The payload of a GT element is its Marshal
+// encoding, and the payload of a scalar is Scalar.Bytes. Public keys and
+// signatures are points, and secret keys are scalars.
+const envelopeVersion = 1
+const envelopeHeaderLen = 4
+
+// EnvelopeKind says which group an enveloped value belongs to.
+type EnvelopeKind byte
+
+const (
+	KindG1 EnvelopeKind = iota + 1
+	KindG2
+	KindGT
+	KindScalar
+)
+
+// Envelope is a decoded envelope. Exactly one of Point, PointT and Scalar is
+// set, depending on Kind.
+type Envelope struct {
+	Curve  CurveSystem
+	Kind   EnvelopeKind
+	Point  Point
+	PointT PointT
+	Scalar *Scalar
+}
+
+var errInvalidEnvelope = errors.New("invalid envelope")
+var errUnknownGroup = errors.New("the value is not in a group of a registered curve")
+var errCurveMismatch = errors.New("the envelopes belong to different curves")
+
+// MarshalPointEnvelope returns the envelope of a G1 or G2 point. The points of
+// this package know the curve instance they were made on, and an error is
+// returned unless that instance is registered. So a point of NewAltbn128 with a
+// different backend can't be mistaken for a point of Altbn128. For other
+// points, the curve is the one registered curve whose points have the type of
+// pt, and an error is returned if there isn't exactly one.
+func MarshalPointEnvelope(pt Point) ([]byte, error) {
+	curve, ok := curveOf(pt, func(c CurveSystem) []interface{} {
+		return []interface{}{c.GetG1(), c.GetG2()}
+	})
+	if !ok {
+		return nil, errUnknownGroup
+	}
+	if reflect.TypeOf(curve.GetG1()) == reflect.TypeOf(pt) {
+		return envelope(curve, KindG1, pt.Marshal())
+	}
+	return envelope(curve, KindG2, pt.Marshal())
+}
+
+// MarshalGTEnvelope returns the envelope of an element of GT. The curve is
+// found as in MarshalPointEnvelope.
+func MarshalGTEnvelope(pt PointT) ([]byte, error) {
+	curve, ok := curveOf(pt, func(c CurveSystem) []interface{} {
+		return []interface{}{c.GetGT()}
+	})
+	if !ok {
+		return nil, errUnknownGroup
+	}
+	return envelope(curve, KindGT, pt.Marshal())
+}
+
+// curvePoint is implemented by the points and GT elements of this package.
+type curvePoint interface {
+	// pointCurve returns the curve instance the value was made on.
+	pointCurve() CurveSystem
+}
+
+// curveOf returns the curve of v, which is either its own curve, or the only
+// registered curve with a value of the same type in groups(curve).
+func curveOf(v interface{}, groups func(CurveSystem) []interface{}) (CurveSystem, bool) {
+	if v == nil {
+		return nil, false
+	}
+	if cp, ok := v.(curvePoint); ok {
+		return cp.pointCurve(), true
+	}
+	var found CurveSystem
+	for _, curve := range RegisteredCurves() {
+		for _, g := range groups(curve) {
+			if reflect.TypeOf(g) == reflect.TypeOf(v) {
+				if found != nil && found != curve {
+					return nil, false
+				}
+				found = curve
+			}
+		}
+	}
+	return found, found != nil
+}
+
+// MarshalScalarEnvelope returns the envelope of a scalar of curve, such as a
+// secret key.
+func MarshalScalarEnvelope(curve CurveSystem, s *Scalar) ([]byte, error) {
+	if s.e.f.modulus.Cmp(curve.GetG1Order()) != 0 {
+		return nil, errUnknownGroup
+	}
+	return envelope(curve, KindScalar, s.Bytes())
+}
+
+func envelope(curve CurveSystem, kind EnvelopeKind, payload []byte) ([]byte, error) {
+	id, ok := GetCurveID(curve)
+	if !ok {
+		return nil, errUnknownCurve
+	}
+	data := make([]byte, envelopeHeaderLen, envelopeHeaderLen+len(payload))
+	data[0] = envelopeVersion
+	binary.BigEndian.PutUint16(data[1:3], uint16(id))
+	data[3] = byte(kind)
+	return append(data, payload...), nil
+}
+
+// UnmarshalEnvelope decodes an envelope on any registered curve.
+func UnmarshalEnvelope(data []byte) (*Envelope, error) {
+	if len(data) < envelopeHeaderLen || data[0] != envelopeVersion {
+		return nil, errInvalidEnvelope
+	}
+	curve, ok := LookupID(CurveID(binary.BigEndian.Uint16(data[1:3])))
+	if !ok {
+		return nil, errUnknownCurve
+	}
+	e := &Envelope{Curve: curve, Kind: EnvelopeKind(data[3])}
+	payload := data[envelopeHeaderLen:]
+	switch e.Kind {
+	case KindG1:
+		e.Point, ok = curve.UnmarshalG1Strict(payload)
+	case KindG2:
+		e.Point, ok = curve.UnmarshalG2Strict(payload)
+	case KindGT:
+		e.PointT, ok = curve.UnmarshalGT(payload)
+	case KindScalar:
+		e.Scalar, ok = ScalarFromBytes(curve, payload)
+	default:
+		ok = false
+	}
+	if !ok {
+		return nil, errInvalidEnvelope
+	}
+	return e, nil
+}
+
+// UnmarshalEnvelopes decodes several envelopes, and returns an error unless
+// they all belong to the same curve, which is returned.
+func UnmarshalEnvelopes(data [][]byte) (CurveSystem, []*Envelope, error) {
+	envelopes := make([]*Envelope, len(data))
+	var curve CurveSystem
+	for i := range data {
+		e, err := UnmarshalEnvelope(data[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if curve == nil {
+			curve = e.Curve
+		} else if curve != e.Curve {
+			return nil, nil, errCurveMismatch
+		}
+		envelopes[i] = e
+	}
+	return curve, envelopes, nil
+}
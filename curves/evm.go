@@ -0,0 +1,82 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// The EVM precompiles for altbn128 (EIP-196 and EIP-197) take points as big
+// endian 32 byte words. A G1 point is the uint256[2] (x, y), and a G2 point is
+// the uint256[4] (x_i, x_r, y_i, y_r), where X = x_i * i + x_r. This puts the
+// imaginary part of each coordinate first, as in ToAffineCoords. The point at
+// infinity is encoded as all zeros in both groups.
+
+// AltbnEncodeG1 returns the 64 byte EIP-196 encoding of an altbn128 G1 point.
+// It returns false if pt isn't an altbn128 G1 point.
+func AltbnEncodeG1(pt Point) ([]byte, bool) {
+	if g1Point, ok := pt.(*altbn128Point1); ok {
+		return g1Point.point.bytes(), true
+	}
+	return nil, false
+}
+
+// AltbnEncodeG2 returns the 128 byte EIP-197 encoding of an altbn128 G2 point.
+// It returns false if pt isn't an altbn128 G2 point.
+func AltbnEncodeG2(pt Point) ([]byte, bool) {
+	if g2Point, ok := pt.(*altbn128Point2); ok {
+		return g2Point.point.bytes(), true
+	}
+	return nil, false
+}
+
+// AltbnDecodeG1 decodes a 64 byte EIP-196 encoding. Like the precompiles, it
+// rejects coordinates which aren't less than q, and points which aren't on the curve.
+func AltbnDecodeG1(data []byte) (Point, bool) {
+	if len(data) != 64 {
+		return nil, false
+	}
+	return Altbn128.MakeG1Point(evmWords(data), true)
+}
+
+// AltbnDecodeG2 decodes a 128 byte EIP-197 encoding. Like the pairing
+// precompile, it rejects coordinates which aren't less than q, and points
+// which aren't in G2.
+func AltbnDecodeG2(data []byte) (Point, bool) {
+	if len(data) != 128 {
+		return nil, false
+	}
+	return Altbn128.MakeG2Point(evmWords(data), true)
+}
+
+// AltbnPairingCalldata returns the input to the ecPairing precompile at address
+// 0x08, which checks that the product of e(g1Points[i], g2Points[i]) is one.
+// Each pair is encoded as 192 bytes, the G1 point followed by the G2 point.
+// It returns false if the slices differ in length, or contain points which
+// aren't altbn128 points of the right group.
+func AltbnPairingCalldata(g1Points []Point, g2Points []Point) ([]byte, bool) {
+	if len(g1Points) != len(g2Points) {
+		return nil, false
+	}
+	calldata := make([]byte, 0, 192*len(g1Points))
+	for i := 0; i < len(g1Points); i++ {
+		g1, ok1 := AltbnEncodeG1(g1Points[i])
+		g2, ok2 := AltbnEncodeG2(g2Points[i])
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		calldata = append(calldata, g1...)
+		calldata = append(calldata, g2...)
+	}
+	return calldata, true
+}
+
+// evmWords splits data into big endian 32 byte words.
+func evmWords(data []byte) []*big.Int {
+	words := make([]*big.Int, len(data)/32)
+	for i := range words {
+		words[i] = new(big.Int).SetBytes(data[32*i : 32*(i+1)])
+	}
+	return words
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvmEncoding(t *testing.T) {
+	// The generators, as given in EIP-197
+	g1, _ := AltbnEncodeG1(Altbn128.GetG1())
+	assert.Equal(t, append(pad32Bytes(one.Bytes()), pad32Bytes(two.Bytes())...), g1)
+	g2, _ := AltbnEncodeG2(Altbn128.GetG2())
+	for i, c := range Altbn128.GetG2().ToAffineCoords() {
+		assert.Equal(t, pad32Bytes(c.Bytes()), g2[32*i:32*(i+1)], "G2 words are out of order")
+	}
+	inf1, _ := AltbnEncodeG1(Altbn128.GetG1Infinity())
+	inf2, _ := AltbnEncodeG2(Altbn128.GetG2Infinity())
+	assert.Equal(t, make([]byte, 64), inf1)
+	assert.Equal(t, make([]byte, 128), inf2)
+
+	for i := 0; i < 4; i++ {
+		k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+		pt1, pt2 := Altbn128.GetG1().Mul(k), Altbn128.GetG2().Mul(k)
+		data1, ok1 := AltbnEncodeG1(pt1)
+		data2, ok2 := AltbnEncodeG2(pt2)
+		assert.True(t, ok1 && ok2)
+		dec1, ok1 := AltbnDecodeG1(data1)
+		dec2, ok2 := AltbnDecodeG2(data2)
+		assert.True(t, ok1 && dec1.Equals(pt1), "G1 encoding does not round trip")
+		assert.True(t, ok2 && dec2.Equals(pt2), "G2 encoding does not round trip")
+	}
+	for _, data := range [][]byte{inf1, inf2} {
+		_, ok := AltbnDecodeG1(data)
+		assert.Equal(t, len(data) == 64, ok)
+		_, ok = AltbnDecodeG2(data)
+		assert.Equal(t, len(data) == 128, ok)
+	}
+
+	// Coordinates must be less than q, and points must be on the curve
+	bad := append(pad32Bytes(new(big.Int).Add(altbnG1Q, one).Bytes()), g1[32:]...)
+	_, ok := AltbnDecodeG1(bad)
+	assert.False(t, ok, "decoded x = q + 1")
+	g1[63]++
+	_, ok = AltbnDecodeG1(g1)
+	assert.False(t, ok, "decoded a point off the curve")
+	_, ok = AltbnEncodeG1(Bls12381.GetG1())
+	assert.False(t, ok, "encoded a bls12381 point")
+	_, ok = AltbnEncodeG2(Altbn128.GetG1())
+	assert.False(t, ok, "encoded a G1 point as G2")
+
+	calldata, ok := AltbnPairingCalldata([]Point{Altbn128.GetG1()}, []Point{Altbn128.GetG2()})
+	assert.True(t, ok)
+	assert.Equal(t, append(pad32Bytes(one.Bytes()), pad32Bytes(two.Bytes())...), calldata[:64])
+	assert.Equal(t, g2, calldata[64:])
+	_, ok = AltbnPairingCalldata([]Point{Altbn128.GetG1()}, nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,124 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Executor runs tasks on a bounded number of goroutines. The bound is shared by
+// every call to Run, so that many concurrent callers can't use more than the
+// configured parallelism between them. When every slot is taken, the calling
+// goroutine runs the task itself instead of waiting. This means that nested
+// calls, such as an aggregation inside a parallel verification, can't deadlock.
+type Executor struct {
+	slots chan struct{}
+}
+
+// NewExecutor returns an executor that runs at most parallelism tasks on
+// goroutines of its own at once. If parallelism is not positive, the number
+// of CPUs is used.
+func NewExecutor(parallelism int) *Executor {
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+	return &Executor{make(chan struct{}, parallelism)}
+}
+
+// Run calls task(i) for every i in [0, n), and waits for all of them to finish.
+// Once a task returns an error, or ctx is done, no further tasks are started.
+// Tasks which are already running are waited for, so no goroutines outlive
+// the call. Run returns the first error returned by a task, or else the error
+// of ctx if it was done before all tasks were started.
+func (e *Executor) Run(ctx context.Context, n int, task func(i int) error) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for i := 0; i < n && runCtx.Err() == nil; i++ {
+		select {
+		case e.slots <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-e.slots
+					wg.Done()
+				}()
+				if runCtx.Err() != nil {
+					return
+				}
+				if err := task(i); err != nil {
+					fail(err)
+				}
+			}(i)
+		default:
+			if err := task(i); err != nil {
+				fail(err)
+			}
+		}
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+var defaultExecutor = NewExecutor(0)
+var defaultExecutorMu sync.RWMutex
+
+// SetParallelism sets the number of goroutines which are shared by all
+// of the parallel operations in this package. If n is not positive, the number
+// of CPUs is used. Calls which are already running keep their previous bound.
+func SetParallelism(n int) {
+	defaultExecutorMu.Lock()
+	defaultExecutor = NewExecutor(n)
+	defaultExecutorMu.Unlock()
+}
+
+// Parallelize runs task(i) for every i in [0, n) on the executor shared by this
+// package, with the semantics of Executor.Run.
+func Parallelize(ctx context.Context, n int, task func(i int) error) error {
+	defaultExecutorMu.RLock()
+	e := defaultExecutor
+	defaultExecutorMu.RUnlock()
+	return e.Run(ctx, n, task)
+}
+
+// chunks splits [0, n) into at most numChunks contiguous ranges of equal size,
+// each with at least minSize elements when n allows it.
+func chunks(n int, numChunks int, minSize int) [][2]int {
+	if numChunks > n/minSize {
+		numChunks = n / minSize
+	}
+	if numChunks < 1 {
+		numChunks = 1
+	}
+	size := (n + numChunks - 1) / numChunks
+	ranges := make([][2]int, 0, numChunks)
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// parallelism returns the parallelism of the shared executor.
+func parallelism() int {
+	defaultExecutorMu.RLock()
+	defer defaultExecutorMu.RUnlock()
+	return cap(defaultExecutor.slots)
+}
@@ -0,0 +1,96 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutorBound(t *testing.T) {
+	e := NewExecutor(3)
+	var running, maxRunning int32
+	// Each caller may also run tasks on its own goroutine, so two callers can
+	// have at most 3 + 2 tasks running at once.
+	done := make(chan error)
+	for c := 0; c < 2; c++ {
+		go func() {
+			done <- e.Run(context.Background(), 50, func(i int) error {
+				cur := atomic.AddInt32(&running, 1)
+				for {
+					prev := atomic.LoadInt32(&maxRunning)
+					if cur <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, cur) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+				return nil
+			})
+		}()
+	}
+	assert.Nil(t, <-done)
+	assert.Nil(t, <-done)
+	assert.True(t, maxRunning <= 5, "executor exceeded its parallelism")
+}
+
+func TestExecutorErrorsAndCancellation(t *testing.T) {
+	e := NewExecutor(4)
+	errTask := errors.New("task failed")
+	var started, running int32
+	err := e.Run(context.Background(), 1000, func(i int) error {
+		atomic.AddInt32(&started, 1)
+		atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		if i == 10 {
+			return errTask
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	assert.Equal(t, errTask, err)
+	assert.True(t, started < 1000, "tasks were started after an error")
+	// Every goroutine of the executor holds a slot until it exits
+	assert.Equal(t, int32(0), atomic.LoadInt32(&running), "a task outlived Run")
+	assert.Equal(t, 0, len(e.slots), "executor leaked goroutines")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started = 0
+	err = e.Run(ctx, 1000, func(i int) error {
+		if atomic.AddInt32(&started, 1) == 20 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.True(t, started < 1000, "tasks were started after cancellation")
+	assert.Equal(t, 0, len(e.slots), "executor leaked goroutines")
+}
+
+func TestContextVariants(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, curve := range curves {
+		points := []Point{curve.GetG1(), curve.GetG1()}
+		_, err := AggregatePointsContext(ctx, points)
+		assert.Equal(t, context.Canceled, err)
+		_, err = curve.PairingProductContext(ctx, points, []Point{curve.GetG2(), curve.GetG2()})
+		assert.Equal(t, context.Canceled, err)
+
+		sum, err := AggregatePointsContext(context.Background(), points)
+		assert.Nil(t, err)
+		assert.True(t, sum.Equals(curve.GetG1().Mul(two)))
+		_, err = AggregatePointsContext(context.Background(), []Point{curve.GetG1(), curve.GetG2()})
+		assert.NotNil(t, err, curve.Name()+" aggregated points from different groups")
+		_, err = AggregatePointsContext(context.Background(), nil)
+		assert.NotNil(t, err)
+		_, err = curve.PairingProductContext(context.Background(), points, points)
+		assert.NotNil(t, err, curve.Name()+" paired two G1 points")
+	}
+}
@@ -0,0 +1,583 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"io"
+	"math/big"
+)
+
+// field is a prime field, and fieldElement is an element of it, which is
+// always reduced. fieldElement implements the arithmetic shared by Fp and
+// Scalar. The result is written to z, which may alias the arguments, and it
+// belongs to the field of the first argument, so that the zero value of z can
+// be used. Both arguments must belong to the same field, and add, sub and mul
+// panic if they don't, such as for elements of two different curves.
+type field struct {
+	modulus *big.Int
+	byteLen int
+}
+
+type fieldElement struct {
+	n big.Int
+	f *field
+}
+
+func newField(modulus *big.Int) *field {
+	return &field{modulus, (modulus.BitLen() + 7) / 8}
+}
+
+func (f *field) fromBytes(data []byte) (fieldElement, bool) {
+	var e fieldElement
+	if len(data) != f.byteLen {
+		return e, false
+	}
+	e.n.SetBytes(data)
+	e.f = f
+	return e, e.n.Cmp(f.modulus) < 0
+}
+
+func (z *fieldElement) set(x *fieldElement) {
+	z.n.Set(&x.n)
+	z.f = x.f
+}
+
+// checkSameField panics if x and y belong to different fields.
+func checkSameField(x, y *fieldElement) {
+	if x.f != y.f && x.f.modulus.Cmp(y.f.modulus) != 0 {
+		panic("bgls: field elements with moduli " + x.f.modulus.String() + " and " +
+			y.f.modulus.String() + " can't be combined")
+	}
+}
+
+func (z *fieldElement) add(x, y *fieldElement) {
+	checkSameField(x, y)
+	z.f = x.f
+	z.n.Add(&x.n, &y.n)
+	if z.n.Cmp(z.f.modulus) >= 0 {
+		z.n.Sub(&z.n, z.f.modulus)
+	}
+}
+
+func (z *fieldElement) sub(x, y *fieldElement) {
+	checkSameField(x, y)
+	z.f = x.f
+	z.n.Sub(&x.n, &y.n)
+	if z.n.Sign() < 0 {
+		z.n.Add(&z.n, z.f.modulus)
+	}
+}
+
+func (z *fieldElement) neg(x *fieldElement) {
+	z.f = x.f
+	if x.n.Sign() == 0 {
+		z.n.SetInt64(0)
+	} else {
+		z.n.Sub(z.f.modulus, &x.n)
+	}
+}
+
+func (z *fieldElement) mul(x, y *fieldElement) {
+	checkSameField(x, y)
+	z.f = x.f
+	z.n.Mul(&x.n, &y.n)
+	z.n.Mod(&z.n, z.f.modulus)
+}
+
+// inverse sets z to 1 / x, or to zero if x is zero.
+func (z *fieldElement) inverse(x *fieldElement) {
+	z.f = x.f
+	if x.n.Sign() == 0 {
+		z.n.SetInt64(0)
+		return
+	}
+	z.n.ModInverse(&x.n, z.f.modulus)
+}
+
+// exp sets z to x^e. If e is negative, this is (1 / x)^(-e).
+func (z *fieldElement) exp(x *fieldElement, e *big.Int) {
+	z.f = x.f
+	if e.Sign() < 0 {
+		z.inverse(x)
+		z.n.Exp(&z.n, new(big.Int).Neg(e), z.f.modulus)
+		return
+	}
+	z.n.Exp(&x.n, e, z.f.modulus)
+}
+
+// sqrt sets z to a square root of x, and returns false if x isn't a square,
+// in which case z is unchanged.
+func (z *fieldElement) sqrt(x *fieldElement) bool {
+	root := calcQuadRes(&x.n, x.f.modulus)
+	check := new(big.Int).Mul(root, root)
+	if check.Mod(check, x.f.modulus).Cmp(&x.n) != 0 {
+		return false
+	}
+	z.f = x.f
+	z.n.Set(root)
+	return true
+}
+
+func (x *fieldElement) legendre() int {
+	return big.Jacobi(&x.n, x.f.modulus)
+}
+
+func (x *fieldElement) equals(y *fieldElement) bool {
+	return x.f.modulus.Cmp(y.f.modulus) == 0 && x.n.Cmp(&y.n) == 0
+}
+
+func (x *fieldElement) bytes() []byte {
+	data := make([]byte, x.f.byteLen)
+	x.n.FillBytes(data)
+	return data
+}
+
+// batchInverse inverts every element of xs, with a single field inversion,
+// using Montgomery's trick. Zero elements are mapped to zero.
+func batchInverse(xs []*fieldElement) []fieldElement {
+	result := make([]fieldElement, len(xs))
+	if len(xs) == 0 {
+		return result
+	}
+	// result[i] holds the product of the non-zero elements before i
+	var acc fieldElement
+	acc.f = xs[0].f
+	acc.n.SetInt64(1)
+	for i, x := range xs {
+		result[i].set(&acc)
+		if x.n.Sign() != 0 {
+			acc.mul(&acc, x)
+		}
+	}
+	acc.inverse(&acc)
+	for i := len(xs) - 1; i >= 0; i-- {
+		if xs[i].n.Sign() == 0 {
+			result[i].n.SetInt64(0)
+			continue
+		}
+		result[i].mul(&result[i], &acc)
+		acc.mul(&acc, xs[i])
+	}
+	return result
+}
+
+// Fp is an element of the base field of a curve, whose modulus is GetG1Q.
+// Like big.Int, methods such as z.Add(x, y) set z to the result and return it,
+// and z may be one of the arguments. The zero value of Fp can be used as a result.
+// Add, Sub and Mul panic if x and y are in the base fields of different curves.
+type Fp struct {
+	e fieldElement
+}
+
+// NewFp returns x mod q, in the base field of curve.
+func NewFp(curve CurveSystem, x *big.Int) *Fp {
+	z := &Fp{fieldElement{f: newField(curve.GetG1Q())}}
+	z.e.n.Mod(x, curve.GetG1Q())
+	return z
+}
+
+// FpFromBytes decodes the output of Bytes. It rejects data which has the wrong
+// length, or encodes a value which isn't less than q.
+func FpFromBytes(curve CurveSystem, data []byte) (*Fp, bool) {
+	e, ok := newField(curve.GetG1Q()).fromBytes(data)
+	if !ok {
+		return nil, false
+	}
+	return &Fp{e}, true
+}
+
+func (z *Fp) Set(x *Fp) *Fp {
+	z.e.set(&x.e)
+	return z
+}
+
+func (z *Fp) Add(x, y *Fp) *Fp {
+	z.e.add(&x.e, &y.e)
+	return z
+}
+
+func (z *Fp) Sub(x, y *Fp) *Fp {
+	z.e.sub(&x.e, &y.e)
+	return z
+}
+
+func (z *Fp) Neg(x *Fp) *Fp {
+	z.e.neg(&x.e)
+	return z
+}
+
+func (z *Fp) Mul(x, y *Fp) *Fp {
+	z.e.mul(&x.e, &y.e)
+	return z
+}
+
+func (z *Fp) Square(x *Fp) *Fp {
+	z.e.mul(&x.e, &x.e)
+	return z
+}
+
+// Inverse sets z to 1 / x, or to zero if x is zero.
+func (z *Fp) Inverse(x *Fp) *Fp {
+	z.e.inverse(&x.e)
+	return z
+}
+
+// Exp sets z to x^e, where e may be negative.
+func (z *Fp) Exp(x *Fp, e *big.Int) *Fp {
+	z.e.exp(&x.e, e)
+	return z
+}
+
+// Sqrt sets z to a square root of x. If x isn't a square, it returns false and
+// z is unchanged.
+func (z *Fp) Sqrt(x *Fp) (*Fp, bool) {
+	return z, z.e.sqrt(&x.e)
+}
+
+// Legendre returns the Legendre symbol of x, which is 1 if x is a non-zero
+// square, -1 if x isn't a square, and 0 if x is zero.
+func (x *Fp) Legendre() int {
+	return x.e.legendre()
+}
+
+func (x *Fp) IsZero() bool {
+	return x.e.n.Sign() == 0
+}
+
+func (x *Fp) Equals(y *Fp) bool {
+	return x.e.equals(&y.e)
+}
+
+// Bytes returns the canonical big endian encoding of x, padded to the length of q.
+func (x *Fp) Bytes() []byte {
+	return x.e.bytes()
+}
+
+// BigInt returns x as a new big.Int in [0, q).
+func (x *Fp) BigInt() *big.Int {
+	return new(big.Int).Set(&x.e.n)
+}
+
+// BatchInvertFp returns the inverses of xs, using a single inversion.
+// Zero elements are mapped to zero.
+func BatchInvertFp(xs []*Fp) []*Fp {
+	elements := make([]*fieldElement, len(xs))
+	for i := range xs {
+		elements[i] = &xs[i].e
+	}
+	inverses := batchInverse(elements)
+	result := make([]*Fp, len(xs))
+	for i := range inverses {
+		result[i] = &Fp{inverses[i]}
+	}
+	return result
+}
+
+// Scalar is an element of the scalar field of a curve, whose modulus is the
+// group order, GetG1Order. It has the same methods as Fp, and Add, Sub and Mul
+// panic for scalars of different curves in the same way.
+type Scalar struct {
+	e fieldElement
+}
+
+// NewScalar returns x mod r, in the scalar field of curve.
+func NewScalar(curve CurveSystem, x *big.Int) *Scalar {
+	z := &Scalar{fieldElement{f: newField(curve.GetG1Order())}}
+	z.e.n.Mod(x, curve.GetG1Order())
+	return z
+}
+
+// RandomScalar returns a uniformly random scalar of curve, read from rand.Reader.
+func RandomScalar(curve CurveSystem) (*Scalar, error) {
+	return RandomScalarFromReader(curve, rand.Reader)
+}
+
+// RandomScalarFromReader returns a uniformly random scalar of curve, read from r.
+// With a reader from NewDeterministicReader, the scalar is reproducible.
+func RandomScalarFromReader(curve CurveSystem, r io.Reader) (*Scalar, error) {
+	x, err := rand.Int(r, curve.GetG1Order())
+	if err != nil {
+		return nil, err
+	}
+	return &Scalar{fieldElement{*x, newField(curve.GetG1Order())}}, nil
+}
+
+// ScalarFromBytes decodes the output of Bytes. It rejects data which has the
+// wrong length, or encodes a value which isn't less than r.
+func ScalarFromBytes(curve CurveSystem, data []byte) (*Scalar, bool) {
+	e, ok := newField(curve.GetG1Order()).fromBytes(data)
+	if !ok {
+		return nil, false
+	}
+	return &Scalar{e}, true
+}
+
+func (z *Scalar) Set(x *Scalar) *Scalar {
+	z.e.set(&x.e)
+	return z
+}
+
+func (z *Scalar) Add(x, y *Scalar) *Scalar {
+	z.e.add(&x.e, &y.e)
+	return z
+}
+
+func (z *Scalar) Sub(x, y *Scalar) *Scalar {
+	z.e.sub(&x.e, &y.e)
+	return z
+}
+
+func (z *Scalar) Neg(x *Scalar) *Scalar {
+	z.e.neg(&x.e)
+	return z
+}
+
+func (z *Scalar) Mul(x, y *Scalar) *Scalar {
+	z.e.mul(&x.e, &y.e)
+	return z
+}
+
+func (z *Scalar) Square(x *Scalar) *Scalar {
+	z.e.mul(&x.e, &x.e)
+	return z
+}
+
+// Inverse sets z to 1 / x, or to zero if x is zero.
+func (z *Scalar) Inverse(x *Scalar) *Scalar {
+	z.e.inverse(&x.e)
+	return z
+}
+
+// Exp sets z to x^e, where e may be negative.
+func (z *Scalar) Exp(x *Scalar, e *big.Int) *Scalar {
+	z.e.exp(&x.e, e)
+	return z
+}
+
+// Sqrt sets z to a square root of x. If x isn't a square, it returns false and
+// z is unchanged.
+func (z *Scalar) Sqrt(x *Scalar) (*Scalar, bool) {
+	return z, z.e.sqrt(&x.e)
+}
+
+// Legendre returns the Legendre symbol of x.
+func (x *Scalar) Legendre() int {
+	return x.e.legendre()
+}
+
+func (x *Scalar) IsZero() bool {
+	return x.e.n.Sign() == 0
+}
+
+func (x *Scalar) Equals(y *Scalar) bool {
+	return x.e.equals(&y.e)
+}
+
+// Bytes returns the canonical big endian encoding of x, padded to the length of r.
+func (x *Scalar) Bytes() []byte {
+	return x.e.bytes()
+}
+
+// BigInt returns x as a new big.Int in [0, r).
+func (x *Scalar) BigInt() *big.Int {
+	return new(big.Int).Set(&x.e.n)
+}
+
+// BatchInvertScalars returns the inverses of xs, using a single inversion.
+// Zero elements are mapped to zero.
+func BatchInvertScalars(xs []*Scalar) []*Scalar {
+	elements := make([]*fieldElement, len(xs))
+	for i := range xs {
+		elements[i] = &xs[i].e
+	}
+	inverses := batchInverse(elements)
+	result := make([]*Scalar, len(xs))
+	for i := range inverses {
+		result[i] = &Scalar{inverses[i]}
+	}
+	return result
+}
+
+// Fp2 is an element re + im * i of Fp[i] / (i^2 + 1), the field which G2 is
+// defined over on both curves. It follows the conventions of Fp.
+type Fp2 struct {
+	re, im Fp
+}
+
+// NewFp2 returns re + im * i. Both parts must be in the base field of the same curve.
+func NewFp2(re, im *Fp) *Fp2 {
+	z := new(Fp2)
+	z.re.Set(re)
+	z.im.Set(im)
+	return z
+}
+
+// Fp2FromBytes decodes the output of Bytes.
+func Fp2FromBytes(curve CurveSystem, data []byte) (*Fp2, bool) {
+	f := newField(curve.GetG1Q())
+	if len(data) != 2*f.byteLen {
+		return nil, false
+	}
+	im, okIm := f.fromBytes(data[:f.byteLen])
+	re, okRe := f.fromBytes(data[f.byteLen:])
+	if !okIm || !okRe {
+		return nil, false
+	}
+	return &Fp2{Fp{re}, Fp{im}}, true
+}
+
+// Real returns a copy of the real part of x.
+func (x *Fp2) Real() *Fp {
+	return new(Fp).Set(&x.re)
+}
+
+// Imag returns a copy of the imaginary part of x.
+func (x *Fp2) Imag() *Fp {
+	return new(Fp).Set(&x.im)
+}
+
+func (z *Fp2) Set(x *Fp2) *Fp2 {
+	z.re.Set(&x.re)
+	z.im.Set(&x.im)
+	return z
+}
+
+func (z *Fp2) Add(x, y *Fp2) *Fp2 {
+	z.re.Add(&x.re, &y.re)
+	z.im.Add(&x.im, &y.im)
+	return z
+}
+
+func (z *Fp2) Sub(x, y *Fp2) *Fp2 {
+	z.re.Sub(&x.re, &y.re)
+	z.im.Sub(&x.im, &y.im)
+	return z
+}
+
+func (z *Fp2) Neg(x *Fp2) *Fp2 {
+	z.re.Neg(&x.re)
+	z.im.Neg(&x.im)
+	return z
+}
+
+// Conjugate sets z to re - im * i.
+func (z *Fp2) Conjugate(x *Fp2) *Fp2 {
+	z.re.Set(&x.re)
+	z.im.Neg(&x.im)
+	return z
+}
+
+// Mul sets z to x * y, with Karatsuba multiplication.
+func (z *Fp2) Mul(x, y *Fp2) *Fp2 {
+	var a, b, c, d Fp
+	a.Mul(&x.re, &y.re)
+	b.Mul(&x.im, &y.im)
+	c.Add(&x.re, &x.im)
+	d.Add(&y.re, &y.im)
+	c.Mul(&c, &d)
+	c.Sub(&c, &a)
+	z.im.Sub(&c, &b)
+	z.re.Sub(&a, &b)
+	return z
+}
+
+// Square sets z to x^2 = (re + im)(re - im) + 2 re im * i.
+func (z *Fp2) Square(x *Fp2) *Fp2 {
+	var a, b Fp
+	a.Add(&x.re, &x.im)
+	b.Sub(&x.re, &x.im)
+	z.im.Mul(&x.re, &x.im)
+	z.im.Add(&z.im, &z.im)
+	z.re.Mul(&a, &b)
+	return z
+}
+
+// norm returns re^2 + im^2, which is x times its conjugate.
+func (x *Fp2) norm() *Fp {
+	norm := new(Fp).Square(&x.im)
+	return norm.Add(new(Fp).Square(&x.re), norm)
+}
+
+// Inverse sets z to 1 / x = conj(x) / norm(x), or to zero if x is zero.
+func (z *Fp2) Inverse(x *Fp2) *Fp2 {
+	t := x.norm()
+	t.Inverse(t)
+	z.re.Mul(&x.re, t)
+	z.im.Mul(&x.im, t)
+	z.im.Neg(&z.im)
+	return z
+}
+
+// Exp sets z to x^e, where e may be negative.
+func (z *Fp2) Exp(x *Fp2, e *big.Int) *Fp2 {
+	base := new(Fp2).Set(x)
+	if e.Sign() < 0 {
+		base.Inverse(base)
+		e = new(big.Int).Neg(e)
+	}
+	result := new(Fp2).Set(x)
+	result.re.e.n.SetInt64(1)
+	result.im.e.n.SetInt64(0)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		result.Square(result)
+		if e.Bit(i) == 1 {
+			result.Mul(result, base)
+		}
+	}
+	return z.Set(result)
+}
+
+// Sqrt sets z to a square root of x. If x isn't a square, it returns false and
+// z is unchanged. The root is taken with calcExtQuadResUnscaled, which doesn't
+// depend on q mod 4.
+func (z *Fp2) Sqrt(x *Fp2) (*Fp2, bool) {
+	q := x.re.e.f.modulus
+	root := calcComplexQuadRes(&complexNum{&x.im.e.n, &x.re.e.n}, q)
+	check := getComplexZero().Square(root, q)
+	if check.re.Cmp(&x.re.e.n) != 0 || check.im.Cmp(&x.im.e.n) != 0 {
+		return z, false
+	}
+	z.Set(x)
+	z.re.e.n.Set(root.re)
+	z.im.e.n.Set(root.im)
+	return z, true
+}
+
+// Legendre returns 1 if x is a non-zero square in Fp2, -1 if it isn't a square,
+// and 0 if x is zero. This is the Legendre symbol of the norm of x in Fp.
+func (x *Fp2) Legendre() int {
+	return x.norm().Legendre()
+}
+
+func (x *Fp2) IsZero() bool {
+	return x.re.IsZero() && x.im.IsZero()
+}
+
+func (x *Fp2) Equals(y *Fp2) bool {
+	return x.re.Equals(&y.re) && x.im.Equals(&y.im)
+}
+
+// Bytes returns the imaginary part followed by the real part, which is the
+// order used to serialize G2 points.
+func (x *Fp2) Bytes() []byte {
+	return append(x.im.Bytes(), x.re.Bytes()...)
+}
+
+// BatchInvertFp2 returns the inverses of xs, using a single inversion in Fp.
+// Zero elements are mapped to zero.
+func BatchInvertFp2(xs []*Fp2) []*Fp2 {
+	norms := make([]*Fp, len(xs))
+	for i := range xs {
+		norms[i] = xs[i].norm()
+	}
+	inverses := BatchInvertFp(norms)
+	result := make([]*Fp2, len(xs))
+	for i := range xs {
+		result[i] = new(Fp2).Conjugate(xs[i])
+		result[i].re.Mul(&result[i].re, inverses[i])
+		result[i].im.Mul(&result[i].im, inverses[i])
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomFp(curve CurveSystem) *Fp {
+	x, _ := rand.Int(rand.Reader, curve.GetG1Q())
+	return NewFp(curve, x)
+}
+
+func TestFp(t *testing.T) {
+	for _, curve := range curves {
+		q := curve.GetG1Q()
+		for i := 0; i < 10; i++ {
+			a, b := randomFp(curve), randomFp(curve)
+			expected := new(big.Int).Mul(a.BigInt(), b.BigInt())
+			assert.Equal(t, expected.Mod(expected, q), new(Fp).Mul(a, b).BigInt())
+			expected.Add(a.BigInt(), b.BigInt())
+			assert.Equal(t, expected.Mod(expected, q), new(Fp).Add(a, b).BigInt())
+			expected.Sub(a.BigInt(), b.BigInt())
+			assert.Equal(t, expected.Mod(expected, q), new(Fp).Sub(a, b).BigInt())
+			assert.True(t, new(Fp).Add(a, new(Fp).Neg(a)).IsZero())
+
+			// aliasing the result with an argument
+			c := new(Fp).Set(a)
+			c.Mul(c, c)
+			assert.True(t, c.Equals(new(Fp).Square(a)))
+			assert.True(t, new(Fp).Mul(a, new(Fp).Inverse(a)).Equals(NewFp(curve, one)))
+			assert.True(t, new(Fp).Exp(a, big.NewInt(-3)).Equals(new(Fp).Inverse(new(Fp).Exp(a, three))))
+
+			root, ok := new(Fp).Sqrt(c)
+			assert.True(t, ok && new(Fp).Square(root).Equals(c), curve.Name()+" square root failed")
+			assert.Equal(t, 1, c.Legendre())
+			_, ok = new(Fp).Sqrt(a)
+			assert.Equal(t, a.Legendre() == 1, ok)
+
+			encoded := a.Bytes()
+			assert.Equal(t, (q.BitLen()+7)/8, len(encoded))
+			decoded, ok := FpFromBytes(curve, encoded)
+			assert.True(t, ok && decoded.Equals(a))
+		}
+		assert.True(t, NewFp(curve, big.NewInt(-1)).Equals(NewFp(curve, new(big.Int).Sub(q, one))))
+		assert.True(t, new(Fp).Inverse(NewFp(curve, zero)).IsZero())
+		assert.Equal(t, 0, NewFp(curve, zero).Legendre())
+		_, ok := FpFromBytes(curve, q.Bytes())
+		assert.False(t, ok, curve.Name()+" accepted a non canonical element")
+		_, ok = FpFromBytes(curve, []byte{1})
+		assert.False(t, ok)
+	}
+}
+
+func TestFieldMismatch(t *testing.T) {
+	a, b := NewFp(Altbn128, two), NewFp(Bls12381, two)
+	assert.Panics(t, func() { new(Fp).Add(a, b) })
+	assert.Panics(t, func() { new(Fp).Sub(a, b) })
+	assert.Panics(t, func() { new(Fp).Mul(a, b) })
+	assert.Panics(t, func() { new(Scalar).Mul(NewScalar(Altbn128, two), NewScalar(Bls12381, two)) })
+	// Elements of the same field made separately can be combined
+	assert.True(t, new(Fp).Add(a, NewFp(Altbn128, two)).Equals(NewFp(Altbn128, four)))
+}
+
+func TestFp2(t *testing.T) {
+	for _, curve := range curves {
+		for i := 0; i < 10; i++ {
+			a := NewFp2(randomFp(curve), randomFp(curve))
+			b := NewFp2(randomFp(curve), randomFp(curve))
+			aComplex := &complexNum{a.Imag().BigInt(), a.Real().BigInt()}
+			bComplex := &complexNum{b.Imag().BigInt(), b.Real().BigInt()}
+			product := getComplexZero().Mul(aComplex, bComplex, curve.GetG1Q())
+			c := new(Fp2).Mul(a, b)
+			assert.True(t, c.Real().BigInt().Cmp(product.re) == 0 && c.Imag().BigInt().Cmp(product.im) == 0,
+				curve.Name()+" Fp2 multiplication disagrees with complexNum")
+			assert.True(t, new(Fp2).Square(a).Equals(new(Fp2).Mul(a, a)))
+			assert.True(t, new(Fp2).Mul(a, new(Fp2).Inverse(a)).Equals(NewFp2(NewFp(curve, one), NewFp(curve, zero))))
+			assert.True(t, new(Fp2).Exp(a, three).Equals(new(Fp2).Mul(a, new(Fp2).Square(a))))
+			assert.True(t, new(Fp2).Sub(new(Fp2).Add(a, b), b).Equals(a))
+
+			square := new(Fp2).Square(a)
+			root, ok := new(Fp2).Sqrt(square)
+			assert.True(t, ok && new(Fp2).Square(root).Equals(square), curve.Name()+" Fp2 square root failed")
+			assert.Equal(t, 1, square.Legendre())
+			_, ok = new(Fp2).Sqrt(a)
+			assert.Equal(t, a.Legendre() == 1, ok)
+
+			decoded, ok := Fp2FromBytes(curve, a.Bytes())
+			assert.True(t, ok && decoded.Equals(a))
+		}
+	}
+}
+
+func TestScalarAndBatchInversion(t *testing.T) {
+	for _, curve := range curves {
+		r := curve.GetG1Order()
+		scalars := make([]*Scalar, 8)
+		fps := make([]*Fp, 8)
+		fp2s := make([]*Fp2, 8)
+		for i := range scalars {
+			scalars[i], _ = RandomScalar(curve)
+			fps[i] = randomFp(curve)
+			fp2s[i] = NewFp2(randomFp(curve), randomFp(curve))
+		}
+		scalars[3] = NewScalar(curve, r)
+		fps[5] = NewFp(curve, zero)
+		assert.True(t, scalars[3].IsZero())
+
+		invScalars := BatchInvertScalars(scalars)
+		invFps := BatchInvertFp(fps)
+		invFp2s := BatchInvertFp2(fp2s)
+		for i := range scalars {
+			assert.True(t, invScalars[i].Equals(new(Scalar).Inverse(scalars[i])))
+			assert.True(t, invFps[i].Equals(new(Fp).Inverse(fps[i])))
+			assert.True(t, invFp2s[i].Equals(new(Fp2).Inverse(fp2s[i])))
+		}
+		assert.Equal(t, 0, len(BatchInvertFp(nil)))
+
+		a, b := scalars[0], scalars[1]
+		expected := new(big.Int).Mul(a.BigInt(), b.BigInt())
+		assert.Equal(t, expected.Mod(expected, r), new(Scalar).Mul(a, b).BigInt())
+		p1 := curve.GetG1().Mul(new(Scalar).Add(a, b).BigInt())
+		p2, _ := curve.GetG1().Mul(a.BigInt()).Add(curve.GetG1().Mul(b.BigInt()))
+		assert.True(t, p1.Equals(p2))
+
+		encoded := a.Bytes()
+		assert.Equal(t, 32, len(encoded))
+		decoded, ok := ScalarFromBytes(curve, encoded)
+		assert.True(t, ok && decoded.Equals(a))
+		_, ok = ScalarFromBytes(curve, r.Bytes())
+		assert.False(t, ok, curve.Name()+" accepted a scalar equal to r")
+		// Elements of different fields are never equal
+		assert.False(t, NewScalar(curve, one).e.equals(&NewFp(curve, one).e))
+	}
+}
+
+func BenchmarkFpMul(b *testing.B) {
+	x, y := randomFp(Bls12381), randomFp(Bls12381)
+	var z Fp
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		z.Mul(x, y)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+)
+
+// On G2, the untwist-Frobenius-twist map psi acts as multiplication by
+// 6u^2 = p mod r, so a scalar multiplication k P can be split as
+// k_0 P + k_1 psi(P) + k_2 psi^2(P) + k_3 psi^3(P), where the k_i have about a
+// quarter of the bits of k. The four multiples are computed together, so there
+// are four times fewer doublings. This is GLS, from "Endomorphisms for faster
+// elliptic curve cryptography on a large class of curves" by Galbraith, Lin
+// and Scott, with the decomposition of "Exponentiation in pairing-friendly
+// groups using homomorphisms" by Galbraith and Scott.
+
+// glsLattice is a basis of short vectors v with v_0 + v_1 lambda + ... = 0 mod r.
+type glsLattice struct {
+	basis [][]*big.Int
+	// the first row of the inverse of basis, for Babai's rounding
+	inverse []*big.Rat
+}
+
+func newGLSLattice(basis [][]*big.Int) *glsLattice {
+	n := len(basis)
+	// Solve x basis = (1, 0, ..., 0) by Gaussian elimination on the transpose.
+	m := make([][]*big.Rat, n)
+	for i := range m {
+		m[i] = make([]*big.Rat, n+1)
+		for j := 0; j < n; j++ {
+			m[i][j] = new(big.Rat).SetInt(basis[j][i])
+		}
+		m[i][n] = new(big.Rat)
+	}
+	m[0][n].SetInt64(1)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for m[pivot][col].Sign() == 0 {
+			pivot++
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for i := 0; i < n; i++ {
+			if i == col || m[i][col].Sign() == 0 {
+				continue
+			}
+			factor := new(big.Rat).Quo(m[i][col], m[col][col])
+			for j := col; j <= n; j++ {
+				m[i][j].Sub(m[i][j], new(big.Rat).Mul(factor, m[col][j]))
+			}
+		}
+	}
+	inverse := make([]*big.Rat, n)
+	for i := range inverse {
+		inverse[i] = new(big.Rat).Quo(m[i][n], m[i][i])
+	}
+	return &glsLattice{basis, inverse}
+}
+
+// decompose returns k_i with k = k_0 + k_1 lambda + ... mod r, which is
+// (k, 0, ..., 0) minus the closest lattice vector found by Babai's rounding.
+// The k_i may be negative.
+func (l *glsLattice) decompose(k *big.Int) []*big.Int {
+	n := len(l.basis)
+	ks := make([]*big.Int, n)
+	ks[0] = new(big.Int).Set(k)
+	for i := 1; i < n; i++ {
+		ks[i] = new(big.Int)
+	}
+	for j := 0; j < n; j++ {
+		c := new(big.Rat).Mul(new(big.Rat).SetInt(k), l.inverse[j])
+		// round(c) = floor((2 num + den) / (2 den)), as den > 0
+		rounded := new(big.Int).Lsh(c.Num(), 1)
+		rounded.Add(rounded, c.Denom())
+		rounded.Div(rounded, new(big.Int).Lsh(c.Denom(), 1))
+		for i := 0; i < n; i++ {
+			ks[i].Sub(ks[i], new(big.Int).Mul(rounded, l.basis[j][i]))
+		}
+	}
+	return ks
+}
+
+// altbnG2Lattice is the lattice for psi, whose eigenvalue on G2 is 6u^2. This
+// is the basis for BN curves from Galbraith and Scott, whose entries have 64 bits.
+var altbnG2Lattice = func() *glsLattice {
+	coeffs := [4][4][2]int64{
+		{{1, 1}, {1, 0}, {1, 0}, {-2, 0}},
+		{{2, 1}, {-1, 0}, {-1, -1}, {-1, 0}},
+		{{2, 0}, {2, 1}, {2, 1}, {2, 1}},
+		{{1, -1}, {4, 2}, {-2, 1}, {1, -1}},
+	}
+	basis := make([][]*big.Int, 4)
+	for i := range basis {
+		basis[i] = make([]*big.Int, 4)
+		for j := range basis[i] {
+			// coeffs[i][j] = {a, b} is a u + b
+			basis[i][j] = new(big.Int).Mul(altbnU, big.NewInt(coeffs[i][j][0]))
+			basis[i][j].Add(basis[i][j], big.NewInt(coeffs[i][j][1]))
+		}
+	}
+	return newGLSLattice(basis)
+}()
+
+// neg returns -P = (X, -Y, Z).
+func (pt *altbnTwistPoint) neg() *altbnTwistPoint {
+	return &altbnTwistPoint{pt.x, getComplexZero().Sub(getComplexZero(), pt.y, altbnG1Q), pt.z}
+}
+
+// mulGLS is mul for points in G2, with the GLS decomposition of scalar mod r.
+// The sums of every subset of P, psi(P), psi^2(P) and psi^3(P) are
+// precomputed, so each bit position of the k_i takes one doubling and at most
+// one addition. It gives wrong results for points outside of G2, where psi
+// isn't multiplication by 6u^2, so it can't be used to check membership.
+func (pt *altbnTwistPoint) mulGLS(scalar *big.Int) *altbnTwistPoint {
+	ks := altbnG2Lattice.decompose(new(big.Int).Mod(scalar, altbnG1Order))
+	var pts [4]*altbnTwistPoint
+	p := pt
+	maxLen := 0
+	for i, k := range ks {
+		if i > 0 {
+			p = p.psi()
+		}
+		pts[i] = p
+		if k.Sign() < 0 {
+			k.Neg(k)
+			pts[i] = p.neg()
+		}
+		if k.BitLen() > maxLen {
+			maxLen = k.BitLen()
+		}
+	}
+	// table[j] is the sum of pts[i] for the bits i set in j
+	var table [16]*altbnTwistPoint
+	table[0] = getAltbnTwistInfinity()
+	for i := range pts {
+		bit := 1 << uint(i)
+		for j := 0; j < bit; j++ {
+			table[bit+j] = table[j].add(pts[i])
+		}
+	}
+	result := getAltbnTwistInfinity()
+	for b := maxLen - 1; b >= 0; b-- {
+		result = result.double()
+		digit := 0
+		for i, k := range ks {
+			digit |= int(k.Bit(b)) << uint(i)
+		}
+		if digit != 0 {
+			result = result.add(table[digit])
+		}
+	}
+	return result
+}
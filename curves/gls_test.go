@@ -0,0 +1,80 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGLSDecomposition(t *testing.T) {
+	lambda := new(big.Int).Mod(altbnSixUSquared, altbnG1Order)
+	for _, v := range altbnG2Lattice.basis {
+		assert.Zero(t, glsEvaluate(v, lambda).Sign(), "the basis isn't in the lattice")
+	}
+	ks := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(altbnG1Order, one)}
+	for i := 0; i < 20; i++ {
+		k, _ := rand.Int(rand.Reader, altbnG1Order)
+		ks = append(ks, k)
+	}
+	for _, k := range ks {
+		decomposition := altbnG2Lattice.decompose(k)
+		assert.Zero(t, glsEvaluate(decomposition, lambda).Cmp(k), "the decomposition doesn't sum to k")
+		for _, ki := range decomposition {
+			assert.True(t, ki.BitLen() <= 66, "the decomposition isn't short")
+		}
+	}
+}
+
+// glsEvaluate returns the sum of v_i lambda^i mod r
+func glsEvaluate(v []*big.Int, lambda *big.Int) *big.Int {
+	sum, power := new(big.Int), big.NewInt(1)
+	for _, vi := range v {
+		sum.Add(sum, new(big.Int).Mul(vi, power))
+		power.Mul(power, lambda).Mod(power, altbnG1Order)
+	}
+	return sum.Mod(sum, altbnG1Order)
+}
+
+func TestGLSMul(t *testing.T) {
+	pt := NewAltbn128(Bn256Reference).GetG2().(*altbn128Point2).point.(referenceG2).pt
+	ks := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2), altbnG1Order, new(big.Int).Sub(altbnG1Order, one)}
+	for i := 0; i < 3; i++ {
+		k, _ := rand.Int(rand.Reader, altbnG1Order)
+		ks = append(ks, k)
+	}
+	for _, k := range ks {
+		reduced := new(big.Int).Mod(k, altbnG1Order)
+		assert.Equal(t, pt.mul(reduced).toAffineCoords(), pt.mulGLS(k).toAffineCoords(),
+			"GLS differs from double and add")
+	}
+}
+
+// BenchmarkGLS compares GLS with double and add on the twist, and with the G2
+// Mul of each backend. Only the reference backend multiplies with GLS.
+func BenchmarkGLS(b *testing.B) {
+	k, _ := rand.Int(rand.Reader, altbnG1Order)
+	pt := NewAltbn128(Bn256Reference).GetG2().(*altbn128Point2).point.(referenceG2).pt.mul(k)
+	b.Run("twist/mul", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pt.mul(k)
+		}
+	})
+	b.Run("twist/mulGLS", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			pt.mulGLS(k)
+		}
+	})
+	for _, backend := range bn256Backends {
+		g2 := NewAltbn128(backend).GetG2().Mul(k)
+		b.Run(backend.Name()+"/G2/Mul", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				g2.Mul(k)
+			}
+		})
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// gtTower describes the tower Fp12 = Fp6[w] / (w^2 - v), Fp6 = Fp2[v] / (v^3 - xi),
+// Fp2 = Fp[i] / (i^2 + 1), which both curves use for GT. Both underlying libraries
+// serialize g0 + g1 w in Fp12 as g1 followed by g0, c0 + c1 v + c2 v^2 in Fp6 as
+// c2, c1, c0, and elements of Fp2 as the imaginary part followed by the real part.
+type gtTower struct {
+	p       *big.Int
+	xi      *complexNum
+	byteLen int // the length of a serialized element of Fp
+	pad     func([]byte) []byte
+	// mont is only set on altbn128, whose p fits in the 4 limbs of montField.
+	// It is used by the Montgomery form methods below.
+	mont *montField
+}
+
+// fp6 is c[0] + c[1] v + c[2] v^2
+type fp6 [3]*complexNum
+
+var altbnGTTower = &gtTower{altbnG1Q, &complexNum{big.NewInt(1), big.NewInt(9)}, 32, pad32Bytes,
+	newMontField(altbnG1Q)}
+var bls12381GTTower = &gtTower{bls12381G1Q, &complexNum{big.NewInt(1), big.NewInt(1)}, 48, pad48Bytes, nil}
+
+// coords splits a serialized element of Fp12 into its 12 coefficients over Fp.
+// It returns false if data has the wrong length or a coefficient isn't less than p.
+func (t *gtTower) coords(data []byte) ([]*big.Int, bool) {
+	if len(data) != 12*t.byteLen {
+		return nil, false
+	}
+	coords := make([]*big.Int, 12)
+	for i := range coords {
+		coords[i] = new(big.Int).SetBytes(data[i*t.byteLen : (i+1)*t.byteLen])
+		if coords[i].Cmp(t.p) >= 0 {
+			return nil, false
+		}
+	}
+	return coords, true
+}
+
+// fromCoords serializes 12 coefficients over Fp, in the order of coords.
+func (t *gtTower) fromCoords(coords []*big.Int) ([]byte, bool) {
+	if len(coords) != 12 {
+		return nil, false
+	}
+	data := make([]byte, 0, 12*t.byteLen)
+	for _, c := range coords {
+		if c.Sign() < 0 || c.Cmp(t.p) >= 0 {
+			return nil, false
+		}
+		data = append(data, t.pad(c.Bytes())...)
+	}
+	return data, true
+}
+
+func (t *gtTower) fp6FromBytes(data []byte) (fp6, bool) {
+	var a fp6
+	if len(data) != 6*t.byteLen {
+		return a, false
+	}
+	for i := 0; i < 3; i++ {
+		im := new(big.Int).SetBytes(data[2*i*t.byteLen : (2*i+1)*t.byteLen])
+		re := new(big.Int).SetBytes(data[(2*i+1)*t.byteLen : (2*i+2)*t.byteLen])
+		if im.Cmp(t.p) >= 0 || re.Cmp(t.p) >= 0 {
+			return a, false
+		}
+		a[2-i] = &complexNum{im, re}
+	}
+	return a, true
+}
+
+func (t *gtTower) fp6Bytes(a fp6) []byte {
+	data := make([]byte, 0, 6*t.byteLen)
+	for i := 2; i >= 0; i-- {
+		data = append(data, t.pad(a[i].im.Bytes())...)
+		data = append(data, t.pad(a[i].re.Bytes())...)
+	}
+	return data
+}
+
+func (t *gtTower) fp6Add(a, b fp6) fp6 {
+	return fp6{getComplexZero().Add(a[0], b[0], t.p), getComplexZero().Add(a[1], b[1], t.p),
+		getComplexZero().Add(a[2], b[2], t.p)}
+}
+
+func (t *gtTower) fp6Sub(a, b fp6) fp6 {
+	return fp6{getComplexZero().Sub(a[0], b[0], t.p), getComplexZero().Sub(a[1], b[1], t.p),
+		getComplexZero().Sub(a[2], b[2], t.p)}
+}
+
+func (t *gtTower) fp6Mul(a, b fp6) fp6 {
+	var prods [5]*complexNum
+	for i := range prods {
+		prods[i] = getComplexZero()
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			prods[i+j].Add(prods[i+j], getComplexZero().Mul(a[i], b[j], t.p), t.p)
+		}
+	}
+	// v^3 = xi
+	prods[0].Add(prods[0], getComplexZero().Mul(prods[3], t.xi, t.p), t.p)
+	prods[1].Add(prods[1], getComplexZero().Mul(prods[4], t.xi, t.p), t.p)
+	return fp6{prods[0], prods[1], prods[2]}
+}
+
+// fp6Inverse returns a^(-1) = (A + B v + C v^2) / F, where A = a0^2 - xi a1 a2,
+// B = xi a2^2 - a0 a1, C = a1^2 - a0 a2, and F = a0 A + xi (a2 B + a1 C).
+// a must be non-zero.
+func (t *gtTower) fp6Inverse(a fp6) fp6 {
+	mul := func(x, y *complexNum) *complexNum { return getComplexZero().Mul(x, y, t.p) }
+	A := getComplexZero().Sub(mul(a[0], a[0]), mul(t.xi, mul(a[1], a[2])), t.p)
+	B := getComplexZero().Sub(mul(t.xi, mul(a[2], a[2])), mul(a[0], a[1]), t.p)
+	C := getComplexZero().Sub(mul(a[1], a[1]), mul(a[0], a[2]), t.p)
+	F := getComplexZero().Add(mul(a[2], B), mul(a[1], C), t.p)
+	F.Add(mul(a[0], A), mul(t.xi, F), t.p)
+	F.Inverse(F, t.p)
+	return fp6{mul(A, F), mul(B, F), mul(C, F)}
+}
+
+func fp6IsZero(a fp6) bool {
+	return a[0].IsZero() && a[1].IsZero() && a[2].IsZero()
+}
+
+func (t *gtTower) fp6One() fp6 {
+	return fp6{&complexNum{big.NewInt(0), big.NewInt(1)}, getComplexZero(), getComplexZero()}
+}
+
+// fp12Mont is g0 + g1 w in Fp12, with the coefficients in Montgomery form.
+type fp12Mont struct {
+	g0, g1 [3]fp2Mont
+}
+
+// montFromBytes converts a serialized element of Fp12 to Montgomery form. The
+// coefficients must be less than p.
+func (t *gtTower) montFromBytes(data []byte) fp12Mont {
+	var a fp12Mont
+	g1, _ := t.fp6FromBytes(data[:6*t.byteLen])
+	g0, _ := t.fp6FromBytes(data[6*t.byteLen:])
+	for i := 0; i < 3; i++ {
+		a.g0[i] = t.mont.fp2FromComplex(g0[i])
+		a.g1[i] = t.mont.fp2FromComplex(g1[i])
+	}
+	return a
+}
+
+func (t *gtTower) montBytes(a *fp12Mont) []byte {
+	var g0, g1 fp6
+	for i := 0; i < 3; i++ {
+		g0[i] = t.mont.fp2ToComplex(&a.g0[i])
+		g1[i] = t.mont.fp2ToComplex(&a.g1[i])
+	}
+	return append(t.fp6Bytes(g1), t.fp6Bytes(g0)...)
+}
+
+func (t *gtTower) montOne() fp12Mont {
+	var a fp12Mont
+	a.g0[0].re = t.mont.fromBig(one)
+	return a
+}
+
+// montMulByXi sets z = x xi. Since xi = c + i for a small integer c, this is
+// (c re - im) + (re + c im) i, which is computed with additions.
+func (t *gtTower) montMulByXi(z, x *fp2Mont) {
+	f := t.mont
+	cx := *x
+	c := t.xi.re.Uint64()
+	for i := bits.Len64(c) - 2; i >= 0; i-- {
+		f.fp2Add(&cx, &cx, &cx)
+		if c>>uint(i)&1 == 1 {
+			f.fp2Add(&cx, &cx, x)
+		}
+	}
+	re := cx.re
+	f.sub(&re, &re, &x.im)
+	f.add(&z.im, &cx.im, &x.re)
+	z.re = re
+}
+
+// montFp6Mul sets z = a b in Fp6, with Karatsuba's method in 6 multiplications
+// in Fp2.
+func (t *gtTower) montFp6Mul(z, a, b *[3]fp2Mont) {
+	f := t.mont
+	var v0, v1, v2, x, y, c0, c1, c2 fp2Mont
+	f.fp2Mul(&v0, &a[0], &b[0])
+	f.fp2Mul(&v1, &a[1], &b[1])
+	f.fp2Mul(&v2, &a[2], &b[2])
+	// c0 = v0 + xi ((a1 + a2)(b1 + b2) - v1 - v2)
+	f.fp2Add(&x, &a[1], &a[2])
+	f.fp2Add(&y, &b[1], &b[2])
+	f.fp2Mul(&c0, &x, &y)
+	f.fp2Sub(&c0, &c0, &v1)
+	f.fp2Sub(&c0, &c0, &v2)
+	t.montMulByXi(&c0, &c0)
+	f.fp2Add(&c0, &c0, &v0)
+	// c1 = (a0 + a1)(b0 + b1) - v0 - v1 + xi v2
+	f.fp2Add(&x, &a[0], &a[1])
+	f.fp2Add(&y, &b[0], &b[1])
+	f.fp2Mul(&c1, &x, &y)
+	f.fp2Sub(&c1, &c1, &v0)
+	f.fp2Sub(&c1, &c1, &v1)
+	t.montMulByXi(&x, &v2)
+	f.fp2Add(&c1, &c1, &x)
+	// c2 = (a0 + a2)(b0 + b2) - v0 - v2 + v1
+	f.fp2Add(&x, &a[0], &a[2])
+	f.fp2Add(&y, &b[0], &b[2])
+	f.fp2Mul(&c2, &x, &y)
+	f.fp2Sub(&c2, &c2, &v0)
+	f.fp2Sub(&c2, &c2, &v2)
+	f.fp2Add(&c2, &c2, &v1)
+	z[0], z[1], z[2] = c0, c1, c2
+}
+
+// montMul sets z = a b = a0 b0 + a1 b1 v + ((a0 + a1)(b0 + b1) - a0 b0 - a1 b1) w.
+func (t *gtTower) montMul(z, a, b *fp12Mont) {
+	f := t.mont
+	var a0b0, a1b1, x, y, cross [3]fp2Mont
+	t.montFp6Mul(&a0b0, &a.g0, &b.g0)
+	t.montFp6Mul(&a1b1, &a.g1, &b.g1)
+	for i := 0; i < 3; i++ {
+		f.fp2Add(&x[i], &a.g0[i], &a.g1[i])
+		f.fp2Add(&y[i], &b.g0[i], &b.g1[i])
+	}
+	t.montFp6Mul(&cross, &x, &y)
+	for i := 0; i < 3; i++ {
+		f.fp2Sub(&cross[i], &cross[i], &a0b0[i])
+		f.fp2Sub(&cross[i], &cross[i], &a1b1[i])
+	}
+	// v a1b1 = xi c2 + c0 v + c1 v^2
+	var vc fp2Mont
+	t.montMulByXi(&vc, &a1b1[2])
+	f.fp2Add(&z.g0[0], &a0b0[0], &vc)
+	f.fp2Add(&z.g0[1], &a0b0[1], &a1b1[0])
+	f.fp2Add(&z.g0[2], &a0b0[2], &a1b1[1])
+	z.g1 = cross
+}
+
+// montFp6MulByV sets z = a v = xi a2 + a0 v + a1 v^2.
+func (t *gtTower) montFp6MulByV(z, a *[3]fp2Mont) {
+	var c0 fp2Mont
+	t.montMulByXi(&c0, &a[2])
+	z[0], z[1], z[2] = c0, a[0], a[1]
+}
+
+// montSquare sets z = a^2 = (a0^2 + a1^2 v) + 2 a0 a1 w, where the first
+// coefficient is (a0 + a1)(a0 + a1 v) - a0 a1 - a0 a1 v, in 2 multiplications
+// in Fp6.
+func (t *gtTower) montSquare(z, a *fp12Mont) {
+	f := t.mont
+	var prod, vprod, x, y [3]fp2Mont
+	t.montFp6Mul(&prod, &a.g0, &a.g1)
+	t.montFp6MulByV(&y, &a.g1)
+	for i := 0; i < 3; i++ {
+		f.fp2Add(&x[i], &a.g0[i], &a.g1[i])
+		f.fp2Add(&y[i], &y[i], &a.g0[i])
+	}
+	t.montFp6Mul(&x, &x, &y)
+	t.montFp6MulByV(&vprod, &prod)
+	for i := 0; i < 3; i++ {
+		f.fp2Sub(&z.g0[i], &x[i], &prod[i])
+		f.fp2Sub(&z.g0[i], &z.g0[i], &vprod[i])
+		f.fp2Add(&z.g1[i], &prod[i], &prod[i])
+	}
+}
+
+// montFp6MulSparse sets z = a (b0 + b1 v), in 5 multiplications in Fp2.
+func (t *gtTower) montFp6MulSparse(z, a *[3]fp2Mont, b0, b1 *fp2Mont) {
+	f := t.mont
+	var v0, v1, x, y, c0, c1, c2 fp2Mont
+	f.fp2Mul(&v0, &a[0], b0)
+	f.fp2Mul(&v1, &a[1], b1)
+	// c0 = v0 + xi a2 b1
+	f.fp2Mul(&c0, &a[2], b1)
+	t.montMulByXi(&c0, &c0)
+	f.fp2Add(&c0, &c0, &v0)
+	// c1 = (a0 + a1)(b0 + b1) - v0 - v1
+	f.fp2Add(&x, &a[0], &a[1])
+	f.fp2Add(&y, b0, b1)
+	f.fp2Mul(&c1, &x, &y)
+	f.fp2Sub(&c1, &c1, &v0)
+	f.fp2Sub(&c1, &c1, &v1)
+	// c2 = a2 b0 + v1
+	f.fp2Mul(&c2, &a[2], b0)
+	f.fp2Add(&c2, &c2, &v1)
+	z[0], z[1], z[2] = c0, c1, c2
+}
+
+// montMulByLine sets z = a (1 + (b0 + b1 v) w), which is the form of a line of
+// the Miller loop at a point of G1, divided by its constant term. With
+// B = b0 + b1 v, this is (a0 + B a1 v) + (a1 + B a0) w.
+func (t *gtTower) montMulByLine(z, a *fp12Mont, b0, b1 *fp2Mont) {
+	f := t.mont
+	var ba0, ba1 [3]fp2Mont
+	t.montFp6MulSparse(&ba0, &a.g0, b0, b1)
+	t.montFp6MulSparse(&ba1, &a.g1, b0, b1)
+	t.montFp6MulByV(&ba1, &ba1)
+	for i := 0; i < 3; i++ {
+		f.fp2Add(&z.g0[i], &a.g0[i], &ba1[i])
+		f.fp2Add(&z.g1[i], &a.g1[i], &ba0[i])
+	}
+}
+
+// montConjugate sets z = a0 - a1 w, which is the inverse of a in GT.
+func (t *gtTower) montConjugate(z, a *fp12Mont) {
+	var zero fp2Mont
+	z.g0 = a.g0
+	for i := 0; i < 3; i++ {
+		t.mont.fp2Sub(&z.g1[i], &zero, &a.g1[i])
+	}
+}
+
+// cyclotomicSquare sets z = a^2 for a in the cyclotomic subgroup of Fp12, which
+// contains GT. This is the squaring from Granger and Scott, "Faster squaring in
+// the cyclotomic subgroup of sixth degree extensions", which views Fp12 as Fp4^3
+// with Fp4 = Fp2[w^3], and takes 9 squarings in Fp2 instead of the 12
+// multiplications in Fp2 of a generic squaring.
+func (t *gtTower) cyclotomicSquare(z, a *fp12Mont) {
+	f := t.mont
+	// Each pair (x, y) is x + y w^3 in Fp4, and its square is
+	// (x^2 + xi y^2) + 2xy w^3, where 2xy = (x + y)^2 - x^2 - y^2.
+	pairs := [3][2]*fp2Mont{{&a.g0[0], &a.g1[1]}, {&a.g1[0], &a.g0[2]}, {&a.g0[1], &a.g1[2]}}
+	var sq, cross [3]fp2Mont
+	for i, pair := range pairs {
+		var x2, y2 fp2Mont
+		f.fp2Square(&x2, pair[0])
+		f.fp2Square(&y2, pair[1])
+		f.fp2Add(&cross[i], pair[0], pair[1])
+		f.fp2Square(&cross[i], &cross[i])
+		f.fp2Sub(&cross[i], &cross[i], &x2)
+		f.fp2Sub(&cross[i], &cross[i], &y2)
+		t.montMulByXi(&sq[i], &y2)
+		f.fp2Add(&sq[i], &sq[i], &x2)
+	}
+	t.montMulByXi(&cross[2], &cross[2])
+	// c -> 3 s - 2 c for the coefficients of 1, v and v^2, and
+	// c -> 3 s + 2 c for those of w, v w and v^2 w
+	update := func(c, s *fp2Mont, minus bool) {
+		var d fp2Mont
+		if minus {
+			f.fp2Sub(&d, s, c)
+		} else {
+			f.fp2Add(&d, s, c)
+		}
+		f.fp2Add(&d, &d, &d)
+		f.fp2Add(c, &d, s)
+	}
+	*z = *a
+	update(&z.g0[0], &sq[0], true)
+	update(&z.g0[1], &sq[1], true)
+	update(&z.g0[2], &sq[2], true)
+	update(&z.g1[0], &cross[2], false)
+	update(&z.g1[1], &cross[0], false)
+	update(&z.g1[2], &cross[1], false)
+}
+
+// compress maps a serialized element f = g0 + g1 w of GT to c = (1 + g0) / g1 in
+// Fp6, which is half of its size. This is the compression to the algebraic
+// torus T2 from "Compression in finite fields and torus-based cryptography".
+// Since f has order r, f is unitary, so g0^2 - g1^2 v = 1 and f = (c + w) / (c - w).
+// If g1 = 0, then f = 1, and it is compressed to c = 0. This is unambiguous,
+// since c = 0 would decompress to -1, which isn't in GT.
+func (t *gtTower) compress(data []byte) []byte {
+	g1, _ := t.fp6FromBytes(data[:6*t.byteLen])
+	g0, _ := t.fp6FromBytes(data[6*t.byteLen:])
+	if fp6IsZero(g1) {
+		return make([]byte, 6*t.byteLen)
+	}
+	c := t.fp6Mul(t.fp6Add(g0, t.fp6One()), t.fp6Inverse(g1))
+	return t.fp6Bytes(c)
+}
+
+// decompress inverts compress, with f = (c + w) / (c - w)
+// = ((c^2 + v) + 2c w) / (c^2 - v). It doesn't check that f is in GT.
+func (t *gtTower) decompress(data []byte) ([]byte, bool) {
+	c, ok := t.fp6FromBytes(data)
+	if !ok {
+		return nil, false
+	}
+	if fp6IsZero(c) {
+		return append(make([]byte, 12*t.byteLen-1), 1), true
+	}
+	v := fp6{getComplexZero(), &complexNum{big.NewInt(0), big.NewInt(1)}, getComplexZero()}
+	cSquared := t.fp6Mul(c, c)
+	denominator := t.fp6Sub(cSquared, v)
+	if fp6IsZero(denominator) {
+		return nil, false
+	}
+	denominator = t.fp6Inverse(denominator)
+	g0 := t.fp6Mul(t.fp6Add(cSquared, v), denominator)
+	g1 := t.fp6Mul(t.fp6Add(c, c), denominator)
+	return append(t.fp6Bytes(g1), t.fp6Bytes(g0)...), true
+}
+
+// wnaf returns the width w non-adjacent form of k >= 0, least significant digit
+// first. Every non-zero digit is odd and less than 2^(w-1) in absolute value,
+// and at most one of any w consecutive digits is non-zero.
+func wnaf(k *big.Int, w uint) []int {
+	n := new(big.Int).Set(k)
+	mod := 1 << w
+	mask := big.NewInt(int64(mod - 1))
+	digits := make([]int, 0, n.BitLen()+1)
+	for n.Sign() > 0 {
+		d := 0
+		if n.Bit(0) == 1 {
+			d = int(new(big.Int).And(n, mask).Int64())
+			if d >= mod/2 {
+				d -= mod
+			}
+			n.Sub(n, big.NewInt(int64(d)))
+		}
+		digits = append(digits, d)
+		n.Rsh(n, 1)
+	}
+	return digits
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGTEncodings(t *testing.T) {
+	for _, curve := range curves {
+		k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		f, _ := curve.Pair(curve.GetG1().Mul(k), curve.GetG2())
+		for _, pt := range []PointT{f, curve.GetGT(), curve.GetGTIdentity(), f.Inverse()} {
+			coords := pt.ToAffineCoords()
+			assert.Equal(t, 12, len(coords))
+			recovered, ok := curve.MakeGTPoint(coords)
+			assert.True(t, ok && recovered.Equals(pt), curve.Name()+" GT coordinates do not round trip")
+
+			compressed := pt.MarshalCompressed()
+			assert.Equal(t, len(pt.Marshal())/2, len(compressed))
+			recovered, ok = curve.UnmarshalGTCompressed(compressed)
+			assert.True(t, ok && recovered.Equals(pt), curve.Name()+" GT compression does not round trip")
+		}
+
+		// Elements outside of GT are rejected
+		coords := f.ToAffineCoords()
+		coords[11].Add(coords[11], one)
+		_, ok := curve.MakeGTPoint(coords)
+		assert.False(t, ok, curve.Name()+" accepted an element outside of GT")
+		coords[11].Set(curve.GetG1Q())
+		_, ok = curve.MakeGTPoint(coords)
+		assert.False(t, ok, curve.Name()+" accepted a coefficient equal to q")
+		compressed := f.MarshalCompressed()
+		compressed[len(compressed)-1] ^= 1
+		_, ok = curve.UnmarshalGTCompressed(compressed)
+		assert.False(t, ok, curve.Name()+" decompressed an element outside of GT")
+		_, ok = curve.UnmarshalGTCompressed(compressed[1:])
+		assert.False(t, ok)
+	}
+}
+
+func TestGTArithmetic(t *testing.T) {
+	for _, curve := range curves {
+		f, _ := curve.Pair(curve.GetG1().Mul(big.NewInt(7)), curve.GetG2())
+		id := curve.GetGTIdentity()
+		sum, _ := f.Add(f.Inverse())
+		assert.True(t, sum.Equals(id), curve.Name()+" f * f^-1 != 1")
+		assert.True(t, f.Inverse().Inverse().Equals(f))
+		assert.True(t, f.Mul(curve.GetG1Order()).Equals(id))
+		assert.True(t, f.Mul(zero).Equals(id))
+		assert.True(t, f.Copy().Equals(f))
+		for i := 0; i < 5; i++ {
+			a, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			b, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			fa, fb := f.Mul(a), f.Mul(b)
+			prod, _ := fa.Add(fb)
+			assert.True(t, prod.Equals(f.Mul(new(big.Int).Add(a, b))), curve.Name()+" f^a * f^b != f^(a+b)")
+			assert.True(t, f.Mul(new(big.Int).Neg(a)).Equals(fa.Inverse()), curve.Name()+" f^-a != (f^a)^-1")
+			// Exponentiation is consistent with the pairing
+			pa, _ := curve.Pair(curve.GetG1().Mul(a), curve.GetG2().Mul(big.NewInt(7)))
+			assert.True(t, pa.Equals(fa), curve.Name()+" f^a != e(a g1, 7 g2)")
+		}
+	}
+
+	// The Frobenius map raises to the power p, and the exponentiation matches the library's
+	f := Altbn128.GetGT().(altbn128PointT).point
+	assert.Equal(t, f.exp(altbnG1Q).bytes(), Altbn128.gtFrobenius(f).bytes())
+	assert.Equal(t, f.exp(altbnGTLambda).bytes(), Altbn128.gtFrobenius(f).bytes())
+	for i := 0; i < 5; i++ {
+		k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+		assert.Equal(t, f.exp(k).bytes(), Altbn128.gtExp(f, k).bytes())
+	}
+}
+
+func TestCyclotomicSquare(t *testing.T) {
+	tower := altbnGTTower
+	for i := 0; i < 5; i++ {
+		k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+		f := Altbn128.GetGT().Mul(k).(altbn128PointT).point
+		a := tower.montFromBytes(f.bytes())
+		assert.Equal(t, f.bytes(), tower.montBytes(&a), "Montgomery form doesn't round trip")
+		var squared, product, conjugate fp12Mont
+		tower.cyclotomicSquare(&squared, &a)
+		tower.montMul(&product, &a, &a)
+		assert.Equal(t, f.mul(f).bytes(), tower.montBytes(&squared), "wrong cyclotomic square")
+		assert.Equal(t, f.mul(f).bytes(), tower.montBytes(&product), "wrong product")
+		tower.montConjugate(&conjugate, &a)
+		assert.Equal(t, f.inverse().bytes(), tower.montBytes(&conjugate), "wrong conjugate")
+	}
+}
+
+func TestWnaf(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+		digits := wnaf(k, 5)
+		sum := new(big.Int)
+		for j := len(digits) - 1; j >= 0; j-- {
+			sum.Lsh(sum, 1)
+			sum.Add(sum, big.NewInt(int64(digits[j])))
+			if digits[j] != 0 {
+				assert.True(t, digits[j]%2 != 0 && absInt(digits[j]) < 16)
+				for l := 1; l < 5 && j+l < len(digits); l++ {
+					assert.Zero(t, digits[j+l], "adjacent non-zero digits")
+				}
+			}
+		}
+		assert.Zero(t, sum.Cmp(k))
+	}
+}
+
+func BenchmarkGTMul(b *testing.B) {
+	for _, curve := range curves {
+		f := curve.GetGT()
+		k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		b.Run(curve.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				f.Mul(k)
+			}
+		})
+	}
+	f := Altbn128.GetGT().(altbn128PointT).point
+	k, _ := rand.Int(rand.Reader, Altbn128.GetG1Order())
+	b.Run("altbn128-binary", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			f.exp(k)
+		}
+	})
+}
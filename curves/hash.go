@@ -5,6 +5,8 @@ package curves
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
+	"io"
 	"math/big"
 )
 
@@ -13,6 +15,8 @@ var one = big.NewInt(1)
 var two = big.NewInt(2)
 var three = big.NewInt(3)
 var four = big.NewInt(4)
+var five = big.NewInt(5)
+var eight = big.NewInt(8)
 
 // 64 byte hash
 func tryAndIncrement64(message []byte, hashfunc func(message []byte) [64]byte, curve CurveSystem) (px, py *big.Int) {
@@ -83,8 +87,54 @@ func sortBigInts(b1 *big.Int, b2 *big.Int) (*big.Int, *big.Int) {
 	return b1, b2
 }
 
-func fouqueTibouchiG1(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
-	pt, ok := sw(curve, t, blind)
+// HashToG1FouqueTibouchi hashes a message to G1 with the indifferentiable
+// encoding from "Indifferentiable Hashing to Barreto–Naehrig Curves".
+// The message is hashed to two field elements t0, t1 with hash_to_field from
+// RFC 9380 under the given domain separation tag, and the result is
+// FT(t0) + FT(t1), which behaves as a random oracle. The encoding is always
+// blinded, so its quadratic residue tests and square root see the field
+// elements multiplied by random squares. It also doesn't branch on secret
+// values: all three candidate x values are computed, and the choice between
+// them, the quadratic residue tests and the sign of y compare fixed width
+// encodings with crypto/subtle. The arithmetic of math/big isn't constant time
+// itself, which is what the blinding is for.
+// The random squares are read from crypto/rand.Reader.
+func HashToG1FouqueTibouchi(curve CurveSystem, message []byte, dst []byte) Point {
+	return HashToG1FouqueTibouchiFromReader(curve, message, dst, rand.Reader)
+}
+
+// HashToG1FouqueTibouchiFromReader is HashToG1FouqueTibouchi, with the random
+// values of the blinding read from r. They don't change the result, so this is
+// for simulations which mustn't read from crypto/rand. If r fails, the rest of
+// the values are read from crypto/rand.Reader instead.
+func HashToG1FouqueTibouchiFromReader(curve CurveSystem, message []byte, dst []byte, r io.Reader) Point {
+	t := hashToField(curve, message, dst, 2)
+	p0, ok0 := fouqueTibouchiG1(curve, t[0], r)
+	p1, ok1 := fouqueTibouchiG1(curve, t[1], r)
+	if !ok0 || !ok1 {
+		panic("bgls: the Fouque-Tibouchi encoding produced a point which isn't on " + curve.Name())
+	}
+	sum, ok := p0.Add(p1)
+	if !ok {
+		panic("bgls: the Fouque-Tibouchi encodings on " + curve.Name() + " can't be added")
+	}
+	return sum
+}
+
+// HashToG1FouqueTibouchiWithDST returns a function which hashes messages to G1
+// with HashToG1FouqueTibouchi, under the given domain separation tag.
+// This is intended for use with SignCustHash and VerifySingleSignatureCustHash.
+func HashToG1FouqueTibouchiWithDST(curve CurveSystem, dst []byte) func([]byte) Point {
+	dstCopy := append([]byte{}, dst...)
+	return func(message []byte) Point {
+		return HashToG1FouqueTibouchi(curve, message, dstCopy)
+	}
+}
+
+// fouqueTibouchiG1 maps t to G1, blinded with values read from blinding, or
+// without blinding if it is nil.
+func fouqueTibouchiG1(curve CurveSystem, t *big.Int, blinding io.Reader) (Point, bool) {
+	pt, ok := sw(curve, t, blinding)
 	if !ok {
 		return nil, false
 	}
@@ -94,7 +144,8 @@ func fouqueTibouchiG1(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
 
 // Shallue - van de Woestijne encoding
 // from "Indifferentiable Hashing to Barreto–Naehrig Curves"
-func sw(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
+func sw(curve CurveSystem, t *big.Int, blinding io.Reader) (Point, bool) {
+	blind := blinding != nil
 	var x [3]*big.Int
 	b := curve.getG1B()
 	q := curve.GetG1Q()
@@ -105,7 +156,7 @@ func sw(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
 	w.Exp(t, two, q)
 	w.Add(w, one)
 	w.Add(w, b)
-	w.ModInverse(w, q)
+	w = inv0(w.Mod(w, q), q)
 	w.Mul(w, t)
 	w.Mod(w, q)
 	w.Mul(w, rootNeg3)
@@ -126,7 +177,7 @@ func sw(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
 			x[0].Mod(x[0], q)
 
 			// If blinding isn't needed, utilize conditional branches.
-			alpha = chkPoint(x[0], curve, q, blind)
+			alpha = chkPoint(x[0], curve, q, blinding)
 			if !blind && alpha == 1 {
 				break
 			}
@@ -137,7 +188,7 @@ func sw(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
 			x[1].Sub(x[1], one)
 			x[1].Mod(x[1], q)
 
-			beta = chkPoint(x[1], curve, q, blind)
+			beta = chkPoint(x[1], curve, q, blinding)
 			if !blind && beta == 1 {
 				break
 			}
@@ -145,25 +196,43 @@ func sw(curve CurveSystem, t *big.Int, blind bool) (Point, bool) {
 			//x[2] = 1 + 1/w^2
 			x[2] = new(big.Int)
 			x[2].Exp(w, two, q)
-			x[2].ModInverse(x[2], q)
+			x[2] = inv0(x[2], q)
 			x[2].Add(x[2], one)
 			x[2].Mod(x[2], q)
 			break
 		}
 	}
 
-	//i = first x[i] such that (x^3 + b) is square
-	if blind {
-		i = int((((alpha - 1) * beta) + 3) % 3)
+	if !blind {
+		ySqr := curve.g1XToYSquared(x[i])
+		y := calcQuadRes(ySqr, q)
+		if parity(y, q) != parity(t, q) {
+			y.Sub(q, y)
+		}
+		// Check is set to false since its guaranteed to be on the curve
+		return curve.MakeG1Point([]*big.Int{x[i], y}, false)
 	}
 
-	// TODO Add blinded form of this
-	y := calcQuadRes(curve.g1XToYSquared(x[i]), q)
-	if parity(y, q) != parity(t, q) {
-		y.Sub(q, y)
-	}
+	//i = first x[i] such that (x^3 + b) is square, selected without branching
+	i = int((((alpha - 1) * beta) + 3) % 3)
+	size := (q.BitLen() + 7) / 8
+	xi := ctSelect(x[2], x[1], subtle.ConstantTimeEq(int32(i), 1), size)
+	xi = ctSelect(xi, x[0], subtle.ConstantTimeEq(int32(i), 0), size)
+
+	// sqrt(r^2 * ySqr) = +-r * sqrt(ySqr), so the root of the blinded value is
+	// unblinded by dividing by r. The sign is then fixed by the parity of t.
+	ySqr := curve.g1XToYSquared(xi)
+	r := randNonZero(q, blinding)
+	ySqr.Mul(ySqr, new(big.Int).Exp(r, two, q))
+	ySqr.Mod(ySqr, q)
+	y := calcQuadRes(ySqr, q)
+	y.Mul(y, inv0(r, q))
+	y.Mod(y, q)
+	negY := new(big.Int).Sub(q, y)
+	negY.Mod(negY, q)
+	y = ctSelect(y, negY, ctParity(y, q, size)^ctParity(t, q, size), size)
 	// Check is set to false since its guaranteed to be on the curve
-	return curve.MakeG1Point([]*big.Int{x[i], y}, false)
+	return curve.MakeG1Point([]*big.Int{xi, y}, false)
 }
 
 func parity(x *big.Int, q *big.Int) bool {
@@ -171,10 +240,35 @@ func parity(x *big.Int, q *big.Int) bool {
 	return x.Cmp(neg) > 0
 }
 
-// Currently implementing first method from
+// ctParity is parity for x in [0, q), as 1 or 0, comparing the size byte
+// encodings of x and q - x without branching on them.
+func ctParity(x *big.Int, q *big.Int, size int) int {
+	xBytes := x.FillBytes(make([]byte, size))
+	negBytes := new(big.Int).Sub(q, x).FillBytes(make([]byte, size))
+	// The final borrow of neg - x is set if and only if x > neg
+	borrow := 0
+	for j := size - 1; j >= 0; j-- {
+		d := int(negBytes[j]) - int(xBytes[j]) - borrow
+		borrow = (d >> 8) & 1
+	}
+	return borrow
+}
+
+// ctSelect returns b if c is 1, and a if c is 0, copying the size byte
+// encodings of a and b without branching on c. a and b must be in [0, 2^(8 size)).
+func ctSelect(a *big.Int, b *big.Int, c int, size int) *big.Int {
+	res := a.FillBytes(make([]byte, size))
+	subtle.ConstantTimeCopy(c, res, b.FillBytes(make([]byte, size)))
+	return new(big.Int).SetBytes(res)
+}
+
+// calcQuadRes returns a square root of ySqr in Fq, for any odd prime q.
+// If ySqr is not a square, the result is not a root, so callers must check it.
+// For q = 3 mod 4 this uses the first method from
 // http://mathworld.wolfram.com/QuadraticResidue.html
 // Experimentally, this seems to always return the canonical square root,
 // however I haven't seen a proof of this.
+// For q = 5 mod 8 this uses Atkin's method, and otherwise Tonelli-Shanks.
 func calcQuadRes(ySqr *big.Int, q *big.Int) *big.Int {
 	resMod4 := new(big.Int).Mod(q, four)
 	if resMod4.Cmp(three) == 0 {
@@ -185,58 +279,173 @@ func calcQuadRes(ySqr *big.Int, q *big.Int) *big.Int {
 		result.Exp(ySqr, exp, q)
 		return result
 	}
-	// TODO: ADD CODE TO CALC QUADRATIC RESIDUE IN OTHER CASES
-	return zero
+	a := new(big.Int).Mod(ySqr, q)
+	if a.Sign() == 0 {
+		return a
+	}
+	if new(big.Int).Mod(q, eight).Cmp(five) == 0 {
+		return atkinQuadRes(a, q)
+	}
+	return tonelliShanks(a, q)
+}
+
+// atkinQuadRes computes a square root for q = 5 mod 8, as
+// t = (2a)^((q-5)/8), i = 2at^2, root = at(i - 1).
+func atkinQuadRes(a *big.Int, q *big.Int) *big.Int {
+	twoA := new(big.Int).Mul(a, two)
+	twoA.Mod(twoA, q)
+	exp := new(big.Int).Sub(q, five)
+	exp.Rsh(exp, 3)
+	t := new(big.Int).Exp(twoA, exp, q)
+	i := new(big.Int).Mul(t, t)
+	i.Mul(i, twoA)
+	i.Sub(i, one)
+	i.Mod(i, q)
+	result := new(big.Int).Mul(a, t)
+	result.Mul(result, i)
+	return result.Mod(result, q)
 }
 
-// Currently implementing method from Guide to Pairing Based Cryptography, Ch 5 algorithm 18.
-// This in turn is cited from "Gora Adj and Francisco Rodriguez-Henriquez.
-// Square root computation over even extension fields.
-// IEEE Transactions on Computers, 63(11):2829-2841, 2014"
+// tonelliShanks computes a square root of a non-zero a, for any odd prime q.
+// If a is not a square, zero is returned.
+func tonelliShanks(a *big.Int, q *big.Int) *big.Int {
+	// q - 1 = oddPart * 2^s
+	oddPart := new(big.Int).Sub(q, one)
+	s := 0
+	for oddPart.Bit(0) == 0 {
+		oddPart.Rsh(oddPart, 1)
+		s++
+	}
+	z := big.NewInt(2)
+	for isQuadRes(z, q) {
+		z.Add(z, one)
+	}
+
+	m := s
+	c := new(big.Int).Exp(z, oddPart, q)
+	t := new(big.Int).Exp(a, oddPart, q)
+	exp := new(big.Int).Add(oddPart, one)
+	exp.Rsh(exp, 1)
+	result := new(big.Int).Exp(a, exp, q)
+	for t.Cmp(one) != 0 {
+		// find the least i such that t^(2^i) = 1
+		i := 0
+		tPow := new(big.Int).Set(t)
+		for tPow.Cmp(one) != 0 {
+			tPow.Mul(tPow, tPow)
+			tPow.Mod(tPow, q)
+			i++
+			if i == m {
+				return new(big.Int)
+			}
+		}
+		b := new(big.Int).Exp(c, new(big.Int).Lsh(one, uint(m-i-1)), q)
+		m = i
+		c.Mul(b, b)
+		c.Mod(c, q)
+		t.Mul(t, c)
+		t.Mod(t, q)
+		result.Mul(result, b)
+		result.Mod(result, q)
+	}
+	return result
+}
+
+// calcComplexQuadRes returns a square root of ySqr in Fq[i]/(i^2 + 1).
+// If ySqr is not a square, the result is not a root, so callers must check it.
+// complexNum is only a field when -1 is not a square, that is when q = 3 mod 4,
+// but the root itself is taken with calcExtQuadResUnscaled, which works for
+// any odd prime.
 func calcComplexQuadRes(ySqr *complexNum, q *big.Int) *complexNum {
-	result := getComplexZero()
-	if ySqr.im.Cmp(zero) == 0 {
-		result.re = calcQuadRes(ySqr.re, q)
-		return result
+	result, den := calcComplexQuadResUnscaled(ySqr, q)
+	if den.Cmp(one) != 0 {
+		result.im.Mul(result.im, inv0(den, q))
+		result.im.Mod(result.im, q)
+	}
+	return result
+}
+
+// calcComplexQuadResUnscaled is calcComplexQuadRes without its inversion in Fq.
+// The imaginary part of the square root is the returned imaginary part divided
+// by den, so that the inversions of several square roots can be shared.
+func calcComplexQuadResUnscaled(ySqr *complexNum, q *big.Int) (*complexNum, *big.Int) {
+	re, im, den := calcExtQuadResUnscaled(ySqr.re, ySqr.im, new(big.Int).Sub(q, one), q)
+	return &complexNum{im, re}, den
+}
+
+// calcExtQuadResUnscaled computes a square root x0 + x1 * u of a0 + a1 * u in
+// Fq[u]/(u^2 - beta), where beta is not a square in Fq, for any odd prime q.
+// It returns x0, x1 * den and den. This is the method from Guide to Pairing
+// Based Cryptography, Ch 5 algorithm 18, which is cited from "Gora Adj and
+// Francisco Rodriguez-Henriquez. Square root computation over even extension
+// fields. IEEE Transactions on Computers, 63(11):2829-2841, 2014".
+// The norm a0^2 - beta a1^2 is a square in Fq when a0 + a1 * u is a square,
+// and with lambda its root, x0^2 is (a0 + lambda) / 2 or (a0 - lambda) / 2.
+// Then x1 = a1 / (2 x0). All roots in Fq are taken with calcQuadRes.
+func calcExtQuadResUnscaled(a0, a1, beta, q *big.Int) (x0, x1, den *big.Int) {
+	a0 = new(big.Int).Mod(a0, q)
+	a1 = new(big.Int).Mod(a1, q)
+	if a1.Sign() == 0 {
+		if isQuadRes(a0, q) {
+			return calcQuadRes(a0, q), new(big.Int), one
+		}
+		// a0 = beta * b^2 for some b in Fq, so the root is b * u
+		b := new(big.Int).Mul(a0, new(big.Int).ModInverse(beta, q))
+		return new(big.Int), calcQuadRes(b.Mod(b, q), q), one
 	}
-	lambda := new(big.Int).Exp(ySqr.re, two, q)
-	lambda.Add(lambda, new(big.Int).Exp(ySqr.im, two, q))
-	lambda = calcQuadRes(lambda, q)
+	lambda := new(big.Int).Mul(a0, a0)
+	lambda.Sub(lambda, new(big.Int).Mul(beta, new(big.Int).Mul(a1, a1)))
+	lambda = calcQuadRes(lambda.Mod(lambda, q), q)
 	invtwo := new(big.Int).ModInverse(two, q)
-	delta := new(big.Int).Add(ySqr.re, lambda)
-	delta.Mod(delta, q)
+	delta := new(big.Int).Add(a0, lambda)
 	delta.Mul(delta, invtwo)
 	delta.Mod(delta, q)
 	if !isQuadRes(delta, q) {
-		delta = new(big.Int).Sub(ySqr.re, lambda)
+		delta.Sub(a0, lambda)
 		delta.Mul(delta, invtwo)
 		delta.Mod(delta, q)
 	}
-	result.re = calcQuadRes(delta, q)
-	invRe := new(big.Int).ModInverse(result.re, q)
-	result.im.Mul(invRe, invtwo)
-	result.im.Mod(result.im, q)
-	result.im.Mul(result.im, ySqr.im)
-	result.im.Mod(result.im, q)
-	result.re.Mod(result.re, q)
-	return result
+	x0 = calcQuadRes(delta, q)
+	den = new(big.Int).Lsh(x0, 1)
+	return x0, a1, den.Mod(den, q)
+}
+
+//generates a random non-zero member of Fq such that it is a square, read from r.
+//Blinding by zero would make every value look like a square.
+func randSquare(q *big.Int, r io.Reader) *big.Int {
+	x := randNonZero(q, r)
+	return x.Exp(x, two, q)
 }
 
-//generates a random member of Fq such that it is a square
-func randSquare(q *big.Int) *big.Int {
-	var r, _ = rand.Int(rand.Reader, q)
-	return r.Exp(r, two, q)
+//generates a uniformly random non-zero member of Fq, read from r
+func randNonZero(q *big.Int, r io.Reader) *big.Int {
+	x := blindingInt(r, new(big.Int).Sub(q, one))
+	return x.Add(x, one)
 }
 
-// If blind is true, this blinds k with a random square in Fq,
-// and then returns square root. This can be done to limit timing leakage.
+// blindingInt returns a uniformly random value in [0, max), read from r. Blinding
+// doesn't change any results, so if r fails, the value is read from
+// crypto/rand.Reader instead.
+func blindingInt(r io.Reader, max *big.Int) *big.Int {
+	x, err := rand.Int(r, max)
+	if err != nil {
+		if x, err = rand.Int(rand.Reader, max); err != nil {
+			panic("bgls: crypto/rand failed: " + err.Error())
+		}
+	}
+	return x
+}
+
+// If blinding isn't nil, this blinds k with a random square in Fq read from
+// it, and then returns square root. This can be done to limit timing leakage.
 // This returns the quadratic character of k.
-func quadraticCharacter(k *big.Int, q *big.Int, blind bool) int64 {
+func quadraticCharacter(k *big.Int, q *big.Int, blinding io.Reader) int64 {
 	r := k
-	if blind {
-		r = randSquare(q)
+	if blinding != nil {
+		r = randSquare(q, blinding)
 		r.Mul(r, k)
 		r.Mod(r, q)
+		return int64(2*ctIsQuadRes(r, q) - 1)
 	}
 	res := isQuadRes(r, q)
 	if res {
@@ -246,8 +455,8 @@ func quadraticCharacter(k *big.Int, q *big.Int, blind bool) int64 {
 }
 
 //checks that (x^3 + b) is a square in Fq
-func chkPoint(x *big.Int, curve CurveSystem, q *big.Int, mask bool) int64 {
-	return quadraticCharacter(curve.g1XToYSquared(x), q, mask)
+func chkPoint(x *big.Int, curve CurveSystem, q *big.Int, blinding io.Reader) int64 {
+	return quadraticCharacter(curve.g1XToYSquared(x), q, blinding)
 }
 
 // Implement Eulers Criterion
@@ -263,3 +472,14 @@ func isQuadRes(a *big.Int, q *big.Int) bool {
 	}
 	return false
 }
+
+// ctIsQuadRes is isQuadRes for a in [0, q), as 1 or 0, comparing the results
+// of Euler's criterion without branching on them.
+func ctIsQuadRes(a *big.Int, q *big.Int) int {
+	size := (q.BitLen() + 7) / 8
+	e := new(big.Int).Rsh(q, 1)
+	res := new(big.Int).Exp(a, e, q).FillBytes(make([]byte, size))
+	oneBytes := one.FillBytes(make([]byte, size))
+	zeroBytes := make([]byte, size)
+	return subtle.ConstantTimeCompare(res, oneBytes) | subtle.ConstantTimeCompare(res, zeroBytes)
+}
@@ -0,0 +1,340 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+// This file implements hashing to G1 and G2 as specified in RFC 9380,
+// "Hashing to Elliptic Curves". A message is expanded with expand_message_xmd
+// using SHA-256, reduced to two field elements, and each of these is mapped to
+// the curve with the Shallue-van de Woestijne (SVDW) map. The two resulting
+// points are then added together, and the cofactor is cleared. For G2 the
+// field elements are in Fp2, and the map is to the twist of the curve.
+//
+// Every call takes an explicit domain separation tag (DST), so that distinct
+// protocols, or distinct uses within a protocol, hash to independent random
+// oracles. The map follows the straight-line procedure from the RFC, but it is
+// variable time: cmov and isQuadRes branch on their inputs, and math/big is
+// not constant time, so this provides no guarantees against timing side channels.
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// AltbnHashToCurve hashes a message to a point on Altbn128 following the
+// BN254G1_XMD:SHA-256_SVDW_RO_ suite of RFC 9380, with the given domain
+// separation tag. The return value is the x,y affine coordinate pair.
+func AltbnHashToCurve(message []byte, dst []byte) []*big.Int {
+	return hashToCurveSvdw(Altbn128, altbnSvdwParams, message, dst).ToAffineCoords()
+}
+
+// AltbnHashToG1WithDST returns a function which hashes messages to G1 on
+// Altbn128 with AltbnHashToCurve, under the given domain separation tag.
+// This is intended for use with SignCustHash and VerifySingleSignatureCustHash.
+func AltbnHashToG1WithDST(dst []byte) func([]byte) Point {
+	dstCopy := append([]byte{}, dst...)
+	return func(message []byte) Point {
+		return hashToCurveSvdw(Altbn128, altbnSvdwParams, message, dstCopy)
+	}
+}
+
+// AltbnHashToCurveG2 hashes a message to a point of G2 on Altbn128 following
+// the BN254G2_XMD:SHA-256_SVDW_RO_ suite of RFC 9380, with the given domain
+// separation tag. The return value is the affine coordinates [x0, x1, y0, y1],
+// where X = x0 * i + x1, and Y = y0 * i + y1.
+func AltbnHashToCurveG2(message []byte, dst []byte) []*big.Int {
+	return hashToTwistSvdw(message, dst).toAffineCoords()
+}
+
+// AltbnHashToG2WithDST returns a function which hashes messages to G2 on
+// Altbn128 with AltbnHashToCurveG2, under the given domain separation tag.
+func AltbnHashToG2WithDST(dst []byte) func([]byte) Point {
+	dstCopy := append([]byte{}, dst...)
+	return func(message []byte) Point {
+		pt, _ := Altbn128.MakeG2Point(AltbnHashToCurveG2(message, dstCopy), false)
+		return pt
+	}
+}
+
+// svdwParams holds the constants for the SVDW map on a curve y^2 = x^3 + ax + b.
+// They are derived from z as described in RFC 9380 section 6.6.1.
+type svdwParams struct {
+	z  *big.Int
+	c1 *big.Int // g(z)
+	c2 *big.Int // -z / 2
+	c3 *big.Int // sqrt(-g(z) * (3z^2 + 4a)), with sgn0(c3) = 0
+	c4 *big.Int // -4g(z) / (3z^2 + 4a)
+}
+
+func newSvdwParams(curve CurveSystem, z *big.Int) *svdwParams {
+	q := curve.GetG1Q()
+	gz := curve.g1XToYSquared(z)
+	gz.Add(gz, new(big.Int).Mul(curve.getG1A(), z))
+	gz.Mod(gz, q)
+
+	// 3z^2 + 4a
+	denom := new(big.Int).Mul(z, z)
+	denom.Mul(denom, three)
+	denom.Add(denom, new(big.Int).Mul(curve.getG1A(), four))
+	denom.Mod(denom, q)
+
+	c2 := new(big.Int).Neg(z)
+	c2.Mul(c2, new(big.Int).ModInverse(two, q))
+	c2.Mod(c2, q)
+
+	c3 := new(big.Int).Mul(gz, denom)
+	c3.Neg(c3)
+	c3.Mod(c3, q)
+	c3 = calcQuadRes(c3, q)
+	if sgn0(c3) == 1 {
+		c3.Sub(q, c3)
+	}
+
+	c4 := new(big.Int).Mul(gz, four)
+	c4.Neg(c4)
+	c4.Mul(c4, new(big.Int).ModInverse(denom, q))
+	c4.Mod(c4, q)
+
+	return &svdwParams{new(big.Int).Mod(z, q), gz, c2, c3, c4}
+}
+
+// hashToCurveSvdw implements hash_to_curve from RFC 9380 with the SVDW map.
+func hashToCurveSvdw(curve CurveSystem, params *svdwParams, msg []byte, dst []byte) Point {
+	u := hashToField(curve, msg, dst, 2)
+	q0 := mapToCurveSvdw(curve, params, u[0])
+	q1 := mapToCurveSvdw(curve, params, u[1])
+	r, _ := q0.Add(q1)
+	return r.Mul(curve.getG1Cofactor())
+}
+
+// mapToCurveSvdw is the straight-line SVDW map from RFC 9380 appendix F.1.
+func mapToCurveSvdw(curve CurveSystem, params *svdwParams, u *big.Int) Point {
+	q := curve.GetG1Q()
+	tv1 := new(big.Int).Mul(u, u)
+	tv1.Mul(tv1, params.c1)
+	tv1.Mod(tv1, q)
+	tv2 := new(big.Int).Add(one, tv1)
+	tv2.Mod(tv2, q)
+	tv1.Sub(one, tv1)
+	tv1.Mod(tv1, q)
+	tv3 := new(big.Int).Mul(tv1, tv2)
+	tv3 = inv0(tv3.Mod(tv3, q), q)
+	tv4 := new(big.Int).Mul(u, tv1)
+	tv4.Mul(tv4, tv3)
+	tv4.Mod(tv4, q)
+	tv4.Mul(tv4, params.c3)
+	tv4.Mod(tv4, q)
+
+	x1 := new(big.Int).Sub(params.c2, tv4)
+	x1.Mod(x1, q)
+	e1 := isQuadRes(svdwG(curve, x1), q)
+
+	x2 := new(big.Int).Add(params.c2, tv4)
+	x2.Mod(x2, q)
+	e2 := isQuadRes(svdwG(curve, x2), q) && !e1
+
+	x3 := new(big.Int).Mul(tv2, tv2)
+	x3.Mul(x3, tv3)
+	x3.Mod(x3, q)
+	x3.Mul(x3, x3)
+	x3.Mul(x3, params.c4)
+	x3.Add(x3, params.z)
+	x3.Mod(x3, q)
+
+	x := cmov(x3, x1, e1)
+	x = cmov(x, x2, e2)
+	y := calcQuadRes(svdwG(curve, x), q)
+	negY := new(big.Int).Sub(q, y)
+	negY.Mod(negY, q)
+	y = cmov(negY, y, sgn0(u) == sgn0(y))
+	// Check is set to false since its guaranteed to be on the curve
+	pt, _ := curve.MakeG1Point([]*big.Int{x, y}, false)
+	return pt
+}
+
+// svdwG returns g(x) = x^3 + ax + b.
+func svdwG(curve CurveSystem, x *big.Int) *big.Int {
+	gx := curve.g1XToYSquared(x)
+	gx.Add(gx, new(big.Int).Mul(curve.getG1A(), x))
+	return gx.Mod(gx, curve.GetG1Q())
+}
+
+// svdwParamsG2 holds the constants of svdwParams for the twist of Altbn128,
+// y^2 = x^3 + b / xi, over Fp2. Since a = 0, 3z^2 + 4a is 3z^2.
+type svdwParamsG2 struct {
+	z, c1, c2, c3, c4 *complexNum
+}
+
+func newAltbnSvdwParamsG2(z *complexNum) *svdwParamsG2 {
+	q := altbnG1Q
+	gz := altbnG2XToYSquared(z)
+	denom := getComplexZero().Square(z, q)
+	denom.Mul(denom, &complexNum{zero, three}, q)
+
+	c2 := getComplexZero().Mul(z, &complexNum{zero, new(big.Int).ModInverse(two, q)}, q)
+	c2.Sub(getComplexZero(), c2, q)
+
+	c3 := getComplexZero().Mul(gz, denom, q)
+	c3 = calcComplexQuadRes(c3.Sub(getComplexZero(), c3, q), q)
+	if sgn0Fp2(c3) == 1 {
+		c3.Sub(getComplexZero(), c3, q)
+	}
+
+	c4 := getComplexZero().Mul(gz, &complexNum{zero, four}, q)
+	c4.Sub(getComplexZero(), c4, q)
+	c4.Mul(c4, getComplexZero().Inverse(denom, q), q)
+
+	return &svdwParamsG2{z, gz, c2, c3, c4}
+}
+
+// hashToTwistSvdw implements hash_to_curve from RFC 9380 for G2 of Altbn128,
+// with the SVDW map to the twist. Each element of Fp2 is made of two elements
+// of hash_to_field, with the real part first.
+func hashToTwistSvdw(msg []byte, dst []byte) *altbnTwistPoint {
+	u := hashToField(Altbn128, msg, dst, 4)
+	q0 := mapToTwistSvdw(altbnSvdwParamsG2, &complexNum{u[1], u[0]})
+	q1 := mapToTwistSvdw(altbnSvdwParamsG2, &complexNum{u[3], u[2]})
+	return q0.add(q1).clearCofactor()
+}
+
+// mapToTwistSvdw is mapToCurveSvdw over Fp2, for the twist of Altbn128.
+func mapToTwistSvdw(params *svdwParamsG2, u *complexNum) *altbnTwistPoint {
+	q := altbnG1Q
+	complexOne := &complexNum{zero, one}
+	tv1 := getComplexZero().Square(u, q)
+	tv1.Mul(tv1, params.c1, q)
+	tv2 := getComplexZero().Add(complexOne, tv1, q)
+	tv1.Sub(complexOne, tv1, q)
+	tv3 := inv0Fp2(getComplexZero().Mul(tv1, tv2, q), q)
+	tv4 := getComplexZero().Mul(u, tv1, q)
+	tv4.Mul(tv4, tv3, q)
+	tv4.Mul(tv4, params.c3, q)
+
+	x1 := getComplexZero().Sub(params.c2, tv4, q)
+	e1 := isSquareFp2(altbnG2XToYSquared(x1), q)
+
+	x2 := getComplexZero().Add(params.c2, tv4, q)
+	e2 := isSquareFp2(altbnG2XToYSquared(x2), q) && !e1
+
+	x3 := getComplexZero().Square(tv2, q)
+	x3.Mul(x3, tv3, q)
+	x3.Square(x3, q)
+	x3.Mul(x3, params.c4, q)
+	x3.Add(x3, params.z, q)
+
+	x := cmovFp2(x3, x1, e1)
+	x = cmovFp2(x, x2, e2)
+	y := calcComplexQuadRes(altbnG2XToYSquared(x), q)
+	negY := getComplexZero().Sub(getComplexZero(), y, q)
+	y = cmovFp2(negY, y, sgn0Fp2(u) == sgn0Fp2(y))
+	return newAltbnTwistPoint(x, y)
+}
+
+// sgn0Fp2 is sgn0 for Fp2, as defined in RFC 9380 section 4.1, where the real
+// part is the first coordinate.
+func sgn0Fp2(x *complexNum) uint {
+	zero0 := uint(0)
+	if x.re.Sign() == 0 {
+		zero0 = 1
+	}
+	return x.re.Bit(0) | (zero0 & x.im.Bit(0))
+}
+
+// isSquareFp2 returns whether x is a square in Fp2, which is the case when its
+// norm is a square in Fp.
+func isSquareFp2(x *complexNum, q *big.Int) bool {
+	norm := new(big.Int).Mul(x.re, x.re)
+	norm.Add(norm, new(big.Int).Mul(x.im, x.im))
+	return isQuadRes(norm.Mod(norm, q), q)
+}
+
+// inv0Fp2 returns the multiplicative inverse of x, or zero if x is zero.
+func inv0Fp2(x *complexNum, q *big.Int) *complexNum {
+	if x.IsZero() {
+		return getComplexZero()
+	}
+	return getComplexZero().Inverse(x, q)
+}
+
+// cmovFp2 returns b if c is true, and a otherwise.
+func cmovFp2(a *complexNum, b *complexNum, c bool) *complexNum {
+	if c {
+		return b
+	}
+	return a
+}
+
+// hashToField implements hash_to_field from RFC 9380 for a prime field,
+// with expand_message_xmd using SHA-256 and a security parameter of 128 bits.
+func hashToField(curve CurveSystem, msg []byte, dst []byte, count int) []*big.Int {
+	q := curve.GetG1Q()
+	l := (q.BitLen() + 128 + 7) / 8
+	uniformBytes := expandMessageXmd(msg, dst, count*l)
+	u := make([]*big.Int, count)
+	for i := 0; i < count; i++ {
+		u[i] = new(big.Int).SetBytes(uniformBytes[i*l : (i+1)*l])
+		u[i].Mod(u[i], q)
+	}
+	return u
+}
+
+// expandMessageXmd implements expand_message_xmd from RFC 9380 section 5.3.1
+// with SHA-256. Domain separation tags longer than 255 bytes are first hashed,
+// as described in section 5.3.3. lenInBytes must be at most 8160.
+func expandMessageXmd(msg []byte, dst []byte, lenInBytes int) []byte {
+	if len(dst) > 255 {
+		h := sha256.Sum256(append([]byte("H2C-OVERSIZE-DST-"), dst...))
+		dst = h[:]
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	ell := (lenInBytes + sha256.Size - 1) / sha256.Size
+
+	h := sha256.New()
+	h.Write(make([]byte, sha256.BlockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(lenInBytes >> 8), byte(lenInBytes), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+
+	uniformBytes := make([]byte, 0, ell*sha256.Size)
+	uniformBytes = append(uniformBytes, bi...)
+	for i := 2; i <= ell; i++ {
+		for j := range bi {
+			bi[j] ^= b0[j]
+		}
+		h.Reset()
+		h.Write(bi)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		uniformBytes = append(uniformBytes, bi...)
+	}
+	return uniformBytes[:lenInBytes]
+}
+
+// sgn0 for a prime field, as defined in RFC 9380 section 4.1.
+func sgn0(x *big.Int) uint {
+	return x.Bit(0)
+}
+
+// inv0 returns the multiplicative inverse of x, or zero if x is zero.
+func inv0(x *big.Int, q *big.Int) *big.Int {
+	return new(big.Int).Exp(x, new(big.Int).Sub(q, two), q)
+}
+
+// cmov returns b if c is true, and a otherwise.
+func cmov(a *big.Int, b *big.Int, c bool) *big.Int {
+	if c {
+		return b
+	}
+	return a
+}
+
+var altbnSvdwParams = newSvdwParams(Altbn128, one)
+var altbnSvdwParamsG2 = newAltbnSvdwParamsG2(&complexNum{zero, one})
@@ -0,0 +1,113 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandMessageXmd(t *testing.T) {
+	// Test vectors from RFC 9380 appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	vectors := []struct {
+		msg        string
+		lenInBytes int
+		expected   string
+	}{
+		{"", 0x20, "68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235"},
+		{"abc", 0x20, "d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d605615"},
+		{"", 0x80, "af84c27ccfd45d41914fdff5df25293e221afc53d8ad2ac06d5e3e29485dadbee0d121587713a3e0dd4d5e69e93eb7cd4f5df4cd103e188cf60cb02edc3edf18eda8576c412b18ffb658e3dd6ec849469b979d444cf7b26911a08e63cf31f9dcc541708d3491184472c2c29bb749d4286b004ceb5ee6b9a7fa5b646c993f0ced"},
+		{"abcdef0123456789", 0x80, "ef904a29bffc4cf9ee82832451c946ac3c8f8058ae97d8d629831a74c6572bd9ebd0df635cd1f208e2038e760c4994984ce73f0d55ea9f22af83ba4734569d4bc95e18350f740c07eef653cbb9f87910d833751825f0ebefa1abe5420bb52be14cf489b37fe1a72f7de2d10be453b2c9d9eb20c7e3f6edc5a60629178d9478df"},
+	}
+	for _, v := range vectors {
+		out := expandMessageXmd([]byte(v.msg), dst, v.lenInBytes)
+		assert.Equal(t, v.expected, hex.EncodeToString(out), "expand_message_xmd mismatch on "+v.msg)
+	}
+}
+
+func TestAltbnHashToCurveVectors(t *testing.T) {
+	// Test vectors for the BN254G1_XMD:SHA-256_SVDW_RO_ suite, as produced by
+	// the hash to curve reference implementation.
+	dst := []byte("QUUX-V01-CS02-with-BN254G1_XMD:SHA-256_SVDW_RO_")
+	vectors := []struct {
+		msg  string
+		x, y string
+	}{
+		{"", "0a976ab906170db1f9638d376514dbf8c42aef256a54bbd48521f20749e59e86", "02925ead66b9e68bfc309b014398640ab55f6619ab59bc1fab2210ad4c4d53d5"},
+		{"abc", "23f717bee89b1003957139f193e6be7da1df5f1374b26a4643b0378b5baf53d1", "04142f826b71ee574452dbc47e05bc3e1a647478403a7ba38b7b93948f4e151d"},
+		{"abcdef0123456789", "187dbf1c3c89aceceef254d6548d7163fdfa43084145f92c4c91c85c21442d4a", "0abd99d5b0000910b56058f9cc3b0ab0a22d47cf27615f588924fac1e5c63b4d"},
+		{"q128_" + strings.Repeat("q", 128), "00fe2b0743575324fc452d590d217390ad48e5a16cf051bee5c40a2eba233f5c", "0794211e0cc72d3cbbdf8e4e5cd6e7d7e78d101ff94862caae8acbe63e9fdc78"},
+		{"a512_" + strings.Repeat("a", 512), "01b05dc540bd79fd0fea4fbb07de08e94fc2e7bd171fe025c479dc212a2173ce", "1bf028afc00c0f843d113758968f580640541728cfc6d32ced9779aa613cd9b0"},
+	}
+	for _, v := range vectors {
+		expX, _ := new(big.Int).SetString(v.x, 16)
+		expY, _ := new(big.Int).SetString(v.y, 16)
+		coords := AltbnHashToCurve([]byte(v.msg), dst)
+		assert.True(t, coords[0].Cmp(expX) == 0 && coords[1].Cmp(expY) == 0,
+			"Hash does not match known test vector on "+v.msg)
+		pt := AltbnHashToG1WithDST(dst)([]byte(v.msg))
+		expPt, _ := Altbn128.MakeG1Point([]*big.Int{expX, expY}, true)
+		assert.True(t, pt.Equals(expPt), "AltbnHashToG1WithDST is not consistent with AltbnHashToCurve")
+	}
+}
+
+func TestAltbnHashToCurveDomainSeparation(t *testing.T) {
+	msg := []byte("message")
+	pt1 := AltbnHashToG1WithDST([]byte("DST-A"))(msg)
+	pt2 := AltbnHashToG1WithDST([]byte("DST-B"))(msg)
+	assert.False(t, pt1.Equals(pt2), "Hashes under distinct DSTs are equal")
+	long := []byte(strings.Repeat("d", 300))
+	pt3 := AltbnHashToG1WithDST(long)(msg)
+	pt4 := AltbnHashToG1WithDST(long)(msg)
+	assert.True(t, pt3.Equals(pt4), "Hashing with an oversized DST is not deterministic")
+}
+
+// fp2FromHex parses an element of Fp2 written as "re,im" in hexadecimal.
+func fp2FromHex(s string) (re, im *big.Int) {
+	parts := strings.Split(s, ",")
+	re, _ = new(big.Int).SetString(strings.TrimPrefix(parts[0], "0x"), 16)
+	im, _ = new(big.Int).SetString(strings.TrimPrefix(parts[1], "0x"), 16)
+	return re, im
+}
+
+func TestAltbnHashToCurveG2Vectors(t *testing.T) {
+	// Test vectors for the BN254G2_XMD:SHA-256_SVDW_RO_ suite, as produced by
+	// gnark-crypto. Elements of Fp2 are written with the real part first.
+	dst := []byte("QUUX-V01-CS02-with-BN254G2_XMD:SHA-256_SVDW_RO_")
+	vectors := []struct {
+		msg  string
+		x, y string
+	}{
+		{"", "0x1192005a0f121921a6d5629946199e4b27ff8ee4d6dd4f9581dc550ade851300,0x1747d950a6f23c16156e2171bce95d1189b04148ad12628869ed21c96a8c9335",
+			"0x498f6bb5ac309a07d9a8b88e6ff4b8de0d5f27a075830e1eb0e68ea318201d8,0x2c9755350ca363ef2cf541005437221c5740086c2e909b71d075152484e845f4"},
+		{"abc", "0x16c88b54eec9af86a41569608cd0f60aab43464e52ce7e6e298bf584b94fccd2,0xb5db3ca7e8ef5edf3a33dfc3242357fbccead98099c3eb564b3d9d13cba4efd",
+			"0x1c42ba524cb74db8e2c680449746c028f7bea923f245e69f89256af2d6c5f3ac,0x22d02d2da7f288545ff8789e789902245ab08c6b1d253561eec789ec2c1bd630"},
+		{"abcdef0123456789", "0x1435fd84aa43c699230e371f6fea3545ce7e053cbbb06a320296a2b81efddc70,0x2a8a360585b6b05996ef69c3c09b2c6fb17afe2b1e944f07559c53178eabf171",
+			"0x2820188dcdc13ffdca31694942418afa1d6dfaaf259d012fab4da52b0f592e38,0x142f08e2441ec431defc24621b73cfe0252d19b243cb55b84bdeb85de039207a"},
+		{"q128_" + strings.Repeat("q", 128), "0x2cffc213fb63d00d923cb22cda5a2904837bb93a2fe6e875c532c51744388341,0x2718ef38d1bc4347f0266c774c8ef4ee5fa7056cc27a4bd7ecf7a888efb95b26",
+			"0x232553f728341afa64ce66d00535764557a052e38657594e10074ad28728c584,0x2206ec0a9288f31ed78531c37295df3b56c42a1284443ee9893adb1521779001"},
+		{"a512_" + strings.Repeat("a", 512), "0x242a0a159f36f87065e7c5170426012087023165ce47a486e53d6e2845ca625a,0x17f9f6292998cf18ccc155903c1fe6b6465d40c794a3e1ed644a4182ad639f4a",
+			"0x2dc5b7b65c9c79e6ef4afab8fbe3083c66d4ce31c78f6621ece17ecc892cf4b3,0x18ef4886c818f01fdf309bc9a46dd904273917f85e74ecd0de62460a68122037"},
+	}
+	for _, v := range vectors {
+		xRe, xIm := fp2FromHex(v.x)
+		yRe, yIm := fp2FromHex(v.y)
+		expected := []*big.Int{xIm, xRe, yIm, yRe}
+		coords := AltbnHashToCurveG2([]byte(v.msg), dst)
+		for i := range coords {
+			assert.Zero(t, coords[i].Cmp(expected[i]), "Hash does not match known test vector on "+v.msg)
+		}
+		expPt, ok := Altbn128.MakeG2Point(expected, true)
+		assert.True(t, ok, "The test vector is not in G2")
+		assert.True(t, AltbnHashToG2WithDST(dst)([]byte(v.msg)).Equals(expPt),
+			"AltbnHashToG2WithDST is not consistent with AltbnHashToCurveG2")
+	}
+	assert.False(t, AltbnHashToG2WithDST([]byte("DST-A"))(nil).Equals(AltbnHashToG2WithDST([]byte("DST-B"))(nil)),
+		"Hashes under distinct DSTs are equal")
+}
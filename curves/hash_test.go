@@ -0,0 +1,136 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcQuadRes(t *testing.T) {
+	ed25519Q := new(big.Int).Sub(new(big.Int).Lsh(one, 255), big.NewInt(19))
+	primes := []*big.Int{
+		altbnG1Q,            // 3 mod 4
+		bls12381G1Q,         // 3 mod 4
+		ed25519Q,            // 5 mod 8
+		altbnG1Order,        // 1 mod 8, with q - 1 divisible by 2^28
+		bls12381G1Order,     // 1 mod 8, with q - 1 divisible by 2^32
+		big.NewInt(13),      // 5 mod 8
+		big.NewInt(97),      // 1 mod 8
+		big.NewInt(7340033), // 7 * 2^20 + 1
+	}
+	for _, q := range primes {
+		assert.Equal(t, 0, calcQuadRes(zero, q).Sign(), "square root of zero is not zero")
+		for i := 0; i < 20; i++ {
+			ySqr := randSquare(q, rand.Reader)
+			root := calcQuadRes(ySqr, q)
+			rootSqr := new(big.Int).Exp(root, two, q)
+			assert.Equal(t, 0, rootSqr.Cmp(ySqr), "incorrect square root mod "+q.String())
+		}
+		nonResidue := big.NewInt(2)
+		for isQuadRes(nonResidue, q) {
+			nonResidue.Add(nonResidue, one)
+		}
+		root := calcQuadRes(nonResidue, q)
+		rootSqr := new(big.Int).Exp(root, two, q)
+		assert.NotEqual(t, 0, rootSqr.Cmp(nonResidue), "non-residue has a square root mod "+q.String())
+	}
+}
+
+// TestConstantTimeHelpers checks the helpers of the blinded Fouque-Tibouchi
+// encoding against parity and isQuadRes.
+func TestConstantTimeHelpers(t *testing.T) {
+	for _, q := range []*big.Int{altbnG1Q, bls12381G1Q, big.NewInt(13)} {
+		size := (q.BitLen() + 7) / 8
+		half := new(big.Int).Rsh(q, 1)
+		values := []*big.Int{zero, one, half, new(big.Int).Add(half, one), new(big.Int).Sub(q, one)}
+		for i := 0; i < 20; i++ {
+			x, _ := rand.Int(rand.Reader, q)
+			values = append(values, x)
+		}
+		for _, x := range values {
+			expected := 0
+			if parity(x, q) {
+				expected = 1
+			}
+			assert.Equal(t, expected, ctParity(x, q, size), "parity of "+x.String())
+			expected = 0
+			if isQuadRes(x, q) {
+				expected = 1
+			}
+			assert.Equal(t, expected, ctIsQuadRes(x, q), "quadratic residuosity of "+x.String())
+			y := new(big.Int).Sub(q, x)
+			assert.Equal(t, 0, ctSelect(x, y, 0, size).Cmp(x))
+			assert.Equal(t, 0, ctSelect(x, y, 1, size).Cmp(y))
+		}
+	}
+}
+
+func TestCalcComplexQuadRes(t *testing.T) {
+	// Fq[i]/(i^2 + 1) is only a field for q = 3 mod 4, see TestCalcExtQuadRes
+	primes := []*big.Int{altbnG1Q, bls12381G1Q, big.NewInt(103)}
+	for _, q := range primes {
+		for i := 0; i < 20; i++ {
+			re, _ := rand.Int(rand.Reader, q)
+			im, _ := rand.Int(rand.Reader, q)
+			ySqr := getComplexZero().Square(&complexNum{im, re}, q)
+			root := calcComplexQuadRes(ySqr, q)
+			rootSqr := getComplexZero().Square(root, q)
+			assert.True(t, rootSqr.Equals(ySqr), "incorrect square root in Fq2 mod "+q.String())
+		}
+	}
+}
+
+// extSquare returns (x0 + x1 * u)^2 in Fq[u]/(u^2 - beta).
+func extSquare(x0, x1, beta, q *big.Int) (*big.Int, *big.Int) {
+	a0 := new(big.Int).Mul(x0, x0)
+	a0.Add(a0, new(big.Int).Mul(beta, new(big.Int).Mul(x1, x1)))
+	a1 := new(big.Int).Mul(x0, x1)
+	a1.Lsh(a1, 1)
+	return a0.Mod(a0, q), a1.Mod(a1, q)
+}
+
+func TestCalcExtQuadRes(t *testing.T) {
+	ed25519Q := new(big.Int).Sub(new(big.Int).Lsh(one, 255), big.NewInt(19))
+	primes := []*big.Int{
+		altbnG1Q,            // 3 mod 4
+		big.NewInt(103),     // 3 mod 4
+		ed25519Q,            // 5 mod 8
+		altbnG1Order,        // 1 mod 8
+		bls12381G1Order,     // 1 mod 8
+		big.NewInt(13),      // 5 mod 8
+		big.NewInt(97),      // 1 mod 8
+		big.NewInt(7340033), // 7 * 2^20 + 1
+	}
+	for _, q := range primes {
+		beta := big.NewInt(2)
+		for isQuadRes(beta, q) {
+			beta.Add(beta, one)
+		}
+		inputs := make([][2]*big.Int, 0, 22)
+		for i := 0; i < 20; i++ {
+			x0, _ := rand.Int(rand.Reader, q)
+			x1, _ := rand.Int(rand.Reader, q)
+			a0, a1 := extSquare(x0, x1, beta, q)
+			inputs = append(inputs, [2]*big.Int{a0, a1})
+		}
+		// The squares of elements of Fq and of Fq * u
+		b, _ := rand.Int(rand.Reader, q)
+		a0, _ := extSquare(b, zero, beta, q)
+		inputs = append(inputs, [2]*big.Int{a0, big.NewInt(0)})
+		a0, _ = extSquare(zero, b, beta, q)
+		inputs = append(inputs, [2]*big.Int{a0, big.NewInt(0)})
+
+		for _, a := range inputs {
+			x0, x1, den := calcExtQuadResUnscaled(a[0], a[1], beta, q)
+			x1.Mul(x1, new(big.Int).ModInverse(den, q))
+			r0, r1 := extSquare(x0, x1.Mod(x1, q), beta, q)
+			assert.True(t, r0.Cmp(a[0]) == 0 && r1.Cmp(a[1]) == 0,
+				"incorrect square root in Fq2 mod "+q.String())
+		}
+	}
+}
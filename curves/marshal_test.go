@@ -0,0 +1,286 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"bytes"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// encodings returns the compressed and uncompressed encodings of random points
+// and of the points at infinity, in G1 and in G2.
+func encodings(curve CurveSystem) (g1 [][]byte, g2 [][]byte) {
+	pts1 := []Point{curve.GetG1(), curve.GetG1Infinity()}
+	pts2 := []Point{curve.GetG2(), curve.GetG2Infinity()}
+	for i := 0; i < 4; i++ {
+		k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+		pts1 = append(pts1, curve.GetG1().Mul(k))
+		pts2 = append(pts2, curve.GetG2().Mul(k))
+	}
+	for i := range pts1 {
+		g1 = append(g1, pts1[i].Marshal(), pts1[i].MarshalUncompressed())
+		g2 = append(g2, pts2[i].Marshal(), pts2[i].MarshalUncompressed())
+	}
+	return
+}
+
+func TestStrictUnmarshal(t *testing.T) {
+	for _, curve := range curves {
+		g1, g2 := encodings(curve)
+		for _, data := range g1 {
+			_, ok := curve.UnmarshalG1Strict(data)
+			assert.True(t, ok, curve.Name()+" rejected a canonical G1 encoding")
+			// Setting any bit which isn't part of a coordinate, or flipping a flag,
+			// gives a non-canonical encoding
+			for _, bit := range []byte{0x80, 0x40, 0x20} {
+				altered := append([]byte{}, data...)
+				altered[0] ^= bit
+				if pt, ok := curve.UnmarshalG1Strict(altered); ok {
+					assert.True(t, bytes.Equal(altered, pt.MarshalUncompressed()) ||
+						bytes.Equal(altered, pt.Marshal()), curve.Name()+" accepted a non-canonical G1 encoding")
+				}
+			}
+		}
+		for _, data := range g2 {
+			_, ok := curve.UnmarshalG2Strict(data)
+			assert.True(t, ok, curve.Name()+" rejected a canonical G2 encoding")
+		}
+	}
+
+	// Altbn128 specific non-canonical encodings
+	x := pad32Bytes(altbnG1Q.Bytes())
+	_, ok := Altbn128.UnmarshalG1Strict(append(x, pad32Bytes(two.Bytes())...))
+	assert.False(t, ok, "accepted a coordinate equal to q")
+	_, ok = Altbn128.UnmarshalG1Strict(make([]byte, 32))
+	assert.False(t, ok, "accepted x = 0 as infinity without the infinity flag")
+	_, ok = Altbn128.UnmarshalG1(make([]byte, 32))
+	assert.True(t, ok, "lenient decoding rejected x = 0 as infinity")
+	inf := Altbn128.GetG1Infinity().Marshal()
+	inf[31] = 1
+	_, ok = Altbn128.UnmarshalG1Strict(inf)
+	assert.False(t, ok, "accepted an infinity encoding with a non-zero coordinate")
+	g2 := Altbn128.GetG2().Marshal()
+	g2[32] ^= 0x80
+	_, ok = Altbn128.UnmarshalG2Strict(g2)
+	assert.False(t, ok, "accepted a G2 encoding with inconsistent sign bits")
+}
+
+func TestLenientUnmarshalInfinity(t *testing.T) {
+	// The infinity flag is only accepted when every other bit is zero
+	g1 := bytes.Repeat([]byte{0xab}, 32)
+	g1[0] = 0x7f
+	_, ok := Altbn128.UnmarshalG1(g1)
+	assert.False(t, ok, "accepted garbage with the infinity flag as G1 infinity")
+	g2 := bytes.Repeat([]byte{0xcd}, 64)
+	g2[0] = 0x55
+	_, ok = Altbn128.UnmarshalG2(g2)
+	assert.False(t, ok, "accepted garbage with the infinity flag as G2 infinity")
+	pts, errs := Altbn128.UnmarshalG2Batch([][]byte{Altbn128.GetG2().Marshal(), g2})
+	assert.True(t, errs[0] == nil && errs[1] != nil && pts[1] == nil,
+		"batch decoding accepted garbage with the infinity flag")
+
+	inf, ok := Altbn128.UnmarshalG1(Altbn128.GetG1Infinity().Marshal())
+	assert.True(t, ok && inf.IsInfinity(), "rejected the G1 infinity encoding")
+	inf, ok = Altbn128.UnmarshalG2(Altbn128.GetG2Infinity().Marshal())
+	assert.True(t, ok && inf.IsInfinity(), "rejected the G2 infinity encoding")
+	pts, errs = Altbn128.UnmarshalG2Batch([][]byte{Altbn128.GetG2Infinity().Marshal()})
+	assert.True(t, errs[0] == nil && pts[0].IsInfinity(), "batch decoding rejected the G2 infinity encoding")
+}
+
+func TestMarshalCompressedStandard(t *testing.T) {
+	for _, curve := range curves {
+		pts1 := []Point{curve.GetG1(), curve.GetG1Infinity()}
+		pts2 := []Point{curve.GetG2(), curve.GetG2Infinity()}
+		for i := 0; i < 8; i++ {
+			k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			pts1 = append(pts1, curve.GetG1().Mul(k))
+			pts2 = append(pts2, curve.GetG2().Mul(k))
+		}
+		for i := range pts1 {
+			data := pts1[i].MarshalCompressedStandard()
+			pt, ok := curve.UnmarshalG1CompressedStandard(data)
+			assert.True(t, ok && pt.Equals(pts1[i]), curve.Name()+" standard G1 encoding does not round trip")
+			data = pts2[i].MarshalCompressedStandard()
+			pt, ok = curve.UnmarshalG2CompressedStandard(data)
+			assert.True(t, ok && pt.Equals(pts2[i]), curve.Name()+" standard G2 encoding does not round trip")
+			// The two roots are distinguished by the sign flag
+			neg := pts2[i].Mul(big.NewInt(-1)).MarshalCompressedStandard()
+			assert.True(t, pts2[i].IsInfinity() || neg[0] != data[0])
+			assert.Equal(t, data[1:], neg[1:])
+		}
+	}
+
+	// The generator (1, 2) has the smaller root
+	g1 := Altbn128.GetG1().MarshalCompressedStandard()
+	expected := make([]byte, 32)
+	expected[0], expected[31] = 0x80, 1
+	assert.Equal(t, expected, g1)
+	expected[0] = 0xC0
+	assert.Equal(t, expected, Altbn128.GetG1().Mul(big.NewInt(-1)).MarshalCompressedStandard())
+	expected[0] = 0x40
+	expected[31] = 0
+	assert.Equal(t, expected, Altbn128.GetG1Infinity().MarshalCompressedStandard())
+
+	// Uncompressed flags, x >= q and non-zero data after the infinity flag are rejected
+	g1[0] &= 0x3f
+	_, ok := Altbn128.UnmarshalG1CompressedStandard(g1)
+	assert.False(t, ok)
+	x := pad32Bytes(new(big.Int).Add(altbnG1Q, one).Bytes())
+	x[0] |= 0x80
+	_, ok = Altbn128.UnmarshalG1CompressedStandard(x)
+	assert.False(t, ok)
+	expected[31] = 1
+	_, ok = Altbn128.UnmarshalG1CompressedStandard(expected)
+	assert.False(t, ok)
+	g2 := Altbn128.GetG2Infinity().MarshalCompressedStandard()
+	g2[63] = 1
+	_, ok = Altbn128.UnmarshalG2CompressedStandard(g2)
+	assert.False(t, ok)
+}
+
+func TestUnmarshalDoesNotMutate(t *testing.T) {
+	for _, curve := range curves {
+		g1, g2 := encodings(curve)
+		for _, data := range append(g1, g2...) {
+			cp := append([]byte{}, data...)
+			curve.UnmarshalG1(data)
+			curve.UnmarshalG2(data)
+			curve.UnmarshalG1Strict(data)
+			curve.UnmarshalG2Strict(data)
+			curve.UnmarshalG1CompressedStandard(data)
+			curve.UnmarshalG2CompressedStandard(data)
+			assert.Equal(t, cp, data, curve.Name()+" decoding modified its input")
+		}
+	}
+}
+
+// checkRoundTrip checks that decoding never mutates data, that a strictly decoded
+// point encodes back to data, and that the canonical encoding of a leniently
+// decoded point decodes strictly to the same point.
+func checkRoundTrip(t *testing.T, data []byte, compressedLen int,
+	unmarshal func([]byte) (Point, bool), unmarshalStrict func([]byte) (Point, bool)) {
+	cp := append([]byte{}, data...)
+	pt, ok := unmarshal(data)
+	strictPt, strictOk := unmarshalStrict(data)
+	if !bytes.Equal(cp, data) {
+		t.Fatal("decoding modified its input")
+	}
+	if strictOk {
+		if !ok || !pt.Equals(strictPt) {
+			t.Fatal("strict and lenient decoding disagree")
+		}
+		encoded := strictPt.MarshalUncompressed()
+		if len(data) == compressedLen {
+			encoded = strictPt.Marshal()
+		}
+		if !bytes.Equal(encoded, data) {
+			t.Fatal("strictly decoded point does not encode back to its input")
+		}
+	}
+	if ok {
+		for _, encoded := range [][]byte{pt.Marshal(), pt.MarshalUncompressed()} {
+			if again, ok := unmarshalStrict(encoded); !ok || !again.Equals(pt) {
+				t.Fatal("canonical encoding does not round trip")
+			}
+		}
+	}
+}
+
+func FuzzUnmarshalG1(f *testing.F) {
+	for _, curve := range curves {
+		g1, _ := encodings(curve)
+		for _, data := range g1 {
+			f.Add(data)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, 32, Altbn128.UnmarshalG1, Altbn128.UnmarshalG1Strict)
+		checkRoundTrip(t, data, 48, Bls12381.UnmarshalG1, Bls12381.UnmarshalG1Strict)
+	})
+}
+
+func FuzzUnmarshalG2(f *testing.F) {
+	for _, curve := range curves {
+		_, g2 := encodings(curve)
+		for _, data := range g2 {
+			f.Add(data)
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkRoundTrip(t, data, 64, Altbn128.UnmarshalG2, Altbn128.UnmarshalG2Strict)
+		checkRoundTrip(t, data, 96, Bls12381.UnmarshalG2, Bls12381.UnmarshalG2Strict)
+	})
+}
+
+func TestUnmarshalBatch(t *testing.T) {
+	for _, curve := range curves {
+		g1, g2 := encodings(curve)
+		batches := []struct {
+			data      [][]byte
+			unmarshal func([]byte) (Point, bool)
+			batch     func([][]byte) ([]Point, []error)
+		}{
+			{g1, curve.UnmarshalG1, curve.UnmarshalG1Batch},
+			{g2, curve.UnmarshalG2, curve.UnmarshalG2Batch},
+		}
+		for _, b := range batches {
+			// Invalid encodings are mixed with the valid ones
+			data := append(b.data, nil, make([]byte, 7))
+			for _, valid := range b.data {
+				corrupted := append([]byte{}, valid...)
+				corrupted[len(corrupted)-1] ^= 1
+				data = append(data, corrupted)
+			}
+			cp := make([][]byte, len(data))
+			for i := range data {
+				cp[i] = append([]byte{}, data[i]...)
+			}
+			points, errs := b.batch(data)
+			assert.Equal(t, len(data), len(points))
+			assert.Equal(t, len(data), len(errs))
+			for i := range data {
+				assert.True(t, bytes.Equal(cp[i], data[i]), curve.Name()+" batch decoding modified its input")
+				expected, ok := b.unmarshal(data[i])
+				if !ok {
+					assert.Error(t, errs[i], curve.Name()+" batch decoding accepted an invalid encoding")
+					assert.Nil(t, points[i])
+					continue
+				}
+				assert.NoError(t, errs[i], curve.Name()+" batch decoding rejected a valid encoding")
+				assert.True(t, expected.Equals(points[i]), curve.Name()+" batch decoding differs from decoding each point")
+			}
+		}
+		points, errs := curve.UnmarshalG2Batch(nil)
+		assert.Empty(t, points)
+		assert.Empty(t, errs)
+	}
+}
+
+// BenchmarkUnmarshalG2Batch compares decoding a committee of compressed keys
+// one by one, and as a batch.
+func BenchmarkUnmarshalG2Batch(b *testing.B) {
+	for _, curve := range curves {
+		data := make([][]byte, 64)
+		for i := range data {
+			k, _ := rand.Int(rand.Reader, curve.GetG1Order())
+			data[i] = curve.G2BaseMul(k).Marshal()
+		}
+		b.Run(curve.Name()+"/loop", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, d := range data {
+					curve.UnmarshalG2(d)
+				}
+			}
+		})
+		b.Run(curve.Name()+"/batch", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				curve.UnmarshalG2Batch(data)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// montField is arithmetic modulo an odd prime p < 2^254, in 4 64 bit limbs
+// in Montgomery form. It avoids the allocations and divisions of big.Int, for
+// the few places where this package does long runs of field operations itself.
+// Since p < R / 4, where R = 2^256, sums of two reduced elements don't overflow.
+type montField struct {
+	p    fpMont
+	pInv uint64 // -p^(-1) mod 2^64
+	r2   fpMont // R^2 mod p
+}
+
+// fpMont is x R mod p, as little endian limbs.
+type fpMont [4]uint64
+
+// fp2Mont is re + im i in Fp[i] / (i^2 + 1).
+type fp2Mont struct {
+	re, im fpMont
+}
+
+func newMontField(p *big.Int) *montField {
+	f := &montField{p: montLimbs(p)}
+	// Newton's iteration doubles the number of correct low bits of p^(-1)
+	inv := uint64(1)
+	for i := 0; i < 6; i++ {
+		inv *= 2 - f.p[0]*inv
+	}
+	f.pInv = -inv
+	r2 := new(big.Int).Lsh(one, 512)
+	f.r2 = montLimbs(r2.Mod(r2, p))
+	return f
+}
+
+// montLimbs returns the limbs of 0 <= x < 2^256, without converting to
+// Montgomery form.
+func montLimbs(x *big.Int) fpMont {
+	var z fpMont
+	data := x.Bytes()
+	for i := 0; i < len(data); i++ {
+		z[i/8] |= uint64(data[len(data)-1-i]) << (8 * uint(i%8))
+	}
+	return z
+}
+
+// fromBig returns x R mod p for 0 <= x < p.
+func (f *montField) fromBig(x *big.Int) fpMont {
+	z := montLimbs(x)
+	f.mul(&z, &z, &f.r2)
+	return z
+}
+
+// toBig returns x R^(-1) mod p.
+func (f *montField) toBig(x *fpMont) *big.Int {
+	var z fpMont
+	f.mul(&z, x, &fpMont{1})
+	data := make([]byte, 32)
+	for i := 0; i < len(data); i++ {
+		data[len(data)-1-i] = byte(z[i/8] >> (8 * uint(i%8)))
+	}
+	return new(big.Int).SetBytes(data)
+}
+
+// mul sets z = x y R^(-1) mod p, with the CIOS method from Koc, Acar and
+// Kaliski, "Analyzing and comparing Montgomery multiplication algorithms".
+// Since the top limb of p is less than 2^63 - 1, the carries out of the top
+// limb can be dropped, as described in gnark-crypto's "Faster big-integer
+// modular multiplication for most moduli".
+func (f *montField) mul(z, x, y *fpMont) {
+	var t0, t1, t2, t3 uint64
+	p0, p1, p2, p3, pInv := f.p[0], f.p[1], f.p[2], f.p[3], f.pInv
+	x0, x1, x2, x3 := x[0], x[1], x[2], x[3]
+	for i := 0; i < 4; i++ {
+		yi := y[i]
+		a, lo := madd1(x0, yi, t0)
+		m := lo * pInv
+		c := madd0(m, p0, lo)
+		a, lo = madd2(x1, yi, t1, a)
+		c, t0 = madd2(m, p1, lo, c)
+		a, lo = madd2(x2, yi, t2, a)
+		c, t1 = madd2(m, p2, lo, c)
+		a, lo = madd2(x3, yi, t3, a)
+		c, t2 = madd2(m, p3, lo, c)
+		t3 = c + a
+	}
+	// The result is less than 2p, so at most one subtraction is needed
+	var r0, r1, r2, r3, borrow uint64
+	r0, borrow = bits.Sub64(t0, p0, 0)
+	r1, borrow = bits.Sub64(t1, p1, borrow)
+	r2, borrow = bits.Sub64(t2, p2, borrow)
+	r3, borrow = bits.Sub64(t3, p3, borrow)
+	if borrow != 0 {
+		z[0], z[1], z[2], z[3] = t0, t1, t2, t3
+		return
+	}
+	z[0], z[1], z[2], z[3] = r0, r1, r2, r3
+}
+
+// madd0 returns the high limb of a b + c.
+func madd0(a, b, c uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, carry := bits.Add64(lo, c, 0)
+	return hi + carry
+}
+
+// madd1 returns a b + c as high and low limbs.
+func madd1(a, b, c uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(a, b)
+	lo, carry := bits.Add64(lo, c, 0)
+	return hi + carry, lo
+}
+
+// madd2 returns a b + c + d as high and low limbs.
+func madd2(a, b, c, d uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(a, b)
+	c, carry := bits.Add64(c, d, 0)
+	hi += carry
+	lo, carry = bits.Add64(lo, c, 0)
+	return hi + carry, lo
+}
+
+// add sets z = x + y mod p.
+func (f *montField) add(z, x, y *fpMont) {
+	var s0, s1, s2, s3, carry uint64
+	s0, carry = bits.Add64(x[0], y[0], 0)
+	s1, carry = bits.Add64(x[1], y[1], carry)
+	s2, carry = bits.Add64(x[2], y[2], carry)
+	s3, _ = bits.Add64(x[3], y[3], carry)
+	var r0, r1, r2, r3, borrow uint64
+	r0, borrow = bits.Sub64(s0, f.p[0], 0)
+	r1, borrow = bits.Sub64(s1, f.p[1], borrow)
+	r2, borrow = bits.Sub64(s2, f.p[2], borrow)
+	r3, borrow = bits.Sub64(s3, f.p[3], borrow)
+	if borrow != 0 {
+		z[0], z[1], z[2], z[3] = s0, s1, s2, s3
+		return
+	}
+	z[0], z[1], z[2], z[3] = r0, r1, r2, r3
+}
+
+// sub sets z = x - y mod p.
+func (f *montField) sub(z, x, y *fpMont) {
+	var d0, d1, d2, d3, borrow, carry uint64
+	d0, borrow = bits.Sub64(x[0], y[0], 0)
+	d1, borrow = bits.Sub64(x[1], y[1], borrow)
+	d2, borrow = bits.Sub64(x[2], y[2], borrow)
+	d3, borrow = bits.Sub64(x[3], y[3], borrow)
+	if borrow != 0 {
+		d0, carry = bits.Add64(d0, f.p[0], 0)
+		d1, carry = bits.Add64(d1, f.p[1], carry)
+		d2, carry = bits.Add64(d2, f.p[2], carry)
+		d3, _ = bits.Add64(d3, f.p[3], carry)
+	}
+	z[0], z[1], z[2], z[3] = d0, d1, d2, d3
+}
+
+func (f *montField) fp2FromComplex(x *complexNum) fp2Mont {
+	return fp2Mont{f.fromBig(x.re), f.fromBig(x.im)}
+}
+
+func (f *montField) fp2ToComplex(x *fp2Mont) *complexNum {
+	return &complexNum{f.toBig(&x.im), f.toBig(&x.re)}
+}
+
+func (f *montField) fp2Add(z, x, y *fp2Mont) {
+	f.add(&z.re, &x.re, &y.re)
+	f.add(&z.im, &x.im, &y.im)
+}
+
+func (f *montField) fp2Sub(z, x, y *fp2Mont) {
+	f.sub(&z.re, &x.re, &y.re)
+	f.sub(&z.im, &x.im, &y.im)
+}
+
+// fp2Mul sets z = x y with Karatsuba's method, in 3 multiplications over Fp.
+func (f *montField) fp2Mul(z, x, y *fp2Mont) {
+	var rr, ii, sx, sy fpMont
+	f.mul(&rr, &x.re, &y.re)
+	f.mul(&ii, &x.im, &y.im)
+	f.add(&sx, &x.re, &x.im)
+	f.add(&sy, &y.re, &y.im)
+	f.mul(&z.im, &sx, &sy)
+	f.sub(&z.im, &z.im, &rr)
+	f.sub(&z.im, &z.im, &ii)
+	f.sub(&z.re, &rr, &ii)
+}
+
+// fp2Square sets z = x^2 = (re + im)(re - im) + 2 re im i.
+func (f *montField) fp2Square(z, x *fp2Mont) {
+	var s, d, p fpMont
+	f.add(&s, &x.re, &x.im)
+	f.sub(&d, &x.re, &x.im)
+	f.mul(&p, &x.re, &x.im)
+	f.mul(&z.re, &s, &d)
+	f.add(&z.im, &p, &p)
+}
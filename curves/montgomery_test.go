@@ -0,0 +1,40 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMontField(t *testing.T) {
+	p := altbnG1Q
+	f := newMontField(p)
+	pMinusOne := new(big.Int).Sub(p, one)
+	values := []*big.Int{big.NewInt(0), big.NewInt(1), pMinusOne}
+	for i := 0; i < 10; i++ {
+		x, _ := rand.Int(rand.Reader, p)
+		values = append(values, x)
+	}
+	for _, x := range values {
+		mx := f.fromBig(x)
+		assert.Zero(t, x.Cmp(f.toBig(&mx)), "conversion doesn't round trip")
+		for _, y := range values {
+			my := f.fromBig(y)
+			var z fpMont
+			f.mul(&z, &mx, &my)
+			expected := new(big.Int).Mul(x, y)
+			assert.Zero(t, expected.Mod(expected, p).Cmp(f.toBig(&z)), "wrong product")
+			f.add(&z, &mx, &my)
+			expected.Add(x, y)
+			assert.Zero(t, expected.Mod(expected, p).Cmp(f.toBig(&z)), "wrong sum")
+			f.sub(&z, &mx, &my)
+			expected.Sub(x, y)
+			assert.Zero(t, expected.Mod(expected, p).Cmp(f.toBig(&z)), "wrong difference")
+		}
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"context"
+	"math/big"
+	"math/bits"
+)
+
+// MultiScalarMul computes the sum of scalars[i] * points[i], using the bucket
+// method of Pippenger. The points may all be in G1, or all in G2.
+// The scalars are split into windows of c bits. Within a window, every point is
+// added to the bucket indexed by its c bit digit, and the buckets are combined
+// with a running sum. This takes about (b / c) * (n + 2^c) additions for n
+// points with b bit scalars, instead of n full scalar multiplications.
+// The number of windows is determined by the longest scalar, so short
+// scalars, such as the 128 bit exponents in HAE, only pay for their length.
+// A nil scalar is treated as one, as in ScalePoints, and negative scalars fall
+// back to a regular scalar multiplication. The windows are computed in parallel
+// on the shared executor. If the points can't be added, for instance because
+// some are in G1 and others in G2, nil is returned.
+func MultiScalarMul(points []Point, scalars []*big.Int) Point {
+	if len(points) == 0 || len(points) != len(scalars) {
+		return nil
+	}
+	var sum Point
+	ok := true
+	pts := make([]Point, 0, len(points))
+	ks := make([]*big.Int, 0, len(points))
+	maxBits := 0
+	for i := 0; i < len(points) && ok; i++ {
+		if scalars[i] == nil {
+			sum, ok = addOrSet(sum, points[i])
+		} else if scalars[i].Sign() < 0 {
+			sum, ok = addOrSet(sum, points[i].Mul(scalars[i]))
+		} else if scalars[i].Sign() > 0 {
+			pts = append(pts, points[i])
+			ks = append(ks, scalars[i])
+			if scalars[i].BitLen() > maxBits {
+				maxBits = scalars[i].BitLen()
+			}
+		}
+	}
+
+	if len(pts) > 0 && ok {
+		c := msmWindowSize(len(pts))
+		numWindows := (maxBits + c - 1) / c
+		windowSums := make([]Point, numWindows)
+		err := Parallelize(context.Background(), numWindows, func(w int) error {
+			var windowOk bool
+			windowSums[w], windowOk = msmWindow(pts, ks, w*c, c)
+			if !windowOk {
+				return errIncompatiblePoints
+			}
+			return nil
+		})
+		if err != nil {
+			return nil
+		}
+		// Horner's rule over the windows, from the most significant one down,
+		// multiplying by 2^c with c doublings
+		var acc Point
+		for w := numWindows - 1; w >= 0 && ok; w-- {
+			for j := 0; j < c && acc != nil && ok; j++ {
+				acc, ok = acc.Add(acc)
+			}
+			if ok {
+				acc, ok = addOrSet(acc, windowSums[w])
+			}
+		}
+		if ok {
+			sum, ok = addOrSet(sum, acc)
+		}
+	}
+
+	if !ok {
+		return nil
+	} else if sum == nil {
+		// Every scalar is zero, so return the point at infinity of the right group
+		return points[0].Mul(zero)
+	}
+	return sum
+}
+
+// msmWindow returns the sum of digit_i * pts[i], where digit_i is the c bit
+// digit of ks[i] starting at bit offset. If every digit is zero, it returns nil.
+// The bool is false if two of the points couldn't be added.
+func msmWindow(pts []Point, ks []*big.Int, offset int, c int) (Point, bool) {
+	buckets := make([]Point, 1<<uint(c))
+	ok := true
+	for i := 0; i < len(pts) && ok; i++ {
+		digit := 0
+		for j := c - 1; j >= 0; j-- {
+			digit = (digit << 1) | int(ks[i].Bit(offset+j))
+		}
+		if digit != 0 {
+			buckets[digit], ok = addOrSet(buckets[digit], pts[i])
+		}
+	}
+	// sum_j j * buckets[j] = sum_j (buckets[j] + buckets[j+1] + ... )
+	var running, sum Point
+	for j := len(buckets) - 1; j > 0 && ok; j-- {
+		running, ok = addOrSet(running, buckets[j])
+		if ok {
+			sum, ok = addOrSet(sum, running)
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	return sum, true
+}
+
+// msmWindowSize picks the window size in bits, approximately log2(n) - 2.
+func msmWindowSize(n int) int {
+	c := bits.Len(uint(n)) - 2
+	if c < 2 {
+		return 2
+	} else if c > 16 {
+		return 16
+	}
+	return c
+}
+
+// addOrSet returns a + b, where nil is treated as the identity. The bool is
+// false if a and b can't be added.
+func addOrSet(a Point, b Point) (Point, bool) {
+	if a == nil {
+		return b, true
+	} else if b == nil {
+		return a, true
+	}
+	return a.Add(b)
+}
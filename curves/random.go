@@ -0,0 +1,35 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// deterministicReader is SHAKE256 of a seed, which is safe for concurrent use.
+type deterministicReader struct {
+	mu    sync.Mutex
+	shake sha3.ShakeHash
+}
+
+// NewDeterministicReader returns an endless stream of bytes which only depends
+// on seed, for reproducing keys, coefficients and signatures in tests and
+// simulations. It must never be used to generate real secrets. The stream can
+// be read concurrently, but the bytes each caller gets then depend on the order
+// of the reads.
+func NewDeterministicReader(seed []byte) io.Reader {
+	shake := sha3.NewShake256()
+	shake.Write([]byte("bgls deterministic reader"))
+	shake.Write(seed)
+	return &deterministicReader{shake: shake}
+}
+
+func (r *deterministicReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.shake.Read(p)
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"bytes"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministicReader(t *testing.T) {
+	read := func(r io.Reader) []byte {
+		data := make([]byte, 100)
+		_, err := io.ReadFull(r, data)
+		assert.Nil(t, err)
+		return data
+	}
+	assert.Equal(t, read(NewDeterministicReader([]byte("seed"))), read(NewDeterministicReader([]byte("seed"))))
+	assert.NotEqual(t, read(NewDeterministicReader([]byte("seed"))), read(NewDeterministicReader([]byte("seed2"))))
+	r := NewDeterministicReader([]byte("seed"))
+	assert.NotEqual(t, read(r), read(r), "the reader repeated itself")
+
+	for _, curve := range curves {
+		a, err := RandomScalarFromReader(curve, NewDeterministicReader([]byte("seed")))
+		assert.Nil(t, err)
+		b, _ := RandomScalarFromReader(curve, NewDeterministicReader([]byte("seed")))
+		assert.True(t, a.Equals(b), curve.Name()+" scalars from the same seed differ")
+	}
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r     io.Reader
+	count int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.count, int64(n))
+	return n, err
+}
+
+func TestHashToG1FouqueTibouchiFromReader(t *testing.T) {
+	msg, dst := []byte("message"), []byte("BGLS-TEST-FT")
+	for _, curve := range curves {
+		expected := HashToG1FouqueTibouchi(curve, msg, dst)
+		r := &countingReader{r: NewDeterministicReader([]byte("seed"))}
+		pt := HashToG1FouqueTibouchiFromReader(curve, msg, dst, r)
+		assert.True(t, r.count > 0, curve.Name()+" blinding didn't use the reader")
+		assert.True(t, pt.Equals(expected), curve.Name()+" blinding changed the hash")
+		// A reader which runs out falls back to crypto/rand
+		short := bytes.NewReader(make([]byte, 40))
+		pt = HashToG1FouqueTibouchiFromReader(curve, msg, dst, short)
+		assert.True(t, pt.Equals(expected), curve.Name()+" blinding with a short reader changed the hash")
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// CurveID is a stable numeric identifier for a curve, which is written into
+// envelope encodings. The IDs of the curves in this package never change.
+type CurveID uint16
+
+const (
+	Altbn128ID CurveID = 1
+	Bls12381ID CurveID = 2
+)
+
+var registry = struct {
+	sync.RWMutex
+	byName map[string]CurveSystem
+	byID   map[CurveID]CurveSystem
+	ids    map[CurveSystem]CurveID
+}{byName: map[string]CurveSystem{}, byID: map[CurveID]CurveSystem{}, ids: map[CurveSystem]CurveID{}}
+
+var errCurveRegistered = errors.New("a curve with this name or ID is already registered")
+var errUnknownCurve = errors.New("the curve is not registered")
+
+func init() {
+	Register(Altbn128ID, Altbn128)
+	Register(Bls12381ID, Bls12381)
+}
+
+// Register makes curve available to Lookup, LookupID and the envelope encoding,
+// under curve.Name() and id. It returns an error if either is already taken.
+// Curves defined outside of this package should use IDs of at least 1024.
+func Register(id CurveID, curve CurveSystem) error {
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.byName[curve.Name()]; ok {
+		return errCurveRegistered
+	}
+	if _, ok := registry.byID[id]; ok {
+		return errCurveRegistered
+	}
+	registry.byName[curve.Name()] = curve
+	registry.byID[id] = curve
+	registry.ids[curve] = id
+	return nil
+}
+
+// Lookup returns the registered curve with the given name.
+func Lookup(name string) (CurveSystem, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	curve, ok := registry.byName[name]
+	return curve, ok
+}
+
+// LookupID returns the registered curve with the given ID.
+func LookupID(id CurveID) (CurveSystem, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	curve, ok := registry.byID[id]
+	return curve, ok
+}
+
+// GetCurveID returns the ID under which curve was registered.
+func GetCurveID(curve CurveSystem) (CurveID, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	id, ok := registry.ids[curve]
+	return id, ok
+}
+
+// RegisteredCurves returns the registered curves, ordered by ID.
+func RegisteredCurves() []CurveSystem {
+	registry.RLock()
+	defer registry.RUnlock()
+	ids := make([]int, 0, len(registry.byID))
+	for id := range registry.byID {
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+	result := make([]CurveSystem, len(ids))
+	for i, id := range ids {
+		result[i] = registry.byID[CurveID(id)]
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package curves
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, curve := range curves {
+		found, ok := Lookup(curve.Name())
+		assert.True(t, ok && found == curve, curve.Name()+" is not registered")
+		id, ok := GetCurveID(curve)
+		assert.True(t, ok)
+		found, ok = LookupID(id)
+		assert.True(t, ok && found == curve)
+		assert.NotNil(t, Register(id+100, curve), "registered a curve twice")
+		assert.NotNil(t, Register(CurveID(5000), curve), "registered a name twice")
+	}
+	id, _ := GetCurveID(Altbn128)
+	assert.Equal(t, Altbn128ID, id)
+	id, _ = GetCurveID(Bls12381)
+	assert.Equal(t, Bls12381ID, id)
+	assert.Equal(t, []CurveSystem{Altbn128, Bls12381}, RegisteredCurves())
+	_, ok := Lookup("secp256k1")
+	assert.False(t, ok)
+	_, ok = LookupID(CurveID(5000))
+	assert.False(t, ok)
+}
+
+func TestEnvelope(t *testing.T) {
+	for _, curve := range curves {
+		k, _ := RandomScalar(curve)
+		g1 := curve.G1BaseMul(k.BigInt())
+		g2 := curve.G2BaseMul(k.BigInt())
+		gt, _ := curve.Pair(g1, curve.GetG2())
+		id, _ := GetCurveID(curve)
+
+		for _, pt := range []Point{g1, g2, curve.GetG1Infinity()} {
+			data, err := MarshalPointEnvelope(pt)
+			assert.Nil(t, err)
+			assert.Equal(t, []byte{1, 0, byte(id)}, data[:3])
+			e, err := UnmarshalEnvelope(data)
+			assert.Nil(t, err)
+			assert.True(t, e.Curve == curve && e.Point.Equals(pt), curve.Name()+" point envelope does not round trip")
+		}
+		data, _ := MarshalPointEnvelope(g2)
+		e, _ := UnmarshalEnvelope(data)
+		assert.Equal(t, KindG2, e.Kind)
+
+		data, err := MarshalGTEnvelope(gt)
+		assert.Nil(t, err)
+		e, err = UnmarshalEnvelope(data)
+		assert.True(t, err == nil && e.Kind == KindGT && e.PointT.Equals(gt))
+
+		data, err = MarshalScalarEnvelope(curve, k)
+		assert.Nil(t, err)
+		e, err = UnmarshalEnvelope(data)
+		assert.True(t, err == nil && e.Kind == KindScalar && e.Scalar.Equals(k))
+		_, err = MarshalScalarEnvelope(curve, &Scalar{NewFp(curve, one).e})
+		assert.NotNil(t, err, "accepted a scalar of the wrong field")
+
+		// Corrupted headers and payloads are rejected
+		data, _ = MarshalPointEnvelope(g1)
+		for _, i := range []int{0, 1, 2, 3} {
+			corrupted := append([]byte{}, data...)
+			corrupted[i] ^= 0x40
+			_, err = UnmarshalEnvelope(corrupted)
+			assert.NotNil(t, err)
+		}
+		_, err = UnmarshalEnvelope(data[:len(data)-1])
+		assert.NotNil(t, err)
+		_, err = UnmarshalEnvelope(data[:2])
+		assert.NotNil(t, err)
+	}
+
+	// Envelopes from different curves can't be mixed
+	a, _ := MarshalPointEnvelope(Altbn128.GetG1())
+	b, _ := MarshalPointEnvelope(Altbn128.GetG2())
+	c, _ := MarshalPointEnvelope(Bls12381.GetG1())
+	curve, envelopes, err := UnmarshalEnvelopes([][]byte{a, b})
+	assert.True(t, err == nil && curve == Altbn128 && len(envelopes) == 2)
+	_, _, err = UnmarshalEnvelopes([][]byte{a, b, c})
+	assert.NotNil(t, err, "mixed envelopes from two curves")
+	_, err = MarshalPointEnvelope(nil)
+	assert.NotNil(t, err)
+
+	// Points of an unregistered instance of altbn128 have the same types as
+	// those of Altbn128, but they aren't tagged as Altbn128
+	reference := NewAltbn128(Bn256Reference)
+	_, err = MarshalPointEnvelope(reference.GetG1())
+	assert.NotNil(t, err, "tagged a point of the reference backend as Altbn128")
+	_, err = MarshalPointEnvelope(reference.GetG2())
+	assert.NotNil(t, err, "tagged a point of the reference backend as Altbn128")
+	_, err = MarshalGTEnvelope(reference.GetGT())
+	assert.NotNil(t, err, "tagged an element of the reference backend as Altbn128")
+}
@@ -3,6 +3,7 @@ package dkg
 import (
 	"crypto/rand"
 	"fmt"
+	"io"
 	"math/big"
 
 	. "github.com/orbs-network/bgls/bgls"   // nolint: golint
@@ -12,7 +13,12 @@ import (
 //CoefficientGen generates a coefficient secret (*big.Int)
 //and points (commitments) in G1 and G2
 func CoefficientGen(curve CurveSystem) (*big.Int, Point, Point, error) {
-	x, err := rand.Int(rand.Reader, curve.GetG1Order())
+	return CoefficientGenFromReader(curve, rand.Reader)
+}
+
+//CoefficientGenFromReader is CoefficientGen with the secret read from r
+func CoefficientGenFromReader(curve CurveSystem, r io.Reader) (*big.Int, Point, Point, error) {
+	x, err := rand.Int(r, curve.GetG1Order())
 	if err != nil {
 		return nil, nil, nil, err
 	}
@@ -21,12 +27,31 @@ func CoefficientGen(curve CurveSystem) (*big.Int, Point, Point, error) {
 	return x, g1Commit, g2Commit, nil
 }
 
+//CoefficientGenScalar is CoefficientGen with the secret as a Scalar
+func CoefficientGenScalar(curve CurveSystem) (*Scalar, Point, Point, error) {
+	return CoefficientGenScalarFromReader(curve, rand.Reader)
+}
+
+//CoefficientGenScalarFromReader is CoefficientGenScalar with the secret read from r
+func CoefficientGenScalarFromReader(curve CurveSystem, r io.Reader) (*Scalar, Point, Point, error) {
+	x, err := RandomScalarFromReader(curve, r)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return x, LoadPublicKeyG1Scalar(curve, x), LoadPublicKeyScalar(curve, x), nil
+}
+
 //LoadPublicKeyG1 turns secret key into a public key of type Point1
 func LoadPublicKeyG1(curve CurveSystem, sk *big.Int) Point {
-	pubKey := curve.GetG1().Mul(sk)
+	pubKey := curve.G1BaseMul(sk)
 	return pubKey
 }
 
+//LoadPublicKeyG1Scalar is LoadPublicKeyG1 with a secret key of curve
+func LoadPublicKeyG1Scalar(curve CurveSystem, sk *Scalar) Point {
+	return LoadPublicKeyG1(curve, sk.BigInt())
+}
+
 //GetPrivateCommitment returns a private commitment in the index ind.
 //There should be t+1 coefficients
 func GetPrivateCommitment(curve CurveSystem, ind *big.Int, coefficients []*big.Int) *big.Int {
@@ -43,6 +68,17 @@ func GetPrivateCommitment(curve CurveSystem, ind *big.Int, coefficients []*big.I
 	return sum
 }
 
+//GetPrivateCommitmentScalar is GetPrivateCommitment with scalars of curve.
+//It evaluates the polynomial at ind with Horner's rule.
+func GetPrivateCommitmentScalar(curve CurveSystem, ind *Scalar, coefficients []*Scalar) *Scalar {
+	sum := new(Scalar).Set(coefficients[len(coefficients)-1])
+	for i := len(coefficients) - 2; i >= 0; i-- {
+		sum.Mul(sum, ind)
+		sum.Add(sum, coefficients[i])
+	}
+	return sum
+}
+
 //GetGroupPublicKey turns the public commitments from G2 group of
 //all participants to the group's PK (in G2). The pubCommitG2 is composed
 //of n points each are the commitments to the zero'th coefficient
@@ -54,10 +90,9 @@ func GetGroupPublicKey(curve CurveSystem, pubCommitG2 []Point) Point {
 //VerifyPublicCommitment verifies using pairing that a commitment to a secret (x) using
 //a point on G1 (i.e, x*g1) is the same secret on a committed G2 point (i.e, x*g2).
 func VerifyPublicCommitment(curve CurveSystem, pubCommitG1 Point, pubCommitG2 Point) bool {
-	paired, _ := curve.PairingProduct(
-		[]Point{curve.GetG1().Mul(new(big.Int).SetInt64(-1)), pubCommitG1},
+	return curve.PairingCheck(
+		[]Point{curve.G1BaseMul(new(big.Int).SetInt64(-1)), pubCommitG1},
 		[]Point{pubCommitG2, curve.GetG2()})
-	return curve.GetGTIdentity().Equals(paired)
 }
 
 //VerifyPrivateCommitment verifies the private commitment from some participant (j)
@@ -70,22 +105,26 @@ func VerifyPrivateCommitment(curve CurveSystem, myIndex *big.Int, prvCommit *big
 	return LHS.Equals(RHS)
 }
 
+//VerifyPrivateCommitmentScalar is VerifyPrivateCommitment with scalars of curve
+func VerifyPrivateCommitmentScalar(curve CurveSystem, myIndex *Scalar, prvCommit *Scalar, pubCommitG1 []Point) bool {
+	return VerifyPrivateCommitment(curve, myIndex.BigInt(), prvCommit.BigInt(), pubCommitG1)
+}
+
 //CalculatePrivateCommitment calculates the commitment to the private commitment of
 //participant with index
 //pubCommit (G1/G2) is the public commitments of the participant represented by index
 func CalculatePrivateCommitment(curve CurveSystem, index *big.Int, pubCommit []Point) Point {
-	pubCommitExp := make([]Point, len(pubCommit))
+	scalars := make([]*big.Int, len(pubCommit))
 	j := big.NewInt(0)
-	for i, pubCommit := range pubCommit {
-		scalar := big.NewInt(0).Exp(index, j, curve.GetG1Order())
-		pubCommitExp[i] = pubCommit.Mul(scalar)
+	for i := range pubCommit {
+		scalars[i] = big.NewInt(0).Exp(index, j, curve.GetG1Order())
 		j.Add(j, big.NewInt(1))
 	}
-	return AggregatePoints(pubCommitExp)
+	return MultiScalarMul(pubCommit, scalars)
 }
 
 //GetSecretKey returns the secret key generated after the DKG scheme has done
-//
+//The sum isn't reduced mod the group order, use GetSecretKeyScalar for a reduced key
 func GetSecretKey(prvCommits []*big.Int) *big.Int {
 	sum := big.NewInt(0)
 	for i := 0; i < len(prvCommits); i++ {
@@ -94,6 +133,16 @@ func GetSecretKey(prvCommits []*big.Int) *big.Int {
 	return sum
 }
 
+//GetSecretKeyScalar returns the secret key generated after the DKG scheme has done,
+//reduced mod the group order of curve
+func GetSecretKeyScalar(curve CurveSystem, prvCommits []*Scalar) *Scalar {
+	sum := NewScalar(curve, big.NewInt(0))
+	for i := 0; i < len(prvCommits); i++ {
+		sum.Add(sum, prvCommits[i])
+	}
+	return sum
+}
+
 //GetSpecificPublicKey returns a specific participant (index) public key
 //pubCommitG2 is the public commitments of all praticipants (composed of (t+1)*n points)!
 func GetSpecificPublicKey(curve CurveSystem, index *big.Int, threshold int, pubCommitG2 [][]Point) Point {
@@ -156,12 +205,7 @@ func SignatureReconstruction(curve CurveSystem, sigs []Point, signersIndices []*
 		delta[i].Mod(delta[i], q)
 	}
 
-	sigsExp := make([]Point, t1)
-	for i := 0; i < t1; i++ {
-		sigsExp[i] = sigs[i].Mul(delta[i])
-	}
-
-	return AggregatePoints(sigsExp), nil
+	return MultiScalarMul(sigs, delta), nil
 }
 
 //Encrypt encrypts a big integer
@@ -1,12 +1,13 @@
 package dkg
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
-	"math/rand"
 	"os"
 	"strconv"
 	"strings"
@@ -58,6 +59,12 @@ func getPrCommit() {
 }
 
 func GetCommitDataForAllParticipants(curve CurveSystem, threshold int, n int) (*DataForCommit, error) {
+	return GetCommitDataForAllParticipantsFromReader(curve, threshold, n, rand.Reader)
+}
+
+// GetCommitDataForAllParticipantsFromReader is GetCommitDataForAllParticipants
+// with the coefficients read from r
+func GetCommitDataForAllParticipantsFromReader(curve CurveSystem, threshold int, n int, r io.Reader) (*DataForCommit, error) {
 
 	fmt.Printf("GetCommitDataForAllParticipants() called with threshold=%v n=%v\n", n, threshold)
 
@@ -80,7 +87,7 @@ func GetCommitDataForAllParticipants(curve CurveSystem, threshold int, n int) (*
 		commitPrv := make([]*big.Int, n)
 		for i := 0; i < threshold+1; i++ {
 			var err error
-			coefs[i], commitG1[i], commitG2[i], err = CoefficientGen(curve)
+			coefs[i], commitG1[i], commitG2[i], err = CoefficientGenFromReader(curve, r)
 			if err != nil {
 				return allData, err
 			}
@@ -124,6 +131,11 @@ func VerifyPrvCommitment(curve CurveSystem, threshold int, n int, data *DataForC
 }
 
 func SignAndVerify(curve CurveSystem, threshold int, n int, data *DataForCommit) (bool, error) {
+	return SignAndVerifyFromReader(curve, threshold, n, data, rand.Reader)
+}
+
+// SignAndVerifyFromReader is SignAndVerify with the message read from r
+func SignAndVerifyFromReader(curve CurveSystem, threshold int, n int, data *DataForCommit, r io.Reader) (bool, error) {
 
 	// == Calculate SK, Pks and group PK ==
 	// TODO Should be happen only once, after DKG flow is done, and not for every SignAndVerify()
@@ -175,7 +187,7 @@ func SignAndVerify(curve CurveSystem, threshold int, n int, data *DataForCommit)
 
 	d := make([]byte, 64)
 	var err error
-	_, err = rand.Read(d)
+	_, err = io.ReadFull(r, d)
 	//assert.Nil(t, err, "msg data generation failed")
 	sigs := make([]Point, n)
 	for participant := 0; participant < n; participant++ {
@@ -10,7 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var curves = []CurveSystem{Altbn128}
+var curves = []CurveSystem{Altbn128, Bls12381}
 var threshold = 14
 var n = 22
 
@@ -197,3 +197,79 @@ func TestEncryptionDecryption(t *testing.T) {
 		assert.True(t, dec.Cmp(coef) == 0, "decryption did not return the same encrypted data")
 	}
 }
+
+func TestDKGScalars(t *testing.T) {
+	for _, curve := range curves {
+		coefs := make([]*Scalar, 4)
+		bigCoefs := make([]*big.Int, 4)
+		commitG1 := make([]Point, 4)
+		for i := range coefs {
+			var err error
+			coefs[i], commitG1[i], _, err = CoefficientGenScalar(curve)
+			assert.Nil(t, err, "test data generation failed")
+			bigCoefs[i] = coefs[i].BigInt()
+		}
+		index := NewScalar(curve, big.NewInt(5))
+		prvCommit := GetPrivateCommitmentScalar(curve, index, coefs)
+		assert.Equal(t, GetPrivateCommitment(curve, index.BigInt(), bigCoefs), prvCommit.BigInt())
+		assert.True(t, VerifyPrivateCommitmentScalar(curve, index, prvCommit, commitG1))
+		assert.False(t, VerifyPrivateCommitmentScalar(curve, index, new(Scalar).Add(prvCommit, coefs[0]), commitG1))
+
+		// Unlike GetSecretKey, the sum is reduced
+		sk := GetSecretKeyScalar(curve, coefs)
+		sum := GetSecretKey(bigCoefs)
+		assert.Equal(t, sum.Mod(sum, curve.GetG1Order()), sk.BigInt())
+	}
+}
+
+func TestDKGDeterministic(t *testing.T) {
+	for _, curve := range curves {
+		run := func(seed string) []byte {
+			r := NewDeterministicReader([]byte(seed))
+			data, err := GetCommitDataForAllParticipantsFromReader(curve, 2, 4, r)
+			assert.Nil(t, err)
+			ok, err := SignAndVerifyFromReader(curve, 2, 4, data, r)
+			assert.True(t, ok && err == nil, "DKG with a deterministic reader failed")
+			transcript, err := marshal(data)
+			assert.Nil(t, err)
+			return transcript
+		}
+		assert.Equal(t, run("seed"), run("seed"), curve.Name()+" DKG transcripts from the same seed differ")
+		assert.NotEqual(t, run("seed"), run("seed2"), curve.Name()+" DKG transcripts from different seeds are equal")
+
+		x, g1, g2, _ := CoefficientGenFromReader(curve, NewDeterministicReader([]byte("seed")))
+		s, _, _, _ := CoefficientGenScalarFromReader(curve, NewDeterministicReader([]byte("seed")))
+		assert.Equal(t, x, s.BigInt())
+		assert.True(t, VerifyPublicCommitment(curve, g1, g2))
+	}
+}
+
+func BenchmarkCoefficientGen(b *testing.B) {
+	for _, curve := range curves {
+		b.Run(curve.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CoefficientGen(curve)
+			}
+		})
+	}
+}
+
+// BenchmarkDealing measures the work of one participant in the commit phase,
+// with the threshold and number of participants from TestDKGHappyFlow.
+func BenchmarkDealing(b *testing.B) {
+	for _, curve := range curves {
+		b.Run(curve.Name(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				coefs := make([]*big.Int, threshold+1)
+				commitG1 := make([]Point, threshold+1)
+				commitG2 := make([]Point, threshold+1)
+				for j := range coefs {
+					coefs[j], commitG1[j], commitG2[j], _ = CoefficientGen(curve)
+				}
+				for j := 1; j <= n; j++ {
+					GetPrivateCommitment(curve, big.NewInt(int64(j)), coefs)
+				}
+			}
+		})
+	}
+}
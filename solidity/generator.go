@@ -0,0 +1,277 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// Package solidity generates a Solidity library which verifies the signatures
+// produced by the bgls package on altbn128. Signatures are in G1 and public keys
+// are in G2, and messages are hashed to G1 with the same Keccak256 try and
+// increment method as curves.Altbn128.HashToG1. This means that every signature
+// made with bgls.Sign on altbn128 can be checked on-chain, using the pairing
+// precompile from EIP-197.
+package solidity
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"regexp"
+	"text/template"
+
+	"github.com/orbs-network/bgls/curves"
+)
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+var errInvalidName = errors.New("solidity: invalid library name")
+
+// Generate returns the source of a Solidity library with the given name.
+// The library has the public view functions hashToG1, verifySingle, verifyMulti
+// and verifyAggregate. hashToG1 matches curves.Altbn128.HashToG1, and
+// verifySingle and verifyAggregate match bgls.VerifySingleSignature and
+// bgls.VerifyAggregateSignature. verifyMulti sums the public keys on-chain, and
+// then calls verifySingle. G1 points are passed as uint256[2], and G2 points as
+// uint256[4] in the word order of EIP-197, as returned by curves.AltbnEncodeG1
+// and curves.AltbnEncodeG2.
+func Generate(libraryName string) (string, error) {
+	if !identifier.MatchString(libraryName) {
+		return "", errInvalidName
+	}
+	q := curves.Altbn128.GetG1Q()
+	g2 := curves.Altbn128.GetG2().ToAffineCoords()
+	bRe, bIm := twistB()
+	params := map[string]interface{}{
+		"Name":         libraryName,
+		"Q":            q,
+		"SqrtExponent": new(big.Int).Rsh(new(big.Int).Add(q, big.NewInt(1)), 2),
+		"G2":           g2,
+		"TwistBRe":     bRe,
+		"TwistBIm":     bIm,
+	}
+	var buf bytes.Buffer
+	if err := verifierTemplate.Execute(&buf, params); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// twistB returns the constant 3 / (i + 9) of the twist curve, y^2 = x^3 + b,
+// over Fp2. Since (i + 9)(9 - i) = 82, this is 3 * (9 - i) / 82.
+func twistB() (re *big.Int, im *big.Int) {
+	q := curves.Altbn128.GetG1Q()
+	inv := new(big.Int).ModInverse(big.NewInt(82), q)
+	re = new(big.Int).Mul(big.NewInt(27), inv)
+	re.Mod(re, q)
+	im = new(big.Int).Mul(big.NewInt(-3), inv)
+	im.Mod(im, q)
+	return
+}
+
+var verifierTemplate = template.Must(template.New("verifier").Parse(`// Code generated by github.com/orbs-network/bgls/solidity. DO NOT EDIT.
+
+pragma solidity ^0.5.0;
+pragma experimental ABIEncoderV2;
+
+// {{.Name}} verifies BLS signatures on altbn128 made by github.com/orbs-network/bgls.
+// Signatures are G1 points, given as uint256[2] (x, y). Public keys are G2 points,
+// given as uint256[4] (x_i, x_r, y_i, y_r), where X = x_i * i + x_r. The point at
+// infinity is all zeros.
+library {{.Name}} {
+    uint256 constant Q = {{.Q}};
+    // (Q + 1) / 4, since Q = 3 mod 4
+    uint256 constant SQRT_EXPONENT = {{.SqrtExponent}};
+    uint256 constant G2_XI = {{index .G2 0}};
+    uint256 constant G2_XR = {{index .G2 1}};
+    uint256 constant G2_YI = {{index .G2 2}};
+    uint256 constant G2_YR = {{index .G2 3}};
+    // b = 3 / (i + 9), the constant of the twist curve y^2 = x^3 + b
+    uint256 constant TWIST_B_R = {{.TwistBRe}};
+    uint256 constant TWIST_B_I = {{.TwistBIm}};
+
+    // hashToG1 hashes a message to G1 with try and increment. The x coordinate is
+    // keccak256(counter || message) mod Q for the first counter which gives a point
+    // on the curve, and y is negated if keccak256(255 || message) is odd.
+    function hashToG1(bytes memory message) public view returns (uint256[2] memory) {
+        for (uint256 ctr = 0; ctr < 256; ctr++) {
+            uint256 x = uint256(keccak256(abi.encodePacked(uint8(ctr), message))) % Q;
+            uint256 y2 = addmod(mulmod(mulmod(x, x, Q), x, Q), 3, Q);
+            uint256 y = expMod(y2, SQRT_EXPONENT);
+            if (mulmod(y, y, Q) == y2) {
+                if (uint256(keccak256(abi.encodePacked(uint8(255), message))) % 2 == 1) {
+                    y = Q - y;
+                }
+                return [x, y];
+            }
+        }
+        revert("hashToG1: no point found");
+    }
+
+    // verifySingle checks that e(-H(message), pubKey) * e(sig, g2) = 1.
+    function verifySingle(uint256[2] memory sig, uint256[4] memory pubKey, bytes memory message)
+        public view returns (bool)
+    {
+        if (sig[0] >= Q || sig[1] >= Q) {
+            return false;
+        }
+        uint256[] memory input = new uint256[](12);
+        setPair(input, 0, negate(hashToG1(message)), pubKey);
+        setPair(input, 1, sig, [G2_XI, G2_XR, G2_YI, G2_YR]);
+        return pairingCheck(input);
+    }
+
+    // verifyMulti checks a multi signature on a single message, by summing the
+    // public keys and calling verifySingle. This is vulnerable to the rogue public
+    // key attack, unless the keys have been authenticated.
+    function verifyMulti(uint256[2] memory sig, uint256[4][] memory pubKeys, bytes memory message)
+        public view returns (bool)
+    {
+        if (pubKeys.length == 0) {
+            return false;
+        }
+        uint256[4] memory key = [uint256(0), 0, 0, 0];
+        for (uint256 i = 0; i < pubKeys.length; i++) {
+            if (!isOnTwist(pubKeys[i])) {
+                return false;
+            }
+            key = addG2(key, pubKeys[i]);
+        }
+        return verifySingle(sig, key, message);
+    }
+
+    // verifyAggregate checks that e(H(m_1), key_1) * ... * e(H(m_n), key_n) * e(-sig, g2) = 1.
+    // Duplicate messages are rejected, because of the rogue public key attack.
+    function verifyAggregate(uint256[2] memory sig, uint256[4][] memory pubKeys, bytes[] memory messages)
+        public view returns (bool)
+    {
+        if (pubKeys.length != messages.length || sig[0] >= Q || sig[1] >= Q) {
+            return false;
+        }
+        uint256 n = messages.length;
+        bytes32[] memory digests = new bytes32[](n);
+        for (uint256 i = 0; i < n; i++) {
+            digests[i] = keccak256(messages[i]);
+            for (uint256 j = 0; j < i; j++) {
+                if (digests[i] == digests[j]) {
+                    return false;
+                }
+            }
+        }
+        uint256[] memory input = new uint256[](6 * (n + 1));
+        for (uint256 i = 0; i < n; i++) {
+            setPair(input, i, hashToG1(messages[i]), pubKeys[i]);
+        }
+        setPair(input, n, negate(sig), [G2_XI, G2_XR, G2_YI, G2_YR]);
+        return pairingCheck(input);
+    }
+
+    function negate(uint256[2] memory p) internal pure returns (uint256[2] memory) {
+        return [p[0], (Q - p[1]) % Q];
+    }
+
+    function setPair(uint256[] memory input, uint256 i, uint256[2] memory p1, uint256[4] memory p2)
+        internal pure
+    {
+        input[6 * i] = p1[0];
+        input[6 * i + 1] = p1[1];
+        input[6 * i + 2] = p2[0];
+        input[6 * i + 3] = p2[1];
+        input[6 * i + 4] = p2[2];
+        input[6 * i + 5] = p2[3];
+    }
+
+    // pairingCheck calls the ecPairing precompile, which fails on points outside of G1 or G2.
+    function pairingCheck(uint256[] memory input) internal view returns (bool) {
+        uint256[1] memory output;
+        bool success;
+        assembly {
+            success := staticcall(gas(), 8, add(input, 0x20), mul(mload(input), 0x20), output, 0x20)
+        }
+        return success && output[0] == 1;
+    }
+
+    // expMod returns base^exponent mod Q, using the modexp precompile.
+    function expMod(uint256 base, uint256 exponent) internal view returns (uint256) {
+        uint256[6] memory input;
+        input[0] = 32;
+        input[1] = 32;
+        input[2] = 32;
+        input[3] = base;
+        input[4] = exponent;
+        input[5] = Q;
+        uint256[1] memory output;
+        bool success;
+        assembly {
+            success := staticcall(gas(), 5, input, 0xc0, output, 0x20)
+        }
+        require(success, "modexp failed");
+        return output[0];
+    }
+
+    // Elements of Fp2 are passed as (real, imaginary), with both parts less than Q.
+
+    function fp2Mul(uint256 ar, uint256 ai, uint256 br, uint256 bi) internal pure returns (uint256, uint256) {
+        return (addmod(mulmod(ar, br, Q), Q - mulmod(ai, bi, Q), Q),
+            addmod(mulmod(ar, bi, Q), mulmod(ai, br, Q), Q));
+    }
+
+    function fp2Sub(uint256 ar, uint256 ai, uint256 br, uint256 bi) internal pure returns (uint256, uint256) {
+        return (addmod(ar, Q - br, Q), addmod(ai, Q - bi, Q));
+    }
+
+    // fp2Inv returns 1 / (ar + ai * i) = (ar - ai * i) / (ar^2 + ai^2).
+    function fp2Inv(uint256 ar, uint256 ai) internal view returns (uint256, uint256) {
+        uint256 t = expMod(addmod(mulmod(ar, ar, Q), mulmod(ai, ai, Q), Q), Q - 2);
+        return (mulmod(ar, t, Q), mulmod(Q - ai, t, Q));
+    }
+
+    function isZero(uint256[4] memory p) internal pure returns (bool) {
+        return p[0] == 0 && p[1] == 0 && p[2] == 0 && p[3] == 0;
+    }
+
+    // isOnTwist checks that p is on the twist curve, or is the point at infinity.
+    // It doesn't check that p is in G2, which the pairing precompile does.
+    function isOnTwist(uint256[4] memory p) internal pure returns (bool) {
+        if (p[0] >= Q || p[1] >= Q || p[2] >= Q || p[3] >= Q) {
+            return false;
+        } else if (isZero(p)) {
+            return true;
+        }
+        (uint256 yr, uint256 yi) = fp2Mul(p[3], p[2], p[3], p[2]);
+        (uint256 xr, uint256 xi) = fp2Mul(p[1], p[0], p[1], p[0]);
+        (xr, xi) = fp2Mul(xr, xi, p[1], p[0]);
+        return yr == addmod(xr, TWIST_B_R, Q) && yi == addmod(xi, TWIST_B_I, Q);
+    }
+
+    // addG2 adds two points on the twist curve in affine coordinates.
+    function addG2(uint256[4] memory a, uint256[4] memory b) internal view returns (uint256[4] memory) {
+        if (isZero(a)) {
+            return b;
+        } else if (isZero(b)) {
+            return a;
+        }
+        uint256 lr;
+        uint256 li;
+        if (a[0] == b[0] && a[1] == b[1]) {
+            if (a[2] != b[2] || a[3] != b[3]) {
+                return [uint256(0), 0, 0, 0];
+            }
+            // lambda = 3 x^2 / 2 y
+            (uint256 nr, uint256 ni) = fp2Mul(a[1], a[0], a[1], a[0]);
+            (uint256 dr, uint256 di) = fp2Inv(addmod(a[3], a[3], Q), addmod(a[2], a[2], Q));
+            (lr, li) = fp2Mul(mulmod(nr, 3, Q), mulmod(ni, 3, Q), dr, di);
+        } else {
+            // lambda = (y_b - y_a) / (x_b - x_a)
+            (uint256 nr, uint256 ni) = fp2Sub(b[3], b[2], a[3], a[2]);
+            (uint256 dr, uint256 di) = fp2Sub(b[1], b[0], a[1], a[0]);
+            (dr, di) = fp2Inv(dr, di);
+            (lr, li) = fp2Mul(nr, ni, dr, di);
+        }
+        // x = lambda^2 - x_a - x_b, and y = lambda * (x_a - x) - y_a
+        (uint256 xr, uint256 xi) = fp2Mul(lr, li, lr, li);
+        (xr, xi) = fp2Sub(xr, xi, a[1], a[0]);
+        (xr, xi) = fp2Sub(xr, xi, b[1], b[0]);
+        (uint256 yr, uint256 yi) = fp2Sub(a[1], a[0], xr, xi);
+        (yr, yi) = fp2Mul(lr, li, yr, yi);
+        (yr, yi) = fp2Sub(yr, yi, a[3], a[2]);
+        return [xi, xr, yi, yr];
+    }
+}
+`))
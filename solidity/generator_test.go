@@ -0,0 +1,50 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+package solidity
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate("BGLSVerifier")
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(src, "library BGLSVerifier {"))
+	for _, fn := range []string{"hashToG1", "verifySingle", "verifyMulti", "verifyAggregate"} {
+		assert.True(t, strings.Contains(src, "function "+fn+"("), "missing function "+fn)
+	}
+	for _, c := range append(curves.Altbn128.GetG2().ToAffineCoords(), curves.Altbn128.GetG1Q()) {
+		assert.True(t, strings.Contains(src, "= "+c.String()+";"), "missing constant "+c.String())
+	}
+
+	for _, name := range []string{"", "1Verifier", "BGLS Verifier", "BGLS;"} {
+		_, err = Generate(name)
+		assert.NotNil(t, err, "accepted the library name "+name)
+	}
+}
+
+func TestTwistB(t *testing.T) {
+	// The generator of G2 must satisfy y^2 = x^3 + b over Fp2
+	q := curves.Altbn128.GetG1Q()
+	g2 := curves.Altbn128.GetG2().ToAffineCoords()
+	xi, xr, yi, yr := g2[0], g2[1], g2[2], g2[3]
+	mul := func(ar, ai, br, bi *big.Int) (*big.Int, *big.Int) {
+		re := new(big.Int).Sub(new(big.Int).Mul(ar, br), new(big.Int).Mul(ai, bi))
+		im := new(big.Int).Add(new(big.Int).Mul(ar, bi), new(big.Int).Mul(ai, br))
+		return re.Mod(re, q), im.Mod(im, q)
+	}
+	y2r, y2i := mul(yr, yi, yr, yi)
+	x3r, x3i := mul(xr, xi, xr, xi)
+	x3r, x3i = mul(x3r, x3i, xr, xi)
+	bRe, bIm := twistB()
+	x3r.Add(x3r, bRe).Mod(x3r, q)
+	x3i.Add(x3i, bIm).Mod(x3i, q)
+	assert.Zero(t, y2r.Cmp(x3r))
+	assert.Zero(t, y2i.Cmp(x3i))
+}
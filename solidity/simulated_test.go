@@ -0,0 +1,148 @@
+// Copyright (C) 2018 Authors
+// distributed under Apache 2.0 license
+
+// These tests compile the generated library with solc, deploy it to
+// go-ethereum's simulated backend, and check the on-chain verification against
+// the Go code. They need solc 0.5.x on the PATH, and are skipped without it.
+
+package solidity
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"math/big"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/orbs-network/bgls/bgls"
+	. "github.com/orbs-network/bgls/curves"
+	"github.com/stretchr/testify/assert"
+)
+
+// deploy compiles the generated library and deploys it to a simulated chain.
+func deploy(t *testing.T) *bind.BoundContract {
+	if _, err := exec.LookPath("solc"); err != nil {
+		t.Skip("solc 0.5.x is not on the PATH, so the verifier can't be compiled and deployed")
+	}
+	src, err := Generate("BGLSVerifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	contracts, err := compiler.CompileSolidityString("solc", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contract *compiler.Contract
+	for name, c := range contracts {
+		if strings.HasSuffix(name, ":BGLSVerifier") {
+			contract = c
+		}
+	}
+	if contract == nil {
+		t.Fatal("solc did not output the library")
+	}
+	abiJSON, _ := json.Marshal(contract.Info.AbiDefinition)
+	parsed, err := abi.JSON(bytes.NewReader(abiJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := crypto.GenerateKey()
+	auth := bind.NewKeyedTransactor(key)
+	alloc := core.GenesisAlloc{auth.From: {Balance: new(big.Int).Lsh(big.NewInt(1), 100)}}
+	backend := backends.NewSimulatedBackend(alloc, 10000000)
+	_, _, bound, err := bind.DeployContract(auth, parsed, common.FromHex(contract.Code), backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+	return bound
+}
+
+func g1Words(pt Point) [2]*big.Int {
+	data, _ := AltbnEncodeG1(pt)
+	return [2]*big.Int{new(big.Int).SetBytes(data[:32]), new(big.Int).SetBytes(data[32:])}
+}
+
+func g2Words(pt Point) [4]*big.Int {
+	data, _ := AltbnEncodeG2(pt)
+	var words [4]*big.Int
+	for i := range words {
+		words[i] = new(big.Int).SetBytes(data[32*i : 32*(i+1)])
+	}
+	return words
+}
+
+func call(t *testing.T, verifier *bind.BoundContract, method string, params ...interface{}) bool {
+	var result bool
+	if err := verifier.Call(&bind.CallOpts{}, &result, method, params...); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestSimulatedVerifier(t *testing.T) {
+	verifier := deploy(t)
+	curve := Altbn128
+	N := 5
+	msgs := make([][]byte, N)
+	sks := make([]*big.Int, N)
+	keys := make([]Point, N)
+	keyWords := make([][4]*big.Int, N)
+	for i := 0; i < N; i++ {
+		msgs[i] = make([]byte, 10*i)
+		rand.Read(msgs[i])
+		sks[i], keys[i], _ = bgls.KeyGen(curve)
+		keyWords[i] = g2Words(keys[i])
+	}
+
+	for i := 0; i < N; i++ {
+		var h [2]*big.Int
+		if err := verifier.Call(&bind.CallOpts{}, &h, "hashToG1", msgs[i]); err != nil {
+			t.Fatal(err)
+		}
+		coords := AltbnKeccak3(msgs[i])
+		assert.True(t, h[0].Cmp(coords[0]) == 0 && h[1].Cmp(coords[1]) == 0, "hashToG1 differs from Go")
+
+		sig := bgls.Sign(curve, sks[i], msgs[i])
+		assert.True(t, call(t, verifier, "verifySingle", g1Words(sig), keyWords[i], msgs[i]),
+			"a valid signature was rejected on-chain")
+		tampered, _ := sig.Add(curve.GetG1())
+		assert.False(t, call(t, verifier, "verifySingle", g1Words(tampered), keyWords[i], msgs[i]),
+			"a tampered signature was accepted on-chain")
+		assert.False(t, call(t, verifier, "verifySingle", g1Words(sig), keyWords[(i+1)%N], msgs[i]),
+			"a signature was accepted under the wrong key")
+	}
+
+	sigs := make([]Point, N)
+	for i := 0; i < N; i++ {
+		sigs[i] = bgls.Sign(curve, sks[i], msgs[0])
+	}
+	multiSig := bgls.AggregateSignatures(sigs)
+	assert.True(t, call(t, verifier, "verifyMulti", g1Words(multiSig), keyWords, msgs[0]),
+		"a valid multi signature was rejected on-chain")
+	assert.False(t, call(t, verifier, "verifyMulti", g1Words(multiSig), keyWords[1:], msgs[0]),
+		"a multi signature was accepted with a missing key")
+
+	for i := 0; i < N; i++ {
+		sigs[i] = bgls.Sign(curve, sks[i], msgs[i])
+	}
+	aggSig := bgls.AggregateSignatures(sigs)
+	assert.True(t, call(t, verifier, "verifyAggregate", g1Words(aggSig), keyWords, msgs),
+		"a valid aggregate signature was rejected on-chain")
+	swapped := append([][]byte{msgs[1], msgs[0]}, msgs[2:]...)
+	assert.False(t, call(t, verifier, "verifyAggregate", g1Words(aggSig), keyWords, swapped),
+		"an aggregate signature was accepted with messages swapped")
+	duplicate := append([][]byte{msgs[0]}, msgs[:N-1]...)
+	assert.False(t, call(t, verifier, "verifyAggregate", g1Words(aggSig), keyWords, duplicate),
+		"an aggregate signature was accepted with duplicate messages")
+}